@@ -0,0 +1,18 @@
+package main
+
+import (
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/app"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+// Reconciles webhook subscriptions of all installed stores.
+// Should be run after deploy, that changes app's base URL or registered webhook topics.
+func main() {
+	logger := logging.NewZap("main")
+
+	cfg := config.Get()
+	logger.Info("read config", "config", cfg)
+
+	app.ReconcileWebhooks(cfg)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/app"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+// Seeds development store with products and collections or deletes the seeded ones.
+//
+//	go run ./cmd/seed -shop my-store.myshopify.com
+//	go run ./cmd/seed -shop my-store.myshopify.com -file products.csv
+//	go run ./cmd/seed -shop my-store.myshopify.com -delete 1a2b3c4d
+//	go run ./cmd/seed -shop my-store.myshopify.com -delete all
+func main() {
+	logger := logging.NewZap("main")
+
+	var opts app.SeedOptions
+	flag.StringVar(&opts.StoreName, "shop", "", "store to seed, e.g. my-store.myshopify.com")
+	flag.StringVar(&opts.File, "file", "", "JSON or CSV file with products, embedded fixtures are used if it is empty")
+	flag.StringVar(&opts.Delete, "delete", "", `delete data seeded by the run with this ID or by all runs if it is "all"`)
+	flag.BoolVar(&opts.Force, "force", false, "seed store, which isn't a development one")
+	flag.Parse()
+	if opts.StoreName == "" {
+		logger.Fatal("shop is required")
+	}
+
+	cfg := config.Get()
+	logger.Info("read config", "config", cfg)
+
+	app.Seed(cfg, opts)
+}
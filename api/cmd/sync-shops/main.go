@@ -0,0 +1,18 @@
+package main
+
+import (
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/app"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+// Updates metadata of all installed stores from platform.
+// Should be run once to backfill stores installed before their metadata was stored.
+func main() {
+	logger := logging.NewZap("main")
+
+	cfg := config.Get()
+	logger.Info("read config", "config", cfg)
+
+	app.SyncShops(cfg)
+}
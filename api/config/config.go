@@ -1,19 +1,25 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type (
 	Config struct {
-		App      App
-		Shopify  Shopify
-		HTTP     HTTP
-		Log      Log
-		Postgres Postgres
+		App        App
+		Shopify    Shopify
+		HTTP       HTTP
+		Log        Log
+		Postgres   Postgres
+		Queue      Queue
+		Encryption Encryption
+		Billing    Billing
 	}
 
 	App struct {
@@ -26,6 +32,41 @@ type (
 		ApiKey    string `env:"SHOPIFY_API_KEY" env-default:""`
 		ApiSecret string `env:"SHOPIFY_API_SECRET" env-default:""`
 		Scopes    string `env:"SCOPES" env-default:""`
+		// APIVersion is a version of Admin API used to build every Admin API URL.
+		APIVersion string `env:"SHOPIFY_API_VERSION" env-default:"2024-04"`
+		// HMACMaxAge is the maximum allowed age of the signed request's timestamp, it bounds replaying of signed URLs.
+		HMACMaxAge time.Duration `env:"SHOPIFY_HMAC_MAX_AGE" env-default:"90s"`
+		// OAuthStateTTL is the time during which the OAuth state can be used to complete installation.
+		OAuthStateTTL time.Duration `env:"SHOPIFY_OAUTH_STATE_TTL" env-default:"10m"`
+		// AllowedShopDomains are accepted in addition to myshopify.com, e.g. for dev or test stores.
+		AllowedShopDomains []string `env:"SHOPIFY_ALLOWED_SHOP_DOMAINS" env-separator:"," env-default:""`
+		// WebhookRetention is the time processed webhook IDs are kept to skip duplicate deliveries.
+		WebhookRetention time.Duration `env:"SHOPIFY_WEBHOOK_RETENTION" env-default:"72h"`
+		// RateLimitBucketSize and RateLimitLeakRate describe store's REST API leaky bucket,
+		// they are updated from the call limit header once store responds.
+		RateLimitBucketSize int     `env:"SHOPIFY_RATE_LIMIT_BUCKET_SIZE" env-default:"40"`
+		RateLimitLeakRate   float64 `env:"SHOPIFY_RATE_LIMIT_LEAK_RATE" env-default:"2"`
+		// RateLimitThreshold is a fraction of the bucket, after which requests are slowed down.
+		RateLimitThreshold float64 `env:"SHOPIFY_RATE_LIMIT_THRESHOLD" env-default:"0.8"`
+		// BulkOperationPollInterval is the interval of checking status of running bulk operations.
+		BulkOperationPollInterval time.Duration `env:"SHOPIFY_BULK_OPERATION_POLL_INTERVAL" env-default:"30s"`
+		// BulkOperationProcessTimeout bounds handling of bulk operation's result, the operation is leased to a single worker for this time.
+		// It must exceed the timeout of DownloadBulkOperationResult.
+		BulkOperationProcessTimeout time.Duration `env:"SHOPIFY_BULK_OPERATION_PROCESS_TIMEOUT" env-default:"15m"`
+		// BulkOperationMaxAttempts is a number of times the query of failed bulk operation is run.
+		BulkOperationMaxAttempts int `env:"SHOPIFY_BULK_OPERATION_MAX_ATTEMPTS" env-default:"3"`
+		// MaxRetries is a number of retries of throttled requests.
+		MaxRetries int `env:"SHOPIFY_MAX_RETRIES" env-default:"3"`
+		// OrdersSyncInterval is the interval of fetching orders updated since the previous sync.
+		OrdersSyncInterval time.Duration `env:"SHOPIFY_ORDERS_SYNC_INTERVAL" env-default:"15m"`
+		// CreateProductsConcurrency is a maximum number of products created at once.
+		CreateProductsConcurrency int `env:"SHOPIFY_CREATE_PRODUCTS_CONCURRENCY" env-default:"4"`
+		// RequestTimeout bounds every Admin API operation including its retries, unless it is overridden in OperationTimeouts.
+		// Iterators are bounded per page.
+		RequestTimeout time.Duration `env:"SHOPIFY_REQUEST_TIMEOUT" env-default:"30s"`
+		// OperationTimeouts override RequestTimeout of Platform API operations by their names, e.g. "ReconcileWebhooks:2m,IterateOrders:1m".
+		// DownloadBulkOperationResult is bounded including reading of the whole result, so it defaults to a longer timeout.
+		OperationTimeouts map[string]time.Duration `env:"SHOPIFY_OPERATION_TIMEOUTS" env-default:"DownloadBulkOperationResult:10m"`
 	}
 
 	HTTP struct {
@@ -40,11 +81,75 @@ type (
 		Database string `env:"POSTGRES_DATABASE" env-default:"api"`
 	}
 
+	Queue struct {
+		Workers      int           `env:"QUEUE_WORKERS" env-default:"4"`
+		PollInterval time.Duration `env:"QUEUE_POLL_INTERVAL" env-default:"1s"`
+		MaxAttempts  int           `env:"QUEUE_MAX_ATTEMPTS" env-default:"10"`
+		BackoffBase  time.Duration `env:"QUEUE_BACKOFF_BASE" env-default:"5s"`
+		BackoffMax   time.Duration `env:"QUEUE_BACKOFF_MAX" env-default:"1h"`
+		// LockTimeout is the time after which a job is considered abandoned by its worker and is retried.
+		// Running jobs refresh their locks, so it bounds recovery from a dead worker, not the job's duration.
+		LockTimeout     time.Duration `env:"QUEUE_LOCK_TIMEOUT" env-default:"10m"`
+		ShutdownTimeout time.Duration `env:"QUEUE_SHUTDOWN_TIMEOUT" env-default:"30s"`
+	}
+
+	Encryption struct {
+		// Key is a base64 encoded 32 bytes AES-256 key, used to encrypt customers' personal data at rest,
+		// including orders' emails and compliance requests' payloads.
+		// It is omitted from JSON, so logged config doesn't reveal it.
+		Key string `json:"-" env:"ENCRYPTION_KEY" env-default:""`
+	}
+
+	Billing struct {
+		// Plans are app's subscription plans as JSON array, billing is disabled if there are none.
+		// The first plan is offered to the store after installation.
+		Plans BillingPlans `env:"BILLING_PLANS" env-default:"[]"`
+		// Test marks subscriptions and charges as test ones, which are not billed.
+		Test bool `env:"BILLING_TEST" env-default:"true"`
+	}
+
+	// BillingPlan is a recurring subscription plan with optional usage based charges.
+	BillingPlan struct {
+		Name         string  `json:"name"`
+		Price        float64 `json:"price"`
+		CurrencyCode string  `json:"currencyCode"`
+		// Interval is either EVERY_30_DAYS or ANNUAL.
+		Interval  string `json:"interval"`
+		TrialDays int    `json:"trialDays"`
+		// UsageCap is a maximum amount of usage charges per billing interval, usage charges are disabled if it is zero.
+		UsageCap   float64 `json:"usageCap"`
+		UsageTerms string  `json:"usageTerms"`
+	}
+
+	BillingPlans []BillingPlan
+
 	Log struct {
 		Level string `env:"LOG_LEVEL" env-default:"debug"`
 	}
 )
 
+// SetValue is used to decode plans from JSON environment variable.
+func (p *BillingPlans) SetValue(value string) error {
+	var plans []BillingPlan
+	err := json.Unmarshal([]byte(value), &plans)
+	if err != nil {
+		return fmt.Errorf("failed to decode billing plans: %w", err)
+	}
+
+	*p = plans
+	return nil
+}
+
+// Plan returns plan by its name or nil if it is not found.
+func (p BillingPlans) Plan(name string) *BillingPlan {
+	for i := range p {
+		if p[i].Name == name {
+			return &p[i]
+		}
+	}
+	return nil
+}
+
 var (
 	config Config
 	once   sync.Once
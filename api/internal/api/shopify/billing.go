@@ -0,0 +1,237 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+const appSubscriptionFields = `
+	id
+	name
+	status
+	test
+	trialDays
+	currentPeriodEnd
+	lineItems {
+		id
+		plan {
+			pricingDetails {
+				__typename
+			}
+		}
+	}`
+
+type appSubscriptionNode struct {
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	Status           string     `json:"status"`
+	Test             bool       `json:"test"`
+	TrialDays        int        `json:"trialDays"`
+	CurrentPeriodEnd *time.Time `json:"currentPeriodEnd"`
+	LineItems        []struct {
+		ID   string `json:"id"`
+		Plan struct {
+			PricingDetails struct {
+				Typename string `json:"__typename"`
+			} `json:"pricingDetails"`
+		} `json:"plan"`
+	} `json:"lineItems"`
+}
+
+func (node *appSubscriptionNode) toAppSubscription() *service.AppSubscription {
+	subscription := &service.AppSubscription{
+		ID:               node.ID,
+		Name:             node.Name,
+		Status:           node.Status,
+		Test:             node.Test,
+		TrialDays:        node.TrialDays,
+		CurrentPeriodEnd: node.CurrentPeriodEnd,
+	}
+	for _, lineItem := range node.LineItems {
+		if lineItem.Plan.PricingDetails.Typename == "AppUsagePricing" {
+			subscription.UsageLineItemID = lineItem.ID
+		}
+	}
+	return subscription
+}
+
+const appSubscriptionCreateMutation = `
+mutation appSubscriptionCreate($name: String!, $lineItems: [AppSubscriptionLineItemInput!]!, $returnUrl: URL!, $trialDays: Int, $test: Boolean) {
+	appSubscriptionCreate(name: $name, lineItems: $lineItems, returnUrl: $returnUrl, trialDays: $trialDays, test: $test) {
+		appSubscription {` + appSubscriptionFields + `
+		}
+		confirmationUrl
+		userErrors {
+			field
+			message
+		}
+	}
+}`
+
+type appSubscriptionCreateData struct {
+	AppSubscriptionCreate struct {
+		AppSubscription *appSubscriptionNode `json:"appSubscription"`
+		ConfirmationURL string               `json:"confirmationUrl"`
+		UserErrors      []graphQLUserError   `json:"userErrors"`
+	} `json:"appSubscriptionCreate"`
+}
+
+func (s *shopifyAPI) CreateAppSubscription(ctx context.Context, opts service.CreateAppSubscriptionOptions) (*service.CreatedAppSubscription, error) {
+	logger := s.logger.
+		Named("CreateAppSubscription").
+		WithContext(ctx).
+		With("plan", opts.Plan.Name)
+
+	ctx, cancel := s.withTimeout(ctx, "CreateAppSubscription")
+	defer cancel()
+
+	lineItems := []map[string]interface{}{{
+		"plan": map[string]interface{}{
+			"appRecurringPricingDetails": map[string]interface{}{
+				"price":    money(opts.Plan.Price, opts.Plan.CurrencyCode),
+				"interval": opts.Plan.Interval,
+			},
+		},
+	}}
+	if opts.Plan.UsageCap > 0 {
+		lineItems = append(lineItems, map[string]interface{}{
+			"plan": map[string]interface{}{
+				"appUsagePricingDetails": map[string]interface{}{
+					"cappedAmount": money(opts.Plan.UsageCap, opts.Plan.CurrencyCode),
+					"terms":        opts.Plan.UsageTerms,
+				},
+			},
+		})
+	}
+
+	data, err := graphQL[appSubscriptionCreateData](ctx, s, appSubscriptionCreateMutation, map[string]interface{}{
+		"name":      opts.Plan.Name,
+		"lineItems": lineItems,
+		"returnUrl": opts.ReturnURL,
+		"trialDays": opts.Plan.TrialDays,
+		"test":      opts.Test,
+	})
+	if err != nil {
+		logger.Error("failed to create app subscription", "err", err)
+		return nil, err
+	}
+	err = userErrorsToError(data.AppSubscriptionCreate.UserErrors)
+	if err != nil {
+		logger.Info("app subscription is invalid", "err", err)
+		return nil, err
+	}
+	if data.AppSubscriptionCreate.AppSubscription == nil {
+		return nil, fmt.Errorf("app subscription is missing in response")
+	}
+	logger.Info("created app subscription")
+
+	return &service.CreatedAppSubscription{
+		Subscription:    *data.AppSubscriptionCreate.AppSubscription.toAppSubscription(),
+		ConfirmationURL: data.AppSubscriptionCreate.ConfirmationURL,
+	}, nil
+}
+
+const appSubscriptionQuery = `
+query appSubscription($id: ID!) {
+	node(id: $id) {
+		... on AppSubscription {` + appSubscriptionFields + `
+		}
+	}
+}`
+
+type appSubscriptionData struct {
+	Node *appSubscriptionNode `json:"node"`
+}
+
+func (s *shopifyAPI) GetAppSubscription(ctx context.Context, id string) (*service.AppSubscription, error) {
+	logger := s.logger.
+		Named("GetAppSubscription").
+		WithContext(ctx).
+		With("id", id)
+
+	ctx, cancel := s.withTimeout(ctx, "GetAppSubscription")
+	defer cancel()
+
+	data, err := graphQL[appSubscriptionData](ctx, s, appSubscriptionQuery, map[string]interface{}{
+		"id": shopifyGID("AppSubscription", id),
+	})
+	if err != nil {
+		logger.Error("failed to get app subscription", "err", err)
+		return nil, err
+	}
+	// Node is empty if it is not an app subscription of this app
+	if data.Node == nil || data.Node.ID == "" {
+		return nil, nil
+	}
+
+	return data.Node.toAppSubscription(), nil
+}
+
+const appUsageRecordCreateMutation = `
+mutation appUsageRecordCreate($subscriptionLineItemId: ID!, $price: MoneyInput!, $description: String!, $idempotencyKey: String) {
+	appUsageRecordCreate(subscriptionLineItemId: $subscriptionLineItemId, price: $price, description: $description, idempotencyKey: $idempotencyKey) {
+		appUsageRecord {
+			id
+		}
+		userErrors {
+			field
+			message
+		}
+	}
+}`
+
+type appUsageRecordCreateData struct {
+	AppUsageRecordCreate struct {
+		AppUsageRecord *struct {
+			ID string `json:"id"`
+		} `json:"appUsageRecord"`
+		UserErrors []graphQLUserError `json:"userErrors"`
+	} `json:"appUsageRecordCreate"`
+}
+
+func (s *shopifyAPI) CreateUsageRecord(ctx context.Context, opts service.CreateUsageRecordOptions) (string, error) {
+	logger := s.logger.
+		Named("CreateUsageRecord").
+		WithContext(ctx).
+		With("opts", opts)
+
+	ctx, cancel := s.withTimeout(ctx, "CreateUsageRecord")
+	defer cancel()
+
+	variables := map[string]interface{}{
+		"subscriptionLineItemId": opts.LineItemID,
+		"price":                  money(opts.Amount, opts.CurrencyCode),
+		"description":            opts.Description,
+	}
+	if opts.IdempotencyKey != "" {
+		variables["idempotencyKey"] = opts.IdempotencyKey
+	}
+
+	data, err := graphQL[appUsageRecordCreateData](ctx, s, appUsageRecordCreateMutation, variables)
+	if err != nil {
+		logger.Error("failed to create usage record", "err", err)
+		return "", err
+	}
+	err = userErrorsToError(data.AppUsageRecordCreate.UserErrors)
+	if err != nil {
+		logger.Info("usage record is invalid", "err", err)
+		return "", err
+	}
+	if data.AppUsageRecordCreate.AppUsageRecord == nil {
+		return "", fmt.Errorf("usage record is missing in response")
+	}
+	logger.Info("created usage record")
+
+	return data.AppUsageRecordCreate.AppUsageRecord.ID, nil
+}
+
+// money returns MoneyInput of the amount.
+func money(amount float64, currencyCode string) map[string]interface{} {
+	return map[string]interface{}{
+		"amount":       amount,
+		"currencyCode": currencyCode,
+	}
+}
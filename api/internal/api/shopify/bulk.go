@@ -0,0 +1,281 @@
+package shopify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+const bulkOperationRunQueryMutation = `
+mutation bulkOperationRunQuery($query: String!) {
+	bulkOperationRunQuery(query: $query) {
+		bulkOperation {
+			id
+			status
+			createdAt
+		}
+		userErrors {
+			field
+			message
+		}
+	}
+}`
+
+const bulkOperationQuery = `
+query bulkOperation($id: ID!) {
+	node(id: $id) {
+		... on BulkOperation {
+			id
+			status
+			errorCode
+			objectCount
+			url
+			createdAt
+			completedAt
+		}
+	}
+}`
+
+type bulkOperationNode struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	ErrorCode   string     `json:"errorCode"`
+	ObjectCount string     `json:"objectCount"`
+	URL         string     `json:"url"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+}
+
+type bulkOperationRunQueryData struct {
+	BulkOperationRunQuery struct {
+		BulkOperation *bulkOperationNode `json:"bulkOperation"`
+		UserErrors    []graphQLUserError `json:"userErrors"`
+	} `json:"bulkOperationRunQuery"`
+}
+
+type bulkOperationData struct {
+	Node *bulkOperationNode `json:"node"`
+}
+
+func (s *shopifyAPI) RunBulkQuery(ctx context.Context, query string) (*service.BulkOperation, error) {
+	logger := s.logger.
+		Named("RunBulkQuery").
+		WithContext(ctx)
+
+	ctx, cancel := s.withTimeout(ctx, "RunBulkQuery")
+	defer cancel()
+
+	data, err := graphQL[bulkOperationRunQueryData](ctx, s, bulkOperationRunQueryMutation, map[string]interface{}{
+		"query": query,
+	})
+	if err != nil {
+		logger.Error("failed to run bulk query", "err", err)
+		return nil, err
+	}
+	err = userErrorsToError(data.BulkOperationRunQuery.UserErrors)
+	if err != nil {
+		logger.Info("bulk query is rejected", "err", err)
+		return nil, err
+	}
+	if data.BulkOperationRunQuery.BulkOperation == nil {
+		logger.Error("bulk operation is missing in response")
+		return nil, errors.New("bulk operation is missing in response")
+	}
+
+	operation := data.BulkOperationRunQuery.BulkOperation.toService()
+	logger.Info("started bulk operation", "id", operation.ID)
+
+	return operation, nil
+}
+
+func (s *shopifyAPI) GetBulkOperation(ctx context.Context, id string) (*service.BulkOperation, error) {
+	logger := s.logger.
+		Named("GetBulkOperation").
+		WithContext(ctx).
+		With("id", id)
+
+	ctx, cancel := s.withTimeout(ctx, "GetBulkOperation")
+	defer cancel()
+
+	data, err := graphQL[bulkOperationData](ctx, s, bulkOperationQuery, map[string]interface{}{
+		"id": id,
+	})
+	if err != nil {
+		logger.Error("failed to get bulk operation", "err", err)
+		return nil, err
+	}
+	if data.Node == nil {
+		logger.Info("bulk operation is not found")
+		return nil, nil
+	}
+
+	return data.Node.toService(), nil
+}
+
+func (s *shopifyAPI) DownloadBulkOperationResult(ctx context.Context, url string) (service.BulkRecordIterator, error) {
+	logger := s.logger.
+		Named("DownloadBulkOperationResult").
+		WithContext(ctx)
+
+	// Timeout bounds reading of the whole result, so it is released when the iterator is closed
+	ctx, cancel := s.withTimeout(ctx, "DownloadBulkOperationResult")
+
+	// Result is stored outside of the store, so the request must not carry store's access token
+	res, err := s.newBaseClient().R().
+		SetContext(ctx).
+		SetDoNotParseResponse(true).
+		Get(url)
+	if err != nil {
+		cancel()
+		logger.Error("failed to download bulk operation result", "err", err)
+		return nil, fmt.Errorf("failed to download bulk operation result: %w", err)
+	}
+	body := res.RawBody()
+	if res.StatusCode() != http.StatusOK {
+		body.Close()
+		cancel()
+		logger.Error("failed to download bulk operation result", "status", res.StatusCode())
+		return nil, fmt.Errorf("failed to download bulk operation result: http status %d", res.StatusCode())
+	}
+
+	return newBulkRecordIterator(&cancelOnClose{ReadCloser: body, cancel: cancel}), nil
+}
+
+// cancelOnClose cancels context of the request, which body it reads, once the body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+func (n *bulkOperationNode) toService() *service.BulkOperation {
+	objectCount, _ := strconv.ParseInt(n.ObjectCount, 10, 64)
+	return &service.BulkOperation{
+		ID:          n.ID,
+		Status:      n.Status,
+		ErrorCode:   n.ErrorCode,
+		ObjectCount: objectCount,
+		URL:         n.URL,
+		CreatedAt:   n.CreatedAt,
+		CompletedAt: n.CompletedAt,
+	}
+}
+
+// bulkRecordIterator stream-decodes JSONL result of bulk operation.
+// Every line of nested connection references its parent with __parentId and follows it,
+// so a record is complete once the next top-level record is read.
+// https://shopify.dev/docs/api/usage/bulk-operations/queries#the-jsonl-data-format
+type bulkRecordIterator struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+
+	// records indexes records of the tree being read by their IDs
+	records map[string]*service.BulkRecord
+	pending *service.BulkRecord
+	current *service.BulkRecord
+	err     error
+}
+
+var _ service.BulkRecordIterator = (*bulkRecordIterator)(nil)
+
+func newBulkRecordIterator(body io.ReadCloser) *bulkRecordIterator {
+	return &bulkRecordIterator{
+		body:    body,
+		decoder: json.NewDecoder(body),
+		records: make(map[string]*service.BulkRecord),
+	}
+}
+
+type bulkRecordLine struct {
+	ID       string `json:"id"`
+	ParentID string `json:"__parentId"`
+}
+
+func (it *bulkRecordIterator) Next(ctx context.Context) bool {
+	it.current = nil
+	if it.err != nil {
+		return false
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		var data json.RawMessage
+		err := it.decoder.Decode(&data)
+		if errors.Is(err, io.EOF) {
+			it.current, it.pending = it.pending, nil
+			return it.current != nil
+		}
+		if err != nil {
+			it.err = fmt.Errorf("failed to decode bulk operation result: %w", err)
+			return false
+		}
+
+		var line bulkRecordLine
+		err = json.Unmarshal(data, &line)
+		if err != nil {
+			it.err = fmt.Errorf("failed to decode bulk operation record: %w", err)
+			return false
+		}
+
+		record := &service.BulkRecord{
+			ID:   line.ID,
+			Type: gidType(line.ID),
+			Data: data,
+		}
+
+		if line.ParentID != "" {
+			parent, ok := it.records[line.ParentID]
+			if !ok {
+				it.err = fmt.Errorf("parent %s of bulk operation record is not found", line.ParentID)
+				return false
+			}
+			parent.Children = append(parent.Children, record)
+			it.records[record.ID] = record
+			continue
+		}
+
+		// New top-level record means the previous one is complete
+		it.current, it.pending = it.pending, record
+		it.records = map[string]*service.BulkRecord{record.ID: record}
+		if it.current != nil {
+			return true
+		}
+	}
+}
+
+func (it *bulkRecordIterator) Item() *service.BulkRecord {
+	return it.current
+}
+
+func (it *bulkRecordIterator) Err() error {
+	return it.err
+}
+
+func (it *bulkRecordIterator) Close() error {
+	return it.body.Close()
+}
+
+// gidType returns type of the object from its global ID, e.g. "Product" for "gid://shopify/Product/1".
+func gidType(gid string) string {
+	parts := strings.Split(strings.TrimPrefix(gid, "gid://shopify/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0]
+}
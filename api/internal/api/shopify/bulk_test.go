@@ -0,0 +1,74 @@
+package shopify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+// countingTransport counts requests sent through it.
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestDownloadBulkOperationResult(t *testing.T) {
+	var accessToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessToken = r.Header.Get("X-Shopify-Access-Token")
+		if r.URL.Path != "/result.jsonl" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"gid://shopify/Product/1"}
+{"id":"gid://shopify/ProductVariant/2","__parentId":"gid://shopify/Product/1"}
+{"id":"gid://shopify/Product/3"}
+`))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	api := NewAPI(Options{
+		Config:    newTestConfig(),
+		Logger:    logging.NewZap("error"),
+		BaseURL:   func(string) string { return server.URL },
+		Transport: transport,
+	}).WithConfig(context.Background(), &entity.Store{Name: "s.myshopify.com", AccessToken: "token"})
+
+	records, err := api.DownloadBulkOperationResult(context.Background(), server.URL+"/result.jsonl")
+	if err != nil {
+		t.Fatalf("DownloadBulkOperationResult() error = %v", err)
+	}
+	defer records.Close()
+
+	var ids []string
+	for records.Next(context.Background()) {
+		ids = append(ids, records.Item().ID)
+	}
+	if err := records.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	if len(ids) != 2 || ids[0] != "gid://shopify/Product/1" || ids[1] != "gid://shopify/Product/3" {
+		t.Errorf("ids = %q, want products 1 and 3", ids)
+	}
+	if transport.requests != 1 {
+		t.Errorf("transport sent %d requests, want 1", transport.requests)
+	}
+	if accessToken != "" {
+		t.Errorf("request carries access token %q", accessToken)
+	}
+
+	_, err = api.DownloadBulkOperationResult(context.Background(), server.URL+"/missing.jsonl")
+	if err == nil {
+		t.Error("DownloadBulkOperationResult() of missing result returned no error")
+	}
+}
@@ -0,0 +1,196 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+const collectionCreateMutation = `
+mutation collectionCreate($input: CollectionInput!) {
+	collectionCreate(input: $input) {
+		collection {
+			id
+		}
+		userErrors {
+			field
+			message
+		}
+	}
+}`
+
+type collectionCreateData struct {
+	CollectionCreate struct {
+		Collection *struct {
+			ID string `json:"id"`
+		} `json:"collection"`
+		UserErrors []graphQLUserError `json:"userErrors"`
+	} `json:"collectionCreate"`
+}
+
+func (s *shopifyAPI) CreateCollection(ctx context.Context, opts service.CreateCollectionOptions) (string, error) {
+	logger := s.logger.
+		Named("CreateCollection").
+		WithContext(ctx).
+		With("title", opts.Title, "productsCount", len(opts.ProductIDs))
+
+	ctx, cancel := s.withTimeout(ctx, "CreateCollection")
+	defer cancel()
+
+	products := make([]string, 0, len(opts.ProductIDs))
+	for _, id := range opts.ProductIDs {
+		products = append(products, productGID(id))
+	}
+
+	input := map[string]interface{}{
+		"title":    opts.Title,
+		"products": products,
+	}
+	if opts.Handle != "" {
+		input["handle"] = opts.Handle
+	}
+
+	data, err := graphQL[collectionCreateData](ctx, s, collectionCreateMutation, map[string]interface{}{
+		"input": input,
+	})
+	if err != nil {
+		logger.Error("failed to create collection", "err", err)
+		return "", err
+	}
+	err = userErrorsToError(data.CollectionCreate.UserErrors)
+	if err != nil {
+		logger.Info("collection is invalid", "err", err)
+		return "", err
+	}
+	if data.CollectionCreate.Collection == nil {
+		logger.Error("collection is not returned")
+		return "", fmt.Errorf("collection is not returned")
+	}
+	logger.Info("created collection")
+
+	return data.CollectionCreate.Collection.ID, nil
+}
+
+const collectionExistsQuery = `
+query collectionExists($id: ID!) {
+	collection(id: $id) {
+		id
+	}
+}`
+
+type collectionExistsData struct {
+	Collection *struct {
+		ID string `json:"id"`
+	} `json:"collection"`
+}
+
+const collectionDeleteMutation = `
+mutation collectionDelete($input: CollectionDeleteInput!) {
+	collectionDelete(input: $input) {
+		deletedCollectionId
+		userErrors {
+			field
+			message
+		}
+	}
+}`
+
+type collectionDeleteData struct {
+	CollectionDelete struct {
+		UserErrors []graphQLUserError `json:"userErrors"`
+	} `json:"collectionDelete"`
+}
+
+func (s *shopifyAPI) DeleteCollection(ctx context.Context, id string) (bool, error) {
+	logger := s.logger.
+		Named("DeleteCollection").
+		WithContext(ctx).
+		With("id", id)
+
+	ctx, cancel := s.withTimeout(ctx, "DeleteCollection")
+	defer cancel()
+
+	gid := collectionGID(id)
+
+	// Mutation reports missing collection as user error, so existence is checked first
+	exists, err := graphQL[collectionExistsData](ctx, s, collectionExistsQuery, map[string]interface{}{
+		"id": gid,
+	})
+	if err != nil {
+		logger.Error("failed to check collection existence", "err", err)
+		return false, err
+	}
+	if exists.Collection == nil {
+		return false, nil
+	}
+
+	data, err := graphQL[collectionDeleteData](ctx, s, collectionDeleteMutation, map[string]interface{}{
+		"input": map[string]interface{}{"id": gid},
+	})
+	if err != nil {
+		logger.Error("failed to delete collection", "err", err)
+		return false, err
+	}
+	err = userErrorsToError(data.CollectionDelete.UserErrors)
+	if err != nil {
+		logger.Error("failed to delete collection", "err", err)
+		return false, err
+	}
+	logger.Info("deleted collection")
+
+	return true, nil
+}
+
+const collectionsQuery = `
+query collections($first: Int!, $after: String, $query: String) {
+	collections(first: $first, after: $after, query: $query) {
+		nodes {
+			id
+			title
+			handle
+		}
+		pageInfo {
+			hasNextPage
+			endCursor
+		}
+	}
+}`
+
+type collectionNode struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Handle string `json:"handle"`
+}
+
+type collectionsData struct {
+	Collections graphQLConnection[collectionNode] `json:"collections"`
+}
+
+func (s *shopifyAPI) IterateCollections(ctx context.Context) service.Iterator[service.Collection] {
+	fetch := graphQLPageFetcher(s, collectionsQuery, map[string]interface{}{"query": "collection_type:custom"}, func(data *collectionsData) graphQLConnection[collectionNode] {
+		return data.Collections
+	})
+
+	return newPaginator(withPageTimeout(s, "IterateCollections", func(ctx context.Context, cursor string) ([]service.Collection, string, error) {
+		nodes, next, err := fetch(ctx, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		collections := make([]service.Collection, 0, len(nodes))
+		for _, node := range nodes {
+			collections = append(collections, service.Collection{
+				ID:     node.ID,
+				Title:  node.Title,
+				Handle: node.Handle,
+			})
+		}
+		return collections, next, nil
+	}))
+}
+
+// collectionGID returns global ID of the collection, id can be either numeric ID or global ID.
+func collectionGID(id string) string {
+	return shopifyGID("Collection", id)
+}
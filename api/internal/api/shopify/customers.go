@@ -0,0 +1,56 @@
+package shopify
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+// customerResource is a customer of REST Admin API.
+type customerResource struct {
+	AdminGraphQLAPIID string                   `json:"admin_graphql_api_id"`
+	FirstName         string                   `json:"first_name"`
+	LastName          string                   `json:"last_name"`
+	Email             string                   `json:"email"`
+	Phone             string                   `json:"phone"`
+	State             string                   `json:"state"`
+	Tags              string                   `json:"tags"`
+	DefaultAddress    *service.CustomerAddress `json:"default_address"`
+	CreatedAt         time.Time                `json:"created_at"`
+	UpdatedAt         time.Time                `json:"updated_at"`
+}
+
+func (s *shopifyAPI) IterateCustomers(ctx context.Context, opts service.IterateCustomersOptions) service.Iterator[service.Customer] {
+	query := url.Values{}
+	if opts.UpdatedAtMin != nil {
+		query.Set("updated_at_min", opts.UpdatedAtMin.UTC().Format(time.RFC3339))
+	}
+
+	fetch := restPageFetcher[customerResource](s, "/customers.json", query, "customers")
+
+	return newPaginator(withPageTimeout(s, "IterateCustomers", func(ctx context.Context, cursor string) ([]service.Customer, string, error) {
+		resources, next, err := fetch(ctx, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		customers := make([]service.Customer, 0, len(resources))
+		for _, resource := range resources {
+			customers = append(customers, service.Customer{
+				ID:             resource.AdminGraphQLAPIID,
+				FirstName:      resource.FirstName,
+				LastName:       resource.LastName,
+				Email:          resource.Email,
+				Phone:          resource.Phone,
+				State:          resource.State,
+				Tags:           service.SplitTags(resource.Tags),
+				DefaultAddress: resource.DefaultAddress,
+				CreatedAt:      resource.CreatedAt,
+				UpdatedAt:      resource.UpdatedAt,
+			})
+		}
+		return customers, next, nil
+	}))
+}
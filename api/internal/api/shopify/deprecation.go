@@ -0,0 +1,58 @@
+package shopify
+
+import (
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// deprecatedReasonHeader is returned by platform when request uses deprecated API version or endpoint.
+// https://shopify.dev/docs/api/usage/versioning#deprecation-practices
+const deprecatedReasonHeader = "X-Shopify-API-Deprecated-Reason"
+
+// deprecationCounter counts deprecated API calls per store.
+type deprecationCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newDeprecationCounter() *deprecationCounter {
+	return &deprecationCounter{
+		counts: make(map[string]int),
+	}
+}
+
+// Inc increments number of deprecated calls made by the store and returns it.
+func (c *deprecationCounter) Inc(storeName string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[storeName]++
+	return c.counts[storeName]
+}
+
+// reportDeprecations makes client log and count responses marked as deprecated by platform.
+func (s *shopifyAPI) reportDeprecations(client *resty.Client) *resty.Client {
+	return client.OnAfterResponse(func(c *resty.Client, res *resty.Response) error {
+		reason := res.Header().Get(deprecatedReasonHeader)
+		if reason == "" {
+			return nil
+		}
+
+		storeName := rateLimiterKey(c, res.Request)
+		endpoint := res.Request.URL
+		if res.Request.RawRequest != nil {
+			endpoint = res.Request.RawRequest.URL.Path
+		}
+
+		count := s.deprecations.Inc(storeName)
+		s.logger.Named("reportDeprecations").WithContext(res.Request.Context()).Warn("called deprecated api",
+			"storeName", storeName,
+			"method", res.Request.Method,
+			"endpoint", endpoint,
+			"reason", reason,
+			"count", count,
+		)
+		return nil
+	})
+}
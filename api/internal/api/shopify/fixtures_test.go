@@ -0,0 +1,372 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/api/shopify/shopifytest"
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+const (
+	// fixtureStoreName is a development store with test data only, which fixtures are recorded at.
+	fixtureStoreName = "app-template-fixtures.myshopify.com"
+	// fixturesAccessTokenEnv is an environment variable with app's access token of the fixture store,
+	// it is required to record fixtures.
+	fixturesAccessTokenEnv = "SHOPIFY_FIXTURES_ACCESS_TOKEN"
+)
+
+// newFixtureAPI returns API of the fixture store, which replays interactions of testdata/<name>.json.
+// With shopifytest.FixturesModeEnv set to record, interactions are recorded at the store instead.
+func newFixtureAPI(t *testing.T, name string) (*shopifyAPI, *shopifytest.Recorder) {
+	t.Helper()
+
+	recorder, err := shopifytest.NewRecorder(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatalf("failed to create recorder: %v", err)
+	}
+
+	t.Cleanup(func() {
+		err := recorder.Save()
+		if err != nil {
+			t.Errorf("failed to save fixture: %v", err)
+		}
+		if unreplayed := recorder.Unreplayed(); len(unreplayed) > 0 {
+			t.Errorf("%d interactions of the fixture are not replayed, first is %s %s",
+				len(unreplayed), unreplayed[0].Request.Method, unreplayed[0].Request.URL)
+		}
+	})
+
+	api := NewAPI(Options{Config: newTestConfig(), Logger: logging.NewZap("error"), Transport: recorder})
+	store := &entity.Store{Name: fixtureStoreName, AccessToken: fixtureAccessToken(t, recorder)}
+	return api.WithConfig(context.Background(), store).(*shopifyAPI), recorder
+}
+
+// fixtureAccessToken returns access token of the fixture store, it is needed to record fixtures only.
+func fixtureAccessToken(t *testing.T, recorder *shopifytest.Recorder) string {
+	t.Helper()
+
+	if recorder.Mode() != shopifytest.RecorderModeRecord {
+		return "token"
+	}
+	accessToken := os.Getenv(fixturesAccessTokenEnv)
+	if accessToken == "" {
+		t.Fatalf("%s is required to record fixtures", fixturesAccessTokenEnv)
+	}
+	return accessToken
+}
+
+func TestOAuthFixture(t *testing.T) {
+	api, recorder := newFixtureAPI(t, "oauth")
+	if recorder.Mode() == shopifytest.RecorderModeRecord {
+		t.Skip("authorization code is issued to store owner's browser, so the fixture can't be recorded by test")
+	}
+	api.cfg.Shopify.Scopes = "read_products,write_products"
+
+	accessToken, err := api.HandleRedirect(context.Background(), service.APIHandleRedirectOptions{
+		Nonce:         "4f1b0c9d2e",
+		RedirectedURL: "https://app.example.com/auth/callback?code=0907a61c0c8d55e99db179b68161bc00&shop=" + fixtureStoreName + "&state=4f1b0c9d2e",
+		StoreName:     fixtureStoreName,
+	})
+	if err != nil {
+		t.Fatalf("HandleRedirect() error = %v", err)
+	}
+	// Access token is scrubbed from the fixture
+	if accessToken != "[SCRUBBED]" {
+		t.Errorf("HandleRedirect() = %q, want scrubbed access token", accessToken)
+	}
+}
+
+func TestShopFixture(t *testing.T) {
+	api, recorder := newFixtureAPI(t, "shop")
+
+	shop, err := api.GetShop(context.Background())
+	if err != nil {
+		t.Fatalf("GetShop() error = %v", err)
+	}
+
+	want := &service.Shop{
+		ID:              72845885589,
+		Name:            "App Template Fixtures",
+		Email:           "[SCRUBBED]",
+		Currency:        "USD",
+		IANATimezone:    "America/New_York",
+		PlanName:        "partner_test",
+		PlanDisplayName: "Developer Preview",
+		PrimaryDomain:   fixtureStoreName,
+		CountryCode:     "US",
+		MyshopifyDomain: fixtureStoreName,
+	}
+	wantUpdatedAt := time.Date(2024, 5, 6, 9, 40, 18, 0, time.UTC)
+	// Owner's email is scrubbed from the fixture, but not from the responses being recorded
+	if recorder.Mode() == shopifytest.RecorderModeRecord {
+		want.Email = shop.Email
+		wantUpdatedAt = shop.UpdatedAt
+	}
+	if !shop.UpdatedAt.Equal(wantUpdatedAt) {
+		t.Errorf("GetShop() updated at %s, want %s", shop.UpdatedAt, wantUpdatedAt)
+	}
+	// Time is compared above, since it keeps shop's time zone offset
+	want.UpdatedAt = shop.UpdatedAt
+	if !reflect.DeepEqual(shop, want) {
+		t.Errorf("GetShop() = %+v, want %+v", shop, want)
+	}
+}
+
+func TestProductsFixture(t *testing.T) {
+	api, _ := newFixtureAPI(t, "products")
+	ctx := context.Background()
+
+	// The first request is throttled, so its error code must survive scrubbing to be retried
+	count, err := api.GetProductsCount(ctx)
+	if err != nil {
+		t.Fatalf("GetProductsCount() error = %v", err)
+	}
+	if count != 3 {
+		t.Errorf("GetProductsCount() = %d, want 3", count)
+	}
+
+	page, err := api.ListProducts(ctx, service.ListProductsOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListProducts() error = %v", err)
+	}
+	if len(page.Products) != 2 || page.NextCursor == "" {
+		t.Fatalf("ListProducts() = %d products with cursor %q, want 2 products with next cursor", len(page.Products), page.NextCursor)
+	}
+	product := page.Products[0]
+	if product.Title != "Classic Tee" || len(product.Variants) != 2 || product.Variants[1].SKU != "TEE-M" {
+		t.Errorf("ListProducts() first product = %+v", product)
+	}
+
+	page, err = api.ListProducts(ctx, service.ListProductsOptions{Limit: 2, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("ListProducts() error = %v", err)
+	}
+	if len(page.Products) != 1 || page.NextCursor != "" {
+		t.Errorf("ListProducts() = %d products with cursor %q, want the last product", len(page.Products), page.NextCursor)
+	}
+}
+
+func TestOrdersFixture(t *testing.T) {
+	api, recorder := newFixtureAPI(t, "orders")
+	ctx := context.Background()
+	updatedAtMin := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	var orders []service.Order
+	iterator := api.IterateOrders(ctx, service.IterateOrdersOptions{UpdatedAtMin: &updatedAtMin})
+	for iterator.Next(ctx) {
+		orders = append(orders, iterator.Item())
+	}
+	if err := iterator.Err(); err != nil {
+		t.Fatalf("IterateOrders() error = %v", err)
+	}
+
+	if len(orders) != 2 {
+		t.Fatalf("IterateOrders() returned %d orders, want 2 from both pages", len(orders))
+	}
+	order := orders[0]
+	if order.ID != "gid://shopify/Order/5781234567001" || order.Name != "#1001" || order.FulfillmentStatus != "fulfilled" {
+		t.Errorf("IterateOrders() first order = %+v", order)
+	}
+	// Customer's email is scrubbed from the fixture
+	if recorder.Mode() == shopifytest.RecorderModeReplay && order.Email != "[SCRUBBED]" {
+		t.Errorf("IterateOrders() first order's email = %q, want scrubbed", order.Email)
+	}
+	if len(order.LineItems) != 1 || order.LineItems[0].ProductID != "gid://shopify/Product/8812345678001" {
+		t.Errorf("IterateOrders() first order's line items = %+v", order.LineItems)
+	}
+	if len(order.Fulfillments) != 1 || order.Fulfillments[0].TrackingNumbers[0] != "1Z999AA10123456784" {
+		t.Errorf("IterateOrders() first order's fulfillments = %+v", order.Fulfillments)
+	}
+	// Product of the second order's line item has been deleted
+	if orders[1].LineItems[0].ProductID != "" {
+		t.Errorf("IterateOrders() second order's line item product = %q, want empty", orders[1].LineItems[0].ProductID)
+	}
+}
+
+func TestCustomersFixture(t *testing.T) {
+	api, recorder := newFixtureAPI(t, "customers")
+	ctx := context.Background()
+
+	var customers []service.Customer
+	iterator := api.IterateCustomers(ctx, service.IterateCustomersOptions{})
+	for iterator.Next(ctx) {
+		customers = append(customers, iterator.Item())
+	}
+	if err := iterator.Err(); err != nil {
+		t.Fatalf("IterateCustomers() error = %v", err)
+	}
+
+	if len(customers) != 2 {
+		t.Fatalf("IterateCustomers() returned %d customers, want 2", len(customers))
+	}
+	customer := customers[0]
+	if customer.ID != "gid://shopify/Customer/7312345678001" || customer.State != "enabled" || !reflect.DeepEqual(customer.Tags, []string{"vip", "wholesale"}) {
+		t.Errorf("IterateCustomers() first customer = %+v", customer)
+	}
+	if customer.DefaultAddress == nil || customer.DefaultAddress.Province != "New York" {
+		t.Errorf("IterateCustomers() first customer's address = %+v", customer.DefaultAddress)
+	}
+	// Personal data is scrubbed from the fixture, while the rest of the address is kept
+	if recorder.Mode() == shopifytest.RecorderModeReplay {
+		if customer.FirstName != "[SCRUBBED]" || customer.Email != "[SCRUBBED]" || customer.Phone != "[SCRUBBED]" {
+			t.Errorf("IterateCustomers() first customer's personal data = %+v, want scrubbed", customer)
+		}
+		if customer.DefaultAddress != nil && customer.DefaultAddress.Address1 != "[SCRUBBED]" {
+			t.Errorf("IterateCustomers() first customer's address = %+v, want scrubbed", customer.DefaultAddress)
+		}
+	}
+	// Customer without phone and address keeps them empty
+	if customers[1].Phone != "" || customers[1].DefaultAddress != nil {
+		t.Errorf("IterateCustomers() second customer = %+v, want no phone and address", customers[1])
+	}
+}
+
+func TestWebhooksFixture(t *testing.T) {
+	api, recorder := newFixtureAPI(t, "webhooks")
+	address := "https://app.example.com/webhooks"
+
+	output, err := api.ReconcileWebhooks(context.Background(), service.ReconcileWebhooksOptions{
+		StoreName:   fixtureStoreName,
+		AccessToken: fixtureAccessToken(t, recorder),
+		Subscriptions: []service.WebhookSubscription{
+			{Topic: "app/uninstalled", Address: address, Format: "json"},
+			{Topic: "shop/update", Address: address, Format: "json"},
+			{Topic: "products/update", Address: address, Format: "json"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ReconcileWebhooks() error = %v", err)
+	}
+
+	want := service.ReconcileWebhooksOutput{
+		Created: []string{"products/update"},
+		Updated: []string{"shop/update"},
+		Deleted: []string{"orders/create"},
+	}
+	if !reflect.DeepEqual(output, want) {
+		t.Errorf("ReconcileWebhooks() = %+v, want %+v", output, want)
+	}
+}
+
+func TestBulkOperationsFixture(t *testing.T) {
+	api, _ := newFixtureAPI(t, "bulk_operations")
+	ctx := context.Background()
+
+	started, err := api.RunBulkQuery(ctx, `{ products { edges { node { id title } } } }`)
+	if err != nil {
+		t.Fatalf("RunBulkQuery() error = %v", err)
+	}
+	if started.ID != "gid://shopify/BulkOperation/4412345678001" || started.Status != "CREATED" {
+		t.Errorf("RunBulkQuery() = %+v", started)
+	}
+
+	// Another bulk query is rejected while the first one runs
+	_, err = api.RunBulkQuery(ctx, `{ customers { edges { node { id } } } }`)
+	var userErrors service.PlatformUserErrors
+	if !errors.As(err, &userErrors) {
+		t.Errorf("RunBulkQuery() error = %v, want user errors", err)
+	}
+
+	operation, err := api.GetBulkOperation(ctx, started.ID)
+	if err != nil {
+		t.Fatalf("GetBulkOperation() error = %v", err)
+	}
+	if operation.Status != service.BulkOperationStatusCompleted || operation.ObjectCount != 2 || operation.URL == "" {
+		t.Fatalf("GetBulkOperation() = %+v", operation)
+	}
+
+	records, err := api.DownloadBulkOperationResult(ctx, operation.URL)
+	if err != nil {
+		t.Fatalf("DownloadBulkOperationResult() error = %v", err)
+	}
+	defer records.Close()
+
+	var titles []string
+	for records.Next(ctx) {
+		var product struct {
+			Title string `json:"title"`
+		}
+		if err := records.Item().Decode(&product); err != nil {
+			t.Fatalf("failed to decode record: %v", err)
+		}
+		titles = append(titles, product.Title)
+	}
+	if err := records.Err(); err != nil {
+		t.Fatalf("failed to iterate records: %v", err)
+	}
+	if !reflect.DeepEqual(titles, []string{"Classic Tee", "Canvas Tote"}) {
+		t.Errorf("bulk operation result = %v, want both products", titles)
+	}
+}
+
+func TestBillingFixture(t *testing.T) {
+	api, _ := newFixtureAPI(t, "billing")
+	ctx := context.Background()
+
+	created, err := api.CreateAppSubscription(ctx, service.CreateAppSubscriptionOptions{
+		Plan: config.BillingPlan{
+			Name:         "Basic",
+			Price:        9.99,
+			CurrencyCode: "USD",
+			Interval:     "EVERY_30_DAYS",
+			TrialDays:    7,
+			UsageCap:     100,
+			UsageTerms:   "$0.10 per imported order",
+		},
+		ReturnURL: "https://app.example.com/api/billing/callback?shop=" + fixtureStoreName,
+		Test:      true,
+	})
+	if err != nil {
+		t.Fatalf("CreateAppSubscription() error = %v", err)
+	}
+	if created.ConfirmationURL == "" || created.Subscription.Status != "PENDING" {
+		t.Errorf("CreateAppSubscription() = %+v", created)
+	}
+	if created.Subscription.UsageLineItemID != "gid://shopify/AppSubscriptionLineItem/29012345001?v=1&index=1" {
+		t.Errorf("CreateAppSubscription() usage line item = %q", created.Subscription.UsageLineItemID)
+	}
+
+	subscription, err := api.GetAppSubscription(ctx, "29012345001")
+	if err != nil {
+		t.Fatalf("GetAppSubscription() error = %v", err)
+	}
+	if subscription == nil || subscription.Status != "ACTIVE" || subscription.CurrentPeriodEnd == nil {
+		t.Errorf("GetAppSubscription() = %+v", subscription)
+	}
+}
+
+func TestCollectionsFixture(t *testing.T) {
+	api, _ := newFixtureAPI(t, "collections")
+	ctx := context.Background()
+
+	id, err := api.CreateCollection(ctx, service.CreateCollectionOptions{
+		Title:      "Summer",
+		ProductIDs: []string{"8812345678001", "8812345678002"},
+	})
+	if err != nil {
+		t.Fatalf("CreateCollection() error = %v", err)
+	}
+	if id != "gid://shopify/Collection/6012345678001" {
+		t.Errorf("CreateCollection() = %q", id)
+	}
+
+	deleted, err := api.DeleteCollection(ctx, id)
+	if err != nil || !deleted {
+		t.Errorf("DeleteCollection() = %v, %v, want deleted", deleted, err)
+	}
+
+	// Collection is deleted already
+	deleted, err = api.DeleteCollection(ctx, id)
+	if err != nil || deleted {
+		t.Errorf("DeleteCollection() of deleted collection = %v, %v, want not deleted", deleted, err)
+	}
+}
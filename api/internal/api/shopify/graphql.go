@@ -0,0 +1,141 @@
+package shopify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+const (
+	// graphQLMaxThrottleRetries is a number of retries of throttled GraphQL request.
+	graphQLMaxThrottleRetries = 5
+	// graphQLThrottledCode is an error code of the request rejected due to exceeded query cost limit.
+	graphQLThrottledCode = "THROTTLED"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data       json.RawMessage `json:"data"`
+	Errors     []graphQLError  `json:"errors"`
+	Extensions struct {
+		Cost *graphQLCost `json:"cost"`
+	} `json:"extensions"`
+}
+
+type graphQLError struct {
+	Message    string `json:"message"`
+	Extensions struct {
+		Code string `json:"code"`
+	} `json:"extensions"`
+}
+
+// graphQLCost describes cost of the query and the state of the store's leaky bucket.
+// https://shopify.dev/docs/api/usage/rate-limits#graphql-admin-api-rate-limits
+type graphQLCost struct {
+	RequestedQueryCost float64 `json:"requestedQueryCost"`
+	ActualQueryCost    float64 `json:"actualQueryCost"`
+	ThrottleStatus     struct {
+		MaximumAvailable   float64 `json:"maximumAvailable"`
+		CurrentlyAvailable float64 `json:"currentlyAvailable"`
+		RestoreRate        float64 `json:"restoreRate"`
+	} `json:"throttleStatus"`
+}
+
+// graphQLUserError is a validation error of the mutation's input.
+type graphQLUserError struct {
+	Field   []string `json:"field"`
+	Message string   `json:"message"`
+}
+
+// graphQL executes GraphQL query with variables and decodes its data into T.
+// Throttled requests are retried once enough query cost is restored.
+func graphQL[T any](ctx context.Context, s *shopifyAPI, query string, variables map[string]interface{}) (*T, error) {
+	for attempt := 0; ; attempt++ {
+		var responseBody graphQLResponse
+		res, err := s.client.R().
+			SetContext(ctx).
+			SetBody(graphQLRequest{Query: query, Variables: variables}).
+			SetResult(&responseBody).
+			Post(s.adminPath("/graphql.json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to send graphql request: %w", err)
+		}
+		if res.StatusCode() != http.StatusOK {
+			return nil, fmt.Errorf("failed to send graphql request: http status %d, body %s", res.StatusCode(), res.String())
+		}
+
+		if len(responseBody.Errors) > 0 {
+			if isGraphQLThrottled(responseBody.Errors) && attempt < graphQLMaxThrottleRetries {
+				wait := graphQLThrottleWait(responseBody.Extensions.Cost)
+				s.logger.Named("graphQL").WithContext(ctx).Info("graphql request is throttled", "wait", wait, "attempt", attempt)
+
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(wait):
+				}
+				continue
+			}
+			return nil, fmt.Errorf("graphql request failed: %s", responseBody.Errors[0].Message)
+		}
+
+		var data T
+		err = json.Unmarshal(responseBody.Data, &data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode graphql data: %w", err)
+		}
+
+		return &data, nil
+	}
+}
+
+// isGraphQLThrottled checks whether request is rejected due to exceeded query cost limit.
+func isGraphQLThrottled(errors []graphQLError) bool {
+	for _, err := range errors {
+		if err.Extensions.Code == graphQLThrottledCode {
+			return true
+		}
+	}
+	return false
+}
+
+// graphQLThrottleWait returns time needed to restore enough query cost to retry the request.
+func graphQLThrottleWait(cost *graphQLCost) time.Duration {
+	if cost == nil || cost.ThrottleStatus.RestoreRate <= 0 {
+		return time.Second
+	}
+
+	missing := cost.RequestedQueryCost - cost.ThrottleStatus.CurrentlyAvailable
+	if missing <= 0 {
+		return time.Second
+	}
+
+	return time.Duration(math.Ceil(missing/cost.ThrottleStatus.RestoreRate)) * time.Second
+}
+
+// userErrorsToError converts mutation's user errors into service.PlatformUserErrors.
+// It returns nil if there are no user errors.
+func userErrorsToError(userErrors []graphQLUserError) error {
+	if len(userErrors) == 0 {
+		return nil
+	}
+
+	platformUserErrors := make(service.PlatformUserErrors, 0, len(userErrors))
+	for _, userError := range userErrors {
+		platformUserErrors = append(platformUserErrors, service.PlatformUserError{
+			Field:   userError.Field,
+			Message: userError.Message,
+		})
+	}
+
+	return platformUserErrors
+}
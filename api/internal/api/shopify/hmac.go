@@ -0,0 +1,129 @@
+package shopify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+func (s *shopifyAPI) VerifyRequestURL(ctx context.Context, requestURL string) error {
+	logger := s.logger.
+		Named("VerifyRequestURL").
+		WithContext(ctx).
+		With("requestURL", requestURL)
+
+	parsedURL, err := url.Parse(requestURL)
+	if err != nil {
+		logger.Info("failed to parse request url", "err", err)
+		return service.ErrVerifyRequestURLInvalidHMAC
+	}
+
+	err = s.verifyQueryHMAC(parsedURL.Query())
+	if err != nil {
+		logger.Info("failed to verify query hmac", "err", err)
+		return err
+	}
+	logger.Debug("verified query hmac")
+
+	return nil
+}
+
+// verifyQueryHMAC verifies hmac signature and timestamp of the query parameters signed by Shopify.
+// https://shopify.dev/docs/apps/auth/oauth/getting-started#step-1-verify-the-installation-request
+func (s *shopifyAPI) verifyQueryHMAC(query url.Values) error {
+	signature, err := hex.DecodeString(query.Get("hmac"))
+	if err != nil || len(signature) == 0 {
+		return service.ErrVerifyRequestURLInvalidHMAC
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.cfg.Shopify.ApiSecret))
+	mac.Write([]byte(buildHMACMessage(query)))
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return service.ErrVerifyRequestURLInvalidHMAC
+	}
+
+	// Verify the request is fresh to prevent replaying of signed urls
+	timestamp, err := strconv.ParseInt(query.Get("timestamp"), 10, 64)
+	if err != nil {
+		return service.ErrVerifyRequestURLExpired
+	}
+	age := time.Since(time.Unix(timestamp, 0))
+	if age < 0 {
+		age = -age
+	}
+	if age > s.cfg.Shopify.HMACMaxAge {
+		return service.ErrVerifyRequestURLExpired
+	}
+
+	return nil
+}
+
+// hmacValueEscaper and hmacKeyEscaper escape characters, which would make the signed message ambiguous.
+var (
+	hmacValueEscaper = strings.NewReplacer("%", "%25", "&", "%26")
+	hmacKeyEscaper   = strings.NewReplacer("%", "%25", "&", "%26", "=", "%3D")
+)
+
+// buildHMACMessage builds the message signed by Shopify: all query parameters except hmac,
+// sorted by key and joined with "&". "%" and "&" are escaped in keys and values, "=" is escaped in keys.
+func buildHMACMessage(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if key == "hmac" || key == "signature" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := make([]string, 0, len(query[key]))
+		for _, value := range query[key] {
+			values = append(values, hmacValueEscaper.Replace(value))
+		}
+		value := values[0]
+		// Array parameters (e.g. ids[]) are signed as a JSON-like list
+		if len(values) > 1 || strings.HasSuffix(key, "[]") {
+			value = fmt.Sprintf(`["%s"]`, strings.Join(values, `", "`))
+			key = strings.TrimSuffix(key, "[]")
+		}
+		pairs = append(pairs, hmacKeyEscaper.Replace(key)+"="+value)
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func (s *shopifyAPI) VerifyWebhook(ctx context.Context, opts service.VerifyWebhookOptions) error {
+	logger := s.logger.
+		Named("VerifyWebhook").
+		WithContext(ctx).
+		With("hmac", opts.HMAC)
+
+	// https://shopify.dev/docs/apps/webhooks/configuration/https#step-5-verify-the-webhook
+	signature, err := base64.StdEncoding.DecodeString(opts.HMAC)
+	if err != nil || len(signature) == 0 {
+		logger.Info("failed to decode webhook hmac", "err", err)
+		return service.ErrVerifyWebhookInvalidHMAC
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.cfg.Shopify.ApiSecret))
+	mac.Write(opts.Body)
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		logger.Info("webhook hmac is incorrect")
+		return service.ErrVerifyWebhookInvalidHMAC
+	}
+	logger.Debug("verified webhook hmac")
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package shopify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+const testAPISecret = "hush"
+
+func newTestAPI(t *testing.T) *shopifyAPI {
+	t.Helper()
+
+	return NewAPI(Options{Config: newTestConfig(), Logger: logging.NewZap("error")})
+}
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Shopify.ApiKey = "api-key"
+	cfg.Shopify.ApiSecret = testAPISecret
+	cfg.Shopify.APIVersion = "2024-04"
+	cfg.Shopify.HMACMaxAge = 90 * time.Second
+	cfg.Shopify.RequestTimeout = 10 * time.Second
+	cfg.Shopify.RateLimitBucketSize = 40
+	cfg.Shopify.RateLimitLeakRate = 2
+	cfg.Shopify.RateLimitThreshold = 0.8
+	cfg.Shopify.CreateProductsConcurrency = 2
+
+	return cfg
+}
+
+func signHex(message string) string {
+	mac := hmac.New(sha256.New, []byte(testAPISecret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestBuildHMACMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		want  string
+	}{
+		{
+			name: "sorts keys and skips signatures",
+			query: url.Values{
+				"timestamp": {"1337178173"},
+				"shop":      {"some-shop.myshopify.com"},
+				"hmac":      {"ignored"},
+				"signature": {"ignored"},
+				"code":      {"0907a61c0c8d55e99db179b68161bc00"},
+			},
+			want: "code=0907a61c0c8d55e99db179b68161bc00&shop=some-shop.myshopify.com&timestamp=1337178173",
+		},
+		{
+			name:  "escapes percent and ampersand in values",
+			query: url.Values{"state": {"a&b%c=d"}},
+			want:  "state=a%26b%25c=d",
+		},
+		{
+			name:  "escapes equals sign in keys",
+			query: url.Values{"a=b&c": {"d"}},
+			want:  "a%3Db%26c=d",
+		},
+		{
+			name:  "signs array parameters as list",
+			query: url.Values{"ids[]": {"1", "2"}, "shop": {"s"}},
+			want:  `ids=["1", "2"]&shop=s`,
+		},
+		{
+			name:  "signs single value array parameter as list",
+			query: url.Values{"ids[]": {"1"}},
+			want:  `ids=["1"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildHMACMessage(tt.query)
+			if got != tt.want {
+				t.Errorf("buildHMACMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRequestURL(t *testing.T) {
+	api := newTestAPI(t)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-2*time.Minute).Unix(), 10)
+	future := strconv.FormatInt(time.Now().Add(2*time.Minute).Unix(), 10)
+
+	signedURL := func(hmac string, params string) string {
+		return "https://app.example.com/api/auth?" + params + "&hmac=" + hmac
+	}
+
+	tests := []struct {
+		name       string
+		requestURL string
+		wantErr    error
+	}{
+		{
+			name:       "valid",
+			requestURL: signedURL(signHex("shop=s.myshopify.com&timestamp="+now), "timestamp="+now+"&shop=s.myshopify.com"),
+		},
+		{
+			name:       "valid with escaped value",
+			requestURL: signedURL(signHex("shop=s.myshopify.com&state=a%26b&timestamp="+now), "shop=s.myshopify.com&state=a%26b&timestamp="+now),
+		},
+		{
+			name:       "bad signature",
+			requestURL: signedURL(signHex("shop=other.myshopify.com&timestamp="+now), "shop=s.myshopify.com&timestamp="+now),
+			wantErr:    service.ErrVerifyRequestURLInvalidHMAC,
+		},
+		{
+			name:       "signature is not hex",
+			requestURL: signedURL("not-hex", "shop=s.myshopify.com&timestamp="+now),
+			wantErr:    service.ErrVerifyRequestURLInvalidHMAC,
+		},
+		{
+			name:       "missing signature",
+			requestURL: "https://app.example.com/api/auth?shop=s.myshopify.com&timestamp=" + now,
+			wantErr:    service.ErrVerifyRequestURLInvalidHMAC,
+		},
+		{
+			name:       "stale timestamp",
+			requestURL: signedURL(signHex("shop=s.myshopify.com&timestamp="+stale), "shop=s.myshopify.com&timestamp="+stale),
+			wantErr:    service.ErrVerifyRequestURLExpired,
+		},
+		{
+			name:       "timestamp in the future",
+			requestURL: signedURL(signHex("shop=s.myshopify.com&timestamp="+future), "shop=s.myshopify.com&timestamp="+future),
+			wantErr:    service.ErrVerifyRequestURLExpired,
+		},
+		{
+			name:       "missing timestamp",
+			requestURL: signedURL(signHex("shop=s.myshopify.com"), "shop=s.myshopify.com"),
+			wantErr:    service.ErrVerifyRequestURLExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := api.VerifyRequestURL(context.Background(), tt.requestURL)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyRequestURL() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	api := newTestAPI(t)
+	body := []byte(`{"shop_domain":"s.myshopify.com"}`)
+	mac := hmac.New(sha256.New, []byte(testAPISecret))
+	mac.Write(body)
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name    string
+		opts    service.VerifyWebhookOptions
+		wantErr error
+	}{
+		{
+			name: "valid",
+			opts: service.VerifyWebhookOptions{Body: body, HMAC: signature},
+		},
+		{
+			name:    "modified body",
+			opts:    service.VerifyWebhookOptions{Body: append(body, ' '), HMAC: signature},
+			wantErr: service.ErrVerifyWebhookInvalidHMAC,
+		},
+		{
+			name:    "missing signature",
+			opts:    service.VerifyWebhookOptions{Body: body},
+			wantErr: service.ErrVerifyWebhookInvalidHMAC,
+		},
+		{
+			name:    "signature is not base64",
+			opts:    service.VerifyWebhookOptions{Body: body, HMAC: "%%%"},
+			wantErr: service.ErrVerifyWebhookInvalidHMAC,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := api.VerifyWebhook(context.Background(), tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,20 +1,32 @@
 package shopify
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"net/url"
 
-	"github.com/google/uuid"
 	"github.com/softcery/shopify-app-template-go/internal/service"
 )
 
-func (s *shopifyAPI) HandleInstall(opts service.HandleInstallOptions) (service.APIHandleInstallOutput, error) {
+// nonceLength is a number of random bytes used to generate nonce.
+const nonceLength = 32
+
+func (s *shopifyAPI) HandleInstall(ctx context.Context, opts service.HandleInstallOptions) (service.APIHandleInstallOutput, error) {
 	logger := s.logger.
 		Named("HandleInstall").
+		WithContext(ctx).
 		With("opts", opts)
 
-	storeNonce := s.generateNonce()
+	storeNonce, err := s.generateNonce()
+	if err != nil {
+		logger.Error("failed to generate nonce", "err", err)
+		return service.APIHandleInstallOutput{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
 	// Build redirection URL
 	values := url.Values{
 		"client_id":       {s.cfg.Shopify.ApiKey},
@@ -28,15 +40,19 @@ func (s *shopifyAPI) HandleInstall(opts service.HandleInstallOptions) (service.A
 	logger.Debug("built values")
 	return service.APIHandleInstallOutput{
 		Nonce:       storeNonce,
-		RedirectURL: fmt.Sprintf("https://%s/admin/oauth/authorize?%s", opts.StoreName, values.Encode()),
+		RedirectURL: fmt.Sprintf("%s/admin/oauth/authorize?%s", s.baseURL(opts.StoreName), values.Encode()),
 	}, nil
 }
 
-func (s *shopifyAPI) HandleRedirect(opts service.APIHandleRedirectOptions) (string, error) {
+func (s *shopifyAPI) HandleRedirect(ctx context.Context, opts service.APIHandleRedirectOptions) (string, error) {
 	logger := s.logger.
 		Named("HandleRedirect").
+		WithContext(ctx).
 		With("opts", opts)
 
+	ctx, cancel := s.withTimeout(ctx, "HandleRedirect")
+	defer cancel()
+
 	// Verify redirected URL
 	parsedURL, err := url.Parse(opts.RedirectedURL)
 	if err != nil {
@@ -53,13 +69,14 @@ func (s *shopifyAPI) HandleRedirect(opts service.APIHandleRedirectOptions) (stri
 	query := parsedURL.Query()
 	var credentials map[string]string
 	res, err := s.client.R().
+		SetContext(ctx).
 		SetQueryParams(map[string]string{
 			"client_id":     s.cfg.Shopify.ApiKey,
 			"client_secret": s.cfg.Shopify.ApiSecret,
 			"code":          query.Get("code"),
 		}).
 		SetResult(&credentials).
-		Post(fmt.Sprintf("https://%s/admin/oauth/access_token", opts.StoreName))
+		Post(s.baseURL(opts.StoreName) + "/admin/oauth/access_token")
 	if err != nil {
 		logger.Error("failed to get shopifyAPI access token", "err", err)
 		return "", fmt.Errorf("failed to get shopifyAPI access token: %w", err)
@@ -82,18 +99,18 @@ func (s *shopifyAPI) HandleRedirect(opts service.APIHandleRedirectOptions) (stri
 func (s *shopifyAPI) verifyNonce(actualNonce string, url *url.URL) bool {
 	q := url.Query()
 	nonce := q.Get("state")
-	if nonce == "" {
-		return true
+	if nonce == "" || actualNonce == "" {
+		return false
 	}
-	return nonce == actualNonce
+	return subtle.ConstantTimeCompare([]byte(nonce), []byte(actualNonce)) == 1
 }
 
-// generateNonce is used to generate random nonce.
-func (s *shopifyAPI) generateNonce() string {
-	nonce, err := uuid.NewUUID()
+// generateNonce is used to generate cryptographically random nonce.
+func (s *shopifyAPI) generateNonce() (string, error) {
+	nonce := make([]byte, nonceLength)
+	_, err := rand.Read(nonce)
 	if err != nil {
-		// If nonce couldn't be generated, use default string
-		return "jg6yf3JAdB5hvFaG1o"
+		return "", err
 	}
-	return nonce.String()
+	return hex.EncodeToString(nonce), nil
 }
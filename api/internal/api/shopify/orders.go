@@ -0,0 +1,163 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+// orderResource is an order of REST Admin API.
+type orderResource struct {
+	ID                int64      `json:"id"`
+	AdminGraphQLAPIID string     `json:"admin_graphql_api_id"`
+	Name              string     `json:"name"`
+	Email             string     `json:"email"`
+	Currency          string     `json:"currency"`
+	FinancialStatus   string     `json:"financial_status"`
+	FulfillmentStatus *string    `json:"fulfillment_status"`
+	TotalPrice        string     `json:"total_price"`
+	SubtotalPrice     string     `json:"subtotal_price"`
+	TotalTax          string     `json:"total_tax"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	ProcessedAt       *time.Time `json:"processed_at"`
+	CancelledAt       *time.Time `json:"cancelled_at"`
+	LineItems         []struct {
+		ID           int64  `json:"id"`
+		ProductID    *int64 `json:"product_id"`
+		VariantID    *int64 `json:"variant_id"`
+		Title        string `json:"title"`
+		VariantTitle string `json:"variant_title"`
+		SKU          string `json:"sku"`
+		Quantity     int    `json:"quantity"`
+		Price        string `json:"price"`
+	} `json:"line_items"`
+	Fulfillments []struct {
+		ID              int64     `json:"id"`
+		Status          string    `json:"status"`
+		TrackingCompany string    `json:"tracking_company"`
+		TrackingNumbers []string  `json:"tracking_numbers"`
+		CreatedAt       time.Time `json:"created_at"`
+	} `json:"fulfillments"`
+}
+
+func (r *orderResource) toOrder() service.Order {
+	order := service.Order{
+		ID:              r.AdminGraphQLAPIID,
+		Name:            r.Name,
+		Email:           r.Email,
+		Currency:        r.Currency,
+		FinancialStatus: r.FinancialStatus,
+		TotalPrice:      r.TotalPrice,
+		SubtotalPrice:   r.SubtotalPrice,
+		TotalTax:        r.TotalTax,
+		LineItems:       make([]service.OrderLineItem, 0, len(r.LineItems)),
+		Fulfillments:    make([]service.OrderFulfillment, 0, len(r.Fulfillments)),
+		CreatedAt:       r.CreatedAt,
+		UpdatedAt:       r.UpdatedAt,
+		ProcessedAt:     r.ProcessedAt,
+		CancelledAt:     r.CancelledAt,
+	}
+	if r.FulfillmentStatus != nil {
+		order.FulfillmentStatus = *r.FulfillmentStatus
+	}
+
+	for _, item := range r.LineItems {
+		lineItem := service.OrderLineItem{
+			ID:           shopifyGID("LineItem", fmt.Sprint(item.ID)),
+			Title:        item.Title,
+			VariantTitle: item.VariantTitle,
+			SKU:          item.SKU,
+			Quantity:     item.Quantity,
+			Price:        item.Price,
+		}
+		// Product and variant are missing if they have been deleted
+		if item.ProductID != nil {
+			lineItem.ProductID = productGID(fmt.Sprint(*item.ProductID))
+		}
+		if item.VariantID != nil {
+			lineItem.VariantID = productVariantGID(fmt.Sprint(*item.VariantID))
+		}
+		order.LineItems = append(order.LineItems, lineItem)
+	}
+
+	for _, fulfillment := range r.Fulfillments {
+		order.Fulfillments = append(order.Fulfillments, service.OrderFulfillment{
+			ID:              shopifyGID("Fulfillment", fmt.Sprint(fulfillment.ID)),
+			Status:          fulfillment.Status,
+			TrackingCompany: fulfillment.TrackingCompany,
+			TrackingNumbers: fulfillment.TrackingNumbers,
+			CreatedAt:       fulfillment.CreatedAt,
+		})
+	}
+
+	return order
+}
+
+func (s *shopifyAPI) IterateOrders(ctx context.Context, opts service.IterateOrdersOptions) service.Iterator[service.Order] {
+	// Closed and cancelled orders are skipped by default
+	query := url.Values{"status": {"any"}}
+	if opts.UpdatedAtMin != nil {
+		query.Set("updated_at_min", opts.UpdatedAtMin.UTC().Format(time.RFC3339))
+		query.Set("order", "updated_at asc")
+	}
+
+	fetch := restPageFetcher[orderResource](s, "/orders.json", query, "orders")
+
+	return newPaginator(withPageTimeout(s, "IterateOrders", func(ctx context.Context, cursor string) ([]service.Order, string, error) {
+		resources, next, err := fetch(ctx, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		orders := make([]service.Order, 0, len(resources))
+		for i := range resources {
+			orders = append(orders, resources[i].toOrder())
+		}
+		return orders, next, nil
+	}))
+}
+
+type getOrderResponseBody struct {
+	Order orderResource `json:"order"`
+}
+
+func (s *shopifyAPI) GetOrder(ctx context.Context, id string) (*service.Order, error) {
+	logger := s.logger.
+		Named("GetOrder").
+		WithContext(ctx).
+		With("id", id)
+
+	ctx, cancel := s.withTimeout(ctx, "GetOrder")
+	defer cancel()
+
+	var responseBody getOrderResponseBody
+	res, err := s.client.R().
+		SetContext(ctx).
+		SetResult(&responseBody).
+		Get(s.adminPath(fmt.Sprintf("/orders/%s.json", legacyID(id))))
+	if err != nil {
+		logger.Error("failed to get order", "err", err)
+		return nil, err
+	}
+	if res.StatusCode() == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.StatusCode() != http.StatusOK {
+		logger.Error("failed to get order", "status", res.StatusCode(), "body", res.String())
+		return nil, fmt.Errorf("failed to get order: http status %d", res.StatusCode())
+	}
+
+	order := responseBody.Order.toOrder()
+	return &order, nil
+}
+
+// legacyID returns numeric ID used by REST API, id can be either numeric ID or global ID.
+func legacyID(id string) string {
+	return id[strings.LastIndex(id, "/")+1:]
+}
@@ -0,0 +1,200 @@
+package shopify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+// defaultPageSize is a number of items fetched per page, if not specified.
+const defaultPageSize = 50
+
+// nextPageLinkRegexp matches URL of the next page in REST API's Link header.
+var nextPageLinkRegexp = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// pageFetcher fetches a single page of items starting at the cursor.
+// It returns cursor of the next page, which is empty if the page is the last one.
+type pageFetcher[T any] func(ctx context.Context, cursor string) ([]T, string, error)
+
+// paginator implements service.Iterator fetching items page by page.
+type paginator[T any] struct {
+	fetch  pageFetcher[T]
+	page   []T
+	index  int
+	cursor string
+	done   bool
+	err    error
+}
+
+var _ service.Iterator[struct{}] = (*paginator[struct{}])(nil)
+
+func newPaginator[T any](fetch pageFetcher[T]) *paginator[T] {
+	return &paginator[T]{
+		fetch: fetch,
+		index: -1,
+	}
+}
+
+func (p *paginator[T]) Next(ctx context.Context) bool {
+	if p.err != nil {
+		return false
+	}
+
+	p.index++
+	for p.index >= len(p.page) {
+		if p.done {
+			return false
+		}
+		if err := ctx.Err(); err != nil {
+			p.err = err
+			return false
+		}
+
+		page, cursor, err := p.fetch(ctx, p.cursor)
+		if err != nil {
+			p.err = err
+			return false
+		}
+		p.page, p.index = page, 0
+		p.cursor, p.done = cursor, cursor == ""
+	}
+
+	return true
+}
+
+func (p *paginator[T]) Item() T {
+	return p.page[p.index]
+}
+
+func (p *paginator[T]) Err() error {
+	return p.err
+}
+
+// withPageTimeout bounds fetching of every page with the operation's timeout, since the whole iteration may take long.
+func withPageTimeout[T any](s *shopifyAPI, operation string, fetch pageFetcher[T]) pageFetcher[T] {
+	return func(ctx context.Context, cursor string) ([]T, string, error) {
+		ctx, cancel := s.withTimeout(ctx, operation)
+		defer cancel()
+
+		return fetch(ctx, cursor)
+	}
+}
+
+// restPageFetcher fetches pages of REST API list endpoint following Link header's page_info cursors.
+// Items are decoded from the response's field named key, e.g. "orders".
+// https://shopify.dev/docs/api/usage/pagination-rest
+func restPageFetcher[T any](s *shopifyAPI, path string, query url.Values, key string) pageFetcher[T] {
+	return func(ctx context.Context, cursor string) ([]T, string, error) {
+		params := url.Values{"limit": {fmt.Sprint(defaultPageSize)}}
+		if cursor == "" {
+			for name, values := range query {
+				params[name] = values
+			}
+		} else {
+			// Subsequent pages accept only limit, fields and page_info parameters
+			params.Set("page_info", cursor)
+			if limit := query.Get("limit"); limit != "" {
+				params.Set("limit", limit)
+			}
+			if fields := query.Get("fields"); fields != "" {
+				params.Set("fields", fields)
+			}
+		}
+
+		var responseBody map[string]json.RawMessage
+		res, err := s.client.R().
+			SetContext(ctx).
+			SetQueryParamsFromValues(params).
+			SetResult(&responseBody).
+			Get(s.adminPath(path))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get page: %w", err)
+		}
+		if res.StatusCode() != http.StatusOK {
+			return nil, "", fmt.Errorf("failed to get page: http status %d, body %s", res.StatusCode(), res.String())
+		}
+
+		var items []T
+		if raw, ok := responseBody[key]; ok {
+			err = json.Unmarshal(raw, &items)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to decode page: %w", err)
+			}
+		}
+
+		return items, nextPageInfo(res.Header().Get("Link")), nil
+	}
+}
+
+// nextPageInfo extracts page_info cursor of the next page from the Link header.
+func nextPageInfo(link string) string {
+	match := nextPageLinkRegexp.FindStringSubmatch(link)
+	if match == nil {
+		return ""
+	}
+
+	nextURL, err := url.Parse(match[1])
+	if err != nil {
+		return ""
+	}
+
+	return nextURL.Query().Get("page_info")
+}
+
+type graphQLPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// graphQLConnection is a page of GraphQL connection.
+// Queries must select nodes and pageInfo { hasNextPage endCursor } of the connection.
+type graphQLConnection[T any] struct {
+	Nodes    []T             `json:"nodes"`
+	PageInfo graphQLPageInfo `json:"pageInfo"`
+}
+
+// graphQLPageFetcher fetches pages of GraphQL connection following pageInfo's endCursor.
+// Query must accept $first and $after variables, connection returns connection from the query's data.
+// https://shopify.dev/docs/api/usage/pagination-graphql
+func graphQLPageFetcher[D any, T any](s *shopifyAPI, query string, variables map[string]interface{}, connection func(data *D) graphQLConnection[T]) pageFetcher[T] {
+	return func(ctx context.Context, cursor string) ([]T, string, error) {
+		pageVariables := map[string]interface{}{"first": defaultPageSize}
+		for name, value := range variables {
+			pageVariables[name] = value
+		}
+		if cursor != "" {
+			pageVariables["after"] = cursor
+		}
+
+		data, err := graphQL[D](ctx, s, query, pageVariables)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get page: %w", err)
+		}
+
+		page := connection(data)
+		if !page.PageInfo.HasNextPage {
+			return page.Nodes, "", nil
+		}
+
+		return page.Nodes, page.PageInfo.EndCursor, nil
+	}
+}
+
+// fetchPagesAfter fetches every page following the cursor.
+func fetchPagesAfter[T any](ctx context.Context, fetch pageFetcher[T], cursor string) ([]T, error) {
+	var items []T
+	for cursor != "" {
+		page, next, err := fetch(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		cursor = next
+	}
+	return items, nil
+}
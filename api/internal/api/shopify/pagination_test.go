@@ -0,0 +1,232 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+func TestNextPageInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{
+			name: "next only",
+			link: `<https://s.myshopify.com/admin/api/2024-04/orders.json?limit=50&page_info=abc>; rel="next"`,
+			want: "abc",
+		},
+		{
+			name: "previous and next",
+			link: `<https://s.myshopify.com/admin/api/2024-04/orders.json?limit=50&page_info=prev>; rel="previous", ` +
+				`<https://s.myshopify.com/admin/api/2024-04/orders.json?limit=50&page_info=next>; rel="next"`,
+			want: "next",
+		},
+		{
+			name: "next and previous",
+			link: `<https://s.myshopify.com/admin/api/2024-04/orders.json?page_info=next>; rel="next", ` +
+				`<https://s.myshopify.com/admin/api/2024-04/orders.json?page_info=prev>; rel="previous"`,
+			want: "next",
+		},
+		{
+			name: "last page has previous only",
+			link: `<https://s.myshopify.com/admin/api/2024-04/orders.json?limit=50&page_info=prev>; rel="previous"`,
+			want: "",
+		},
+		{
+			name: "no header",
+			link: "",
+			want: "",
+		},
+		{
+			name: "malformed header",
+			link: `https://s.myshopify.com/admin/api/2024-04/orders.json?page_info=abc; rel=next`,
+			want: "",
+		},
+		{
+			name: "malformed URL",
+			link: `<%zz?page_info=abc>; rel="next"`,
+			want: "",
+		},
+		{
+			name: "next without page_info",
+			link: `<https://s.myshopify.com/admin/api/2024-04/orders.json?limit=50>; rel="next"`,
+			want: "",
+		},
+		{
+			name: "escaped page_info",
+			link: `<https://s.myshopify.com/admin/api/2024-04/orders.json?page_info=a%2Bb%3D>; rel="next"`,
+			want: "a+b=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextPageInfo(tt.link)
+			if got != tt.want {
+				t.Errorf("nextPageInfo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginator(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+
+	// pages maps cursor to the page fetched with it, "" is the first page
+	type page struct {
+		items []int
+		next  string
+		err   error
+	}
+
+	tests := []struct {
+		name      string
+		pages     map[string]page
+		wantItems []int
+		wantErr   error
+	}{
+		{
+			name:      "single page",
+			pages:     map[string]page{"": {items: []int{1, 2}}},
+			wantItems: []int{1, 2},
+		},
+		{
+			name: "several pages",
+			pages: map[string]page{
+				"":  {items: []int{1, 2}, next: "b"},
+				"b": {items: []int{3}, next: "c"},
+				"c": {items: []int{4, 5}},
+			},
+			wantItems: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:  "no items",
+			pages: map[string]page{"": {}},
+		},
+		{
+			name: "empty page in the middle",
+			pages: map[string]page{
+				"":  {items: []int{1}, next: "b"},
+				"b": {next: "c"},
+				"c": {items: []int{2}},
+			},
+			wantItems: []int{1, 2},
+		},
+		{
+			name: "error on next page",
+			pages: map[string]page{
+				"":  {items: []int{1, 2}, next: "b"},
+				"b": {err: errFetch},
+			},
+			wantItems: []int{1, 2},
+			wantErr:   errFetch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cursors []string
+			p := newPaginator(func(ctx context.Context, cursor string) ([]int, string, error) {
+				cursors = append(cursors, cursor)
+				page, ok := tt.pages[cursor]
+				if !ok {
+					t.Fatalf("unexpected cursor %q", cursor)
+				}
+				return page.items, page.next, page.err
+			})
+
+			var items []int
+			for p.Next(context.Background()) {
+				items = append(items, p.Item())
+			}
+
+			if !reflect.DeepEqual(items, tt.wantItems) {
+				t.Errorf("items = %v, want %v", items, tt.wantItems)
+			}
+			if !errors.Is(p.Err(), tt.wantErr) {
+				t.Errorf("Err() = %v, want %v", p.Err(), tt.wantErr)
+			}
+			if len(cursors) != len(tt.pages) {
+				t.Errorf("fetched cursors %q, want each of %d pages once", cursors, len(tt.pages))
+			}
+			if p.Next(context.Background()) {
+				t.Error("Next() = true after iteration is over")
+			}
+		})
+	}
+}
+
+func TestPaginatorCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := newPaginator(func(ctx context.Context, cursor string) ([]int, string, error) {
+		return []int{1}, "next", nil
+	})
+
+	if !p.Next(ctx) {
+		t.Fatalf("Next() = false, err %v", p.Err())
+	}
+	cancel()
+
+	if p.Next(ctx) {
+		t.Error("Next() = true with canceled context")
+	}
+	if !errors.Is(p.Err(), context.Canceled) {
+		t.Errorf("Err() = %v, want %v", p.Err(), context.Canceled)
+	}
+}
+
+func TestRESTPageFetcher(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Query().Get("page_info") {
+		case "":
+			w.Header().Set("Link", `<http://`+r.Host+r.URL.Path+`?limit=2&page_info=second>; rel="next"`)
+			_, _ = w.Write([]byte(`{"orders":[{"id":1},{"id":2}]}`))
+		case "second":
+			w.Header().Set("Link", `<http://`+r.Host+r.URL.Path+`?limit=2&page_info=first>; rel="previous"`)
+			_, _ = w.Write([]byte(`{"orders":[{"id":3}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	api := NewAPI(Options{
+		Config:  newTestConfig(),
+		Logger:  logging.NewZap("error"),
+		BaseURL: func(string) string { return server.URL },
+	}).WithConfig(context.Background(), &entity.Store{Name: "s.myshopify.com", AccessToken: "token"}).(*shopifyAPI)
+
+	type order struct {
+		ID int `json:"id"`
+	}
+	query := map[string][]string{"limit": {"2"}, "status": {"any"}}
+	p := newPaginator(restPageFetcher[order](api, "/orders.json", query, "orders"))
+
+	var ids []int
+	for p.Next(context.Background()) {
+		ids = append(ids, p.Item().ID)
+	}
+	if err := p.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+	// Filters are sent with the first page only
+	if want := []string{"limit=2&status=any", "limit=2&page_info=second"}; !reflect.DeepEqual(queries, want) {
+		t.Errorf("queries = %q, want %q", queries, want)
+	}
+}
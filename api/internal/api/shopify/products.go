@@ -4,81 +4,899 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
-	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/softcery/shopify-app-template-go/internal/service"
 )
 
-type createProductRequestBody struct {
-	Product *product `json:"product"`
-}
+const productCreateMutation = `
+mutation productCreate($input: ProductInput!, $media: [CreateMediaInput!]) {
+	productCreate(input: $input, media: $media) {
+		product {
+			id
+		}
+		userErrors {
+			field
+			message
+		}
+	}
+}`
 
-type product struct {
-	Title       string `json:"title"`
-	BodyHTML    string `json:"body_html"`
-	Vendor      string `json:"vendor"`
-	ProductType string `json:"product_type"`
-	Status      string `json:"status"`
+type productCreateData struct {
+	ProductCreate struct {
+		Product *struct {
+			ID string `json:"id"`
+		} `json:"product"`
+		UserErrors []graphQLUserError `json:"userErrors"`
+	} `json:"productCreate"`
 }
 
-func (s *shopifyAPI) CreateProducts(ctx context.Context) error {
+func (s *shopifyAPI) CreateProducts(ctx context.Context, opts service.CreateProductsOptions) []service.CreateProductResult {
 	logger := s.logger.
 		Named("CreateProducts").
-		WithContext(ctx)
+		WithContext(ctx).
+		With("count", opts.Count)
+
+	concurrency := s.cfg.Shopify.CreateProductsConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	// Throttled requests are retried by graphQL, so concurrency only bounds the load on the store's query cost bucket
+	results := make([]service.CreateProductResult, opts.Count)
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range results {
+		template := service.ProductTemplate{}
+		if len(opts.Templates) > 0 {
+			template = opts.Templates[i%len(opts.Templates)]
+		}
+
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			// Products, which creation is never started, fail with the context's error
+			logger.Info("products creation is canceled", "started", i, "err", ctx.Err())
+			for j := i; j < len(results); j++ {
+				results[j] = service.CreateProductResult{Index: j, Err: ctx.Err()}
+			}
+			wg.Wait()
+			return results
+		}
+
+		wg.Add(1)
+		go func(i int, template service.ProductTemplate) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			id, err := s.createProduct(ctx, template)
+			if err != nil {
+				logger.Info("failed to create product", "index", i, "err", err)
+			}
+			results[i] = service.CreateProductResult{Index: i, ID: legacyID(id), Err: err}
+		}(i, template)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// createProduct creates a single product from the template and returns its ID.
+func (s *shopifyAPI) createProduct(ctx context.Context, template service.ProductTemplate) (string, error) {
+	ctx, cancel := s.withTimeout(ctx, "CreateProduct")
+	defer cancel()
+
+	title := template.Title
+	if title == "" {
+		title = generateRandomProductTitle()
+	}
+	input := map[string]interface{}{
+		"title":           title,
+		"descriptionHtml": template.DescriptionHTML,
+		"vendor":          template.Vendor,
+		"productType":     template.ProductType,
+		"status":          template.Status,
+	}
+	if template.DescriptionHTML == "" {
+		input["descriptionHtml"] = fmt.Sprintf("<p>Product %s</p>", title)
+	}
+	if template.Vendor == "" {
+		input["vendor"] = "Vendor"
+	}
+	if template.ProductType == "" {
+		input["productType"] = "Type"
+	}
+	if template.Status == "" {
+		input["status"] = service.ProductStatusActive
+	}
+	if len(template.Tags) > 0 {
+		input["tags"] = template.Tags
+	}
+	optionName := template.OptionName
+	if optionName == "" {
+		optionName = "Title"
+	}
+	if len(template.Variants) > 0 {
+		values := make([]map[string]interface{}, 0, len(template.Variants))
+		for _, variant := range template.Variants {
+			values = append(values, map[string]interface{}{"name": variant.Option})
+		}
+		input["productOptions"] = []map[string]interface{}{{"name": optionName, "values": values}}
+	}
+
+	variables := map[string]interface{}{
+		"input": input,
+	}
+	if len(template.Images) > 0 {
+		variables["media"] = mediaInput(template.Images)
+	}
+
+	data, err := graphQL[productCreateData](ctx, s, productCreateMutation, variables)
+	if err != nil {
+		return "", err
+	}
+	err = userErrorsToError(data.ProductCreate.UserErrors)
+	if err != nil {
+		return "", err
+	}
+	if data.ProductCreate.Product == nil {
+		return "", fmt.Errorf("product is not returned")
+	}
+	id := data.ProductCreate.Product.ID
 
-	for i := 0; i < service.DEFAULT_PRODUCT_COUNT; i++ {
-		title := generateRandomProductTitle()
-		product := &createProductRequestBody{
-			Product: &product{
-				Title:       title,
-				BodyHTML:    fmt.Sprintf("<p>Product %s</p>", title),
-				Vendor:      "Vendor",
-				ProductType: "Type",
-				Status:      "active",
-			},
-		}
-		res, err := s.client.R().
-			SetBody(product).
-			Post("/admin/api/2022-07/products.json")
+	if len(template.Variants) > 0 {
+		variants := make([]map[string]interface{}, 0, len(template.Variants))
+		for _, variant := range template.Variants {
+			variants = append(variants, createVariantInput(optionName, variant))
+		}
+
+		// Product is created with a standalone variant of the first option value, which is replaced with the template's ones
+		data, err := graphQL[productVariantsBulkCreateData](ctx, s, productVariantsBulkCreateMutation, map[string]interface{}{
+			"productId": id,
+			"variants":  variants,
+			"strategy":  "REMOVE_STANDALONE_VARIANT",
+		})
 		if err != nil {
-			logger.Error("failed to create product", "err", err)
-			return err
+			return id, fmt.Errorf("failed to create variants: %w", err)
 		}
-		if res.StatusCode() != http.StatusCreated {
-			logger.Error("failed to create product", "status", res.StatusCode())
-			return fmt.Errorf("failed to create product")
+		err = userErrorsToError(data.ProductVariantsBulkCreate.UserErrors)
+		if err != nil {
+			return id, err
 		}
 	}
-	logger.Info("created products")
 
-	return nil
+	return id, nil
+}
+
+const productVariantsBulkCreateMutation = `
+mutation productVariantsBulkCreate($productId: ID!, $variants: [ProductVariantsBulkInput!]!, $strategy: ProductVariantsBulkCreateStrategy) {
+	productVariantsBulkCreate(productId: $productId, variants: $variants, strategy: $strategy) {
+		userErrors {
+			field
+			message
+		}
+	}
+}`
+
+type productVariantsBulkCreateData struct {
+	ProductVariantsBulkCreate struct {
+		UserErrors []graphQLUserError `json:"userErrors"`
+	} `json:"productVariantsBulkCreate"`
+}
+
+func createVariantInput(optionName string, variant service.ProductVariantTemplate) map[string]interface{} {
+	input := map[string]interface{}{
+		"optionValues": []map[string]interface{}{{"optionName": optionName, "name": variant.Option}},
+	}
+	if variant.Price != "" {
+		input["price"] = variant.Price
+	}
+	if variant.CompareAtPrice != "" {
+		input["compareAtPrice"] = variant.CompareAtPrice
+	}
+	if variant.SKU != "" {
+		input["inventoryItem"] = map[string]interface{}{"sku": variant.SKU}
+	}
+	return input
+}
+
+func mediaInput(images []service.CreateProductImageOptions) []map[string]interface{} {
+	media := make([]map[string]interface{}, 0, len(images))
+	for _, image := range images {
+		media = append(media, map[string]interface{}{
+			"originalSource":   image.URL,
+			"alt":              image.AltText,
+			"mediaContentType": "IMAGE",
+		})
+	}
+	return media
 }
 
 func generateRandomProductTitle() string {
 	return fmt.Sprintf("Product %d", rand.Intn(1000))
 }
 
+const productsCountQuery = `
+query productsCount {
+	productsCount {
+		count
+	}
+}`
+
+type productsCountData struct {
+	ProductsCount struct {
+		Count int `json:"count"`
+	} `json:"productsCount"`
+}
+
 func (s *shopifyAPI) GetProductsCount(ctx context.Context) (int, error) {
 	logger := s.logger.
 		Named("GetProductsCount").
 		WithContext(ctx)
 
-	var responseBody struct {
-		Count int `json:"count"`
-	}
+	ctx, cancel := s.withTimeout(ctx, "GetProductsCount")
+	defer cancel()
 
-	resp, err := s.client.R().
-		SetResult(&responseBody).
-		Get("/admin/api/2022-07/products/count.json")
+	data, err := graphQL[productsCountData](ctx, s, productsCountQuery, nil)
 	if err != nil {
 		logger.Error("failed to get products count", "err", err)
 		return 0, err
 	}
 
-	if resp.StatusCode() != 200 {
-		logger.Error("failed to get products count", "status", resp.StatusCode())
-		return 0, fmt.Errorf("failed to get products count")
+	return data.ProductsCount.Count, nil
+}
+
+// productVariantFields are fields of the product variant fetched by product queries.
+const productVariantFields = `
+	id
+	title
+	sku
+	barcode
+	price
+	compareAtPrice
+	inventoryQuantity
+	selectedOptions {
+		name
+		value
+	}`
+
+// productImageFields are fields of the product image fetched by product queries.
+const productImageFields = `
+	id
+	url
+	altText`
+
+// productFields are fields of the product fetched by product queries.
+// Variants and images beyond the first page are fetched by productVariantsQuery and productImagesQuery.
+const productFields = `
+	id
+	title
+	descriptionHtml
+	handle
+	status
+	vendor
+	productType
+	tags
+	createdAt
+	updatedAt
+	options {
+		id
+		name
+		values
+	}
+	variants(first: 50) {
+		nodes {` + productVariantFields + `
+		}
+		pageInfo {
+			hasNextPage
+			endCursor
+		}
+	}
+	images(first: 10) {
+		nodes {` + productImageFields + `
+		}
+		pageInfo {
+			hasNextPage
+			endCursor
+		}
+	}`
+
+const productVariantsQuery = `
+query productVariants($id: ID!, $first: Int!, $after: String) {
+	product(id: $id) {
+		variants(first: $first, after: $after) {
+			nodes {` + productVariantFields + `
+			}
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+		}
+	}
+}`
+
+const productImagesQuery = `
+query productImages($id: ID!, $first: Int!, $after: String) {
+	product(id: $id) {
+		images(first: $first, after: $after) {
+			nodes {` + productImageFields + `
+			}
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+		}
+	}
+}`
+
+const productsQuery = `
+query products($first: Int!, $after: String, $query: String) {
+	products(first: $first, after: $after, query: $query) {
+		nodes {` + productFields + `
+		}
+		pageInfo {
+			hasNextPage
+			endCursor
+		}
+	}
+}`
+
+type productVariantNode struct {
+	ID                string  `json:"id"`
+	Title             string  `json:"title"`
+	SKU               string  `json:"sku"`
+	Barcode           string  `json:"barcode"`
+	Price             string  `json:"price"`
+	CompareAtPrice    *string `json:"compareAtPrice"`
+	InventoryQuantity int     `json:"inventoryQuantity"`
+	SelectedOptions   []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"selectedOptions"`
+}
+
+type productImageNode struct {
+	ID      string `json:"id"`
+	URL     string `json:"url"`
+	AltText string `json:"altText"`
+}
+
+type productNode struct {
+	ID              string    `json:"id"`
+	Title           string    `json:"title"`
+	DescriptionHTML string    `json:"descriptionHtml"`
+	Handle          string    `json:"handle"`
+	Status          string    `json:"status"`
+	Vendor          string    `json:"vendor"`
+	ProductType     string    `json:"productType"`
+	Tags            []string  `json:"tags"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	Options         []struct {
+		ID     string   `json:"id"`
+		Name   string   `json:"name"`
+		Values []string `json:"values"`
+	} `json:"options"`
+	Variants graphQLConnection[productVariantNode] `json:"variants"`
+	Images   graphQLConnection[productImageNode]   `json:"images"`
+}
+
+type productVariantsData struct {
+	Product *struct {
+		Variants graphQLConnection[productVariantNode] `json:"variants"`
+	} `json:"product"`
+}
+
+type productImagesData struct {
+	Product *struct {
+		Images graphQLConnection[productImageNode] `json:"images"`
+	} `json:"product"`
+}
+
+// productNestedPageSize is a number of product's variants or images fetched per page beyond the first one.
+const productNestedPageSize = 250
+
+// fetchRemaining fetches product's variants and images, which don't fit the first page fetched with the product.
+func (s *shopifyAPI) fetchRemaining(ctx context.Context, node *productNode) error {
+	variables := map[string]interface{}{"id": node.ID, "first": productNestedPageSize}
+
+	if node.Variants.PageInfo.HasNextPage {
+		fetch := graphQLPageFetcher(s, productVariantsQuery, variables, func(data *productVariantsData) graphQLConnection[productVariantNode] {
+			if data.Product == nil {
+				return graphQLConnection[productVariantNode]{}
+			}
+			return data.Product.Variants
+		})
+		variants, err := fetchPagesAfter(ctx, fetch, node.Variants.PageInfo.EndCursor)
+		if err != nil {
+			return fmt.Errorf("failed to get product variants: %w", err)
+		}
+		node.Variants.Nodes = append(node.Variants.Nodes, variants...)
+	}
+
+	if node.Images.PageInfo.HasNextPage {
+		fetch := graphQLPageFetcher(s, productImagesQuery, variables, func(data *productImagesData) graphQLConnection[productImageNode] {
+			if data.Product == nil {
+				return graphQLConnection[productImageNode]{}
+			}
+			return data.Product.Images
+		})
+		images, err := fetchPagesAfter(ctx, fetch, node.Images.PageInfo.EndCursor)
+		if err != nil {
+			return fmt.Errorf("failed to get product images: %w", err)
+		}
+		node.Images.Nodes = append(node.Images.Nodes, images...)
+	}
+
+	return nil
+}
+
+func (node *productNode) toProduct() service.Product {
+	// IDs are numeric, so they can be passed back in URL paths
+	product := service.Product{
+		ID:              legacyID(node.ID),
+		Title:           node.Title,
+		DescriptionHTML: node.DescriptionHTML,
+		Handle:          node.Handle,
+		Status:          node.Status,
+		Vendor:          node.Vendor,
+		ProductType:     node.ProductType,
+		Tags:            node.Tags,
+		Options:         make([]service.ProductOption, 0, len(node.Options)),
+		Variants:        make([]service.ProductVariant, 0, len(node.Variants.Nodes)),
+		Images:          make([]service.ProductImage, 0, len(node.Images.Nodes)),
+		CreatedAt:       node.CreatedAt,
+		UpdatedAt:       node.UpdatedAt,
+	}
+
+	for _, option := range node.Options {
+		product.Options = append(product.Options, service.ProductOption{
+			ID:     legacyID(option.ID),
+			Name:   option.Name,
+			Values: option.Values,
+		})
+	}
+
+	for _, variant := range node.Variants.Nodes {
+		selectedOptions := make([]service.ProductSelectedOption, 0, len(variant.SelectedOptions))
+		for _, option := range variant.SelectedOptions {
+			selectedOptions = append(selectedOptions, service.ProductSelectedOption{
+				Name:  option.Name,
+				Value: option.Value,
+			})
+		}
+
+		product.Variants = append(product.Variants, service.ProductVariant{
+			ID:                legacyID(variant.ID),
+			Title:             variant.Title,
+			SKU:               variant.SKU,
+			Barcode:           variant.Barcode,
+			Price:             variant.Price,
+			CompareAtPrice:    variant.CompareAtPrice,
+			InventoryQuantity: variant.InventoryQuantity,
+			SelectedOptions:   selectedOptions,
+		})
+	}
+
+	for _, image := range node.Images.Nodes {
+		product.Images = append(product.Images, service.ProductImage{
+			ID:      legacyID(image.ID),
+			URL:     image.URL,
+			AltText: image.AltText,
+		})
+	}
+
+	return product
+}
+
+type productsData struct {
+	Products graphQLConnection[productNode] `json:"products"`
+}
+
+// productsPageFetcher fetches pages of products matching the filter.
+func (s *shopifyAPI) productsPageFetcher(filter service.ProductFilter, limit int) pageFetcher[service.Product] {
+	variables := map[string]interface{}{}
+	if query := productSearchQuery(filter); query != "" {
+		variables["query"] = query
+	}
+	variables["first"] = limit
+
+	fetch := graphQLPageFetcher(s, productsQuery, variables, func(data *productsData) graphQLConnection[productNode] {
+		return data.Products
+	})
+
+	return func(ctx context.Context, cursor string) ([]service.Product, string, error) {
+		nodes, next, err := fetch(ctx, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		products := make([]service.Product, 0, len(nodes))
+		for i := range nodes {
+			err = s.fetchRemaining(ctx, &nodes[i])
+			if err != nil {
+				return nil, "", err
+			}
+			products = append(products, nodes[i].toProduct())
+		}
+		return products, next, nil
+	}
+}
+
+func (s *shopifyAPI) IterateProducts(ctx context.Context, opts service.IterateProductsOptions) service.Iterator[service.Product] {
+	return newPaginator(withPageTimeout(s, "IterateProducts", s.productsPageFetcher(opts.Filter, productsPageSize)))
+}
+
+const (
+	// productsPageSize is a number of products fetched per page, if not specified.
+	// Products are fetched with their variants and images, so the page is kept small to fit query cost limit.
+	productsPageSize = 10
+	// maxProductsPageSize is a maximum number of products fetched per page.
+	maxProductsPageSize = 15
+)
+
+func (s *shopifyAPI) ListProducts(ctx context.Context, opts service.ListProductsOptions) (*service.ProductsPage, error) {
+	logger := s.logger.
+		Named("ListProducts").
+		WithContext(ctx).
+		With("opts", opts)
+
+	ctx, cancel := s.withTimeout(ctx, "ListProducts")
+	defer cancel()
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = productsPageSize
+	}
+	if limit > maxProductsPageSize {
+		limit = maxProductsPageSize
+	}
+
+	products, next, err := s.productsPageFetcher(opts.Filter, limit)(ctx, opts.Cursor)
+	if err != nil {
+		logger.Error("failed to list products", "err", err)
+		return nil, err
+	}
+
+	return &service.ProductsPage{
+		Products:   products,
+		NextCursor: next,
+	}, nil
+}
+
+const productQuery = `
+query product($id: ID!) {
+	product(id: $id) {` + productFields + `
+	}
+}`
+
+type productData struct {
+	Product *productNode `json:"product"`
+}
+
+func (s *shopifyAPI) GetProduct(ctx context.Context, id string) (*service.Product, error) {
+	logger := s.logger.
+		Named("GetProduct").
+		WithContext(ctx).
+		With("id", id)
+
+	ctx, cancel := s.withTimeout(ctx, "GetProduct")
+	defer cancel()
+
+	data, err := graphQL[productData](ctx, s, productQuery, map[string]interface{}{
+		"id": productGID(id),
+	})
+	if err != nil {
+		logger.Error("failed to get product", "err", err)
+		return nil, err
+	}
+	if data.Product == nil {
+		return nil, nil
+	}
+
+	err = s.fetchRemaining(ctx, data.Product)
+	if err != nil {
+		logger.Error("failed to get rest of product", "err", err)
+		return nil, err
+	}
+
+	product := data.Product.toProduct()
+	return &product, nil
+}
+
+const productExistsQuery = `
+query productExists($id: ID!) {
+	product(id: $id) {
+		id
+	}
+}`
+
+type productExistsData struct {
+	Product *struct {
+		ID string `json:"id"`
+	} `json:"product"`
+}
+
+// productExists checks whether product exists, since mutations report missing product as user error.
+func (s *shopifyAPI) productExists(ctx context.Context, id string) (bool, error) {
+	data, err := graphQL[productExistsData](ctx, s, productExistsQuery, map[string]interface{}{
+		"id": id,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return data.Product != nil, nil
+}
+
+const productUpdateMutation = `
+mutation productUpdate($input: ProductInput!) {
+	productUpdate(input: $input) {
+		product {
+			id
+		}
+		userErrors {
+			field
+			message
+		}
+	}
+}`
+
+type productUpdateData struct {
+	ProductUpdate struct {
+		UserErrors []graphQLUserError `json:"userErrors"`
+	} `json:"productUpdate"`
+}
+
+const productVariantsBulkUpdateMutation = `
+mutation productVariantsBulkUpdate($productId: ID!, $variants: [ProductVariantsBulkInput!]!) {
+	productVariantsBulkUpdate(productId: $productId, variants: $variants) {
+		userErrors {
+			field
+			message
+		}
+	}
+}`
+
+type productVariantsBulkUpdateData struct {
+	ProductVariantsBulkUpdate struct {
+		UserErrors []graphQLUserError `json:"userErrors"`
+	} `json:"productVariantsBulkUpdate"`
+}
+
+const productCreateMediaMutation = `
+mutation productCreateMedia($productId: ID!, $media: [CreateMediaInput!]!) {
+	productCreateMedia(productId: $productId, media: $media) {
+		mediaUserErrors {
+			field
+			message
+		}
+	}
+}`
+
+type productCreateMediaData struct {
+	ProductCreateMedia struct {
+		MediaUserErrors []graphQLUserError `json:"mediaUserErrors"`
+	} `json:"productCreateMedia"`
+}
+
+func (s *shopifyAPI) UpdateProduct(ctx context.Context, opts service.UpdateProductOptions) (*service.Product, error) {
+	logger := s.logger.
+		Named("UpdateProduct").
+		WithContext(ctx).
+		With("opts", opts)
+
+	ctx, cancel := s.withTimeout(ctx, "UpdateProduct")
+	defer cancel()
+
+	id := productGID(opts.ID)
+
+	exists, err := s.productExists(ctx, id)
+	if err != nil {
+		logger.Error("failed to check product existence", "err", err)
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	input := map[string]interface{}{"id": id}
+	if opts.Title != nil {
+		input["title"] = *opts.Title
+	}
+	if opts.DescriptionHTML != nil {
+		input["descriptionHtml"] = *opts.DescriptionHTML
+	}
+	if opts.Vendor != nil {
+		input["vendor"] = *opts.Vendor
+	}
+	if opts.ProductType != nil {
+		input["productType"] = *opts.ProductType
+	}
+	if opts.Tags != nil {
+		input["tags"] = opts.Tags
+	}
+	if opts.Status != nil {
+		input["status"] = *opts.Status
+	}
+
+	if len(input) > 1 {
+		data, err := graphQL[productUpdateData](ctx, s, productUpdateMutation, map[string]interface{}{
+			"input": input,
+		})
+		if err != nil {
+			logger.Error("failed to update product", "err", err)
+			return nil, err
+		}
+		err = userErrorsToError(data.ProductUpdate.UserErrors)
+		if err != nil {
+			logger.Info("product is invalid", "err", err)
+			return nil, err
+		}
+	}
+
+	if len(opts.Variants) > 0 {
+		variants := make([]map[string]interface{}, 0, len(opts.Variants))
+		for _, variant := range opts.Variants {
+			variants = append(variants, variantInput(variant))
+		}
+
+		data, err := graphQL[productVariantsBulkUpdateData](ctx, s, productVariantsBulkUpdateMutation, map[string]interface{}{
+			"productId": id,
+			"variants":  variants,
+		})
+		if err != nil {
+			logger.Error("failed to update product variants", "err", err)
+			return nil, err
+		}
+		err = userErrorsToError(data.ProductVariantsBulkUpdate.UserErrors)
+		if err != nil {
+			logger.Info("product variants are invalid", "err", err)
+			return nil, err
+		}
+	}
+
+	if len(opts.Images) > 0 {
+		data, err := graphQL[productCreateMediaData](ctx, s, productCreateMediaMutation, map[string]interface{}{
+			"productId": id,
+			"media":     mediaInput(opts.Images),
+		})
+		if err != nil {
+			logger.Error("failed to create product images", "err", err)
+			return nil, err
+		}
+		err = userErrorsToError(data.ProductCreateMedia.MediaUserErrors)
+		if err != nil {
+			logger.Info("product images are invalid", "err", err)
+			return nil, err
+		}
+	}
+	logger.Info("updated product")
+
+	return s.GetProduct(ctx, id)
+}
+
+func variantInput(opts service.UpdateProductVariantOptions) map[string]interface{} {
+	input := map[string]interface{}{"id": productVariantGID(opts.ID)}
+	if opts.Price != nil {
+		input["price"] = *opts.Price
+	}
+	if opts.CompareAtPrice != nil {
+		input["compareAtPrice"] = *opts.CompareAtPrice
+	}
+	if opts.Barcode != nil {
+		input["barcode"] = *opts.Barcode
+	}
+	if opts.SKU != nil {
+		input["inventoryItem"] = map[string]interface{}{"sku": *opts.SKU}
+	}
+	return input
+}
+
+const productDeleteMutation = `
+mutation productDelete($input: ProductDeleteInput!) {
+	productDelete(input: $input) {
+		deletedProductId
+		userErrors {
+			field
+			message
+		}
+	}
+}`
+
+type productDeleteData struct {
+	ProductDelete struct {
+		UserErrors []graphQLUserError `json:"userErrors"`
+	} `json:"productDelete"`
+}
+
+func (s *shopifyAPI) DeleteProduct(ctx context.Context, id string) (bool, error) {
+	logger := s.logger.
+		Named("DeleteProduct").
+		WithContext(ctx).
+		With("id", id)
+
+	ctx, cancel := s.withTimeout(ctx, "DeleteProduct")
+	defer cancel()
+
+	gid := productGID(id)
+
+	exists, err := s.productExists(ctx, gid)
+	if err != nil {
+		logger.Error("failed to check product existence", "err", err)
+		return false, err
+	}
+	if !exists {
+		return false, nil
 	}
 
-	return responseBody.Count, nil
+	data, err := graphQL[productDeleteData](ctx, s, productDeleteMutation, map[string]interface{}{
+		"input": map[string]interface{}{"id": gid},
+	})
+	if err != nil {
+		logger.Error("failed to delete product", "err", err)
+		return false, err
+	}
+	err = userErrorsToError(data.ProductDelete.UserErrors)
+	if err != nil {
+		logger.Error("failed to delete product", "err", err)
+		return false, err
+	}
+	logger.Info("deleted product")
+
+	return true, nil
+}
+
+// productGID returns global ID of the product, id can be either numeric ID or global ID.
+func productGID(id string) string {
+	return shopifyGID("Product", id)
+}
+
+// productVariantGID returns global ID of the product variant, id can be either numeric ID or global ID.
+func productVariantGID(id string) string {
+	return shopifyGID("ProductVariant", id)
+}
+
+func shopifyGID(resource string, id string) string {
+	if strings.HasPrefix(id, "gid://") {
+		return id
+	}
+	return fmt.Sprintf("gid://shopify/%s/%s", resource, id)
+}
+
+// productSearchQuery builds products search query from the filter.
+// https://shopify.dev/docs/api/usage/search-syntax
+func productSearchQuery(filter service.ProductFilter) string {
+	terms := make([]string, 0, 6)
+	if filter.Title != "" {
+		terms = append(terms, searchTerm("title", filter.Title))
+	}
+	if filter.Status != "" {
+		terms = append(terms, searchTerm("status", strings.ToLower(filter.Status)))
+	}
+	if filter.Vendor != "" {
+		terms = append(terms, searchTerm("vendor", filter.Vendor))
+	}
+	if filter.ProductType != "" {
+		terms = append(terms, searchTerm("product_type", filter.ProductType))
+	}
+	if filter.Tag != "" {
+		terms = append(terms, searchTerm("tag", filter.Tag))
+	}
+	if filter.UpdatedAtMin != nil {
+		terms = append(terms, "updated_at:>="+quoteSearchValue(filter.UpdatedAtMin.UTC().Format(time.RFC3339)))
+	}
+	return strings.Join(terms, " AND ")
+}
+
+func searchTerm(field string, value string) string {
+	return field + ":" + quoteSearchValue(value)
+}
+
+func quoteSearchValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
 }
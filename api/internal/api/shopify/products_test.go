@@ -0,0 +1,57 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+// cancelingTransport cancels the context on the first request and fails the request once it is canceled.
+type cancelingTransport struct {
+	cancel   context.CancelFunc
+	requests int
+}
+
+func (t *cancelingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.requests++
+	t.cancel()
+	<-r.Context().Done()
+	return nil, r.Context().Err()
+}
+
+func TestCreateProductsCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := newTestConfig()
+	cfg.Shopify.CreateProductsConcurrency = 1
+	transport := &cancelingTransport{cancel: cancel}
+	api := NewAPI(Options{
+		Config:    cfg,
+		Logger:    logging.NewZap("error"),
+		Transport: transport,
+	}).WithConfig(ctx, &entity.Store{Name: "s.myshopify.com", AccessToken: "token"})
+
+	results := api.CreateProducts(ctx, service.CreateProductsOptions{Count: 3})
+
+	if transport.requests != 1 {
+		t.Errorf("CreateProducts() sent %d requests, want 1 before context is canceled", transport.requests)
+	}
+	if len(results) != 3 {
+		t.Fatalf("CreateProducts() returned %d results, want 3", len(results))
+	}
+	if results[0].Err == nil {
+		t.Error("CreateProducts() first result has no error")
+	}
+	// Products, which creation is never started, fail with the context's error
+	for _, result := range results[1:] {
+		if !errors.Is(result.Err, context.Canceled) || result.ID != "" {
+			t.Errorf("CreateProducts() result = %+v, want %v", result, context.Canceled)
+		}
+	}
+}
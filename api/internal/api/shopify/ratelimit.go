@@ -0,0 +1,214 @@
+package shopify
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// callLimitHeader contains store's REST API bucket usage, e.g. "32/40".
+	callLimitHeader = "X-Shopify-Shop-Api-Call-Limit"
+	// defaultRetryAfter is used when throttled response has no valid Retry-After header.
+	defaultRetryAfter = 2 * time.Second
+	// maxRetryWait is the maximum time to wait before retrying throttled request.
+	maxRetryWait = 30 * time.Second
+	// bucketIdleTimeout is the time after which drained bucket of a store, which sends no requests, is evicted.
+	bucketIdleTimeout = 10 * time.Minute
+)
+
+// rateLimiter implements per-store leaky buckets mirroring platform's REST Admin API limits.
+// https://shopify.dev/docs/api/usage/rate-limits#rest-admin-api-rate-limits
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	// evictedAt is the time idle buckets were evicted last time.
+	evictedAt time.Time
+
+	size      float64
+	leakRate  float64
+	threshold float64
+}
+
+// bucket is a leaky bucket of a single store.
+type bucket struct {
+	size      float64
+	level     float64
+	updatedAt time.Time
+}
+
+func newRateLimiter(size int, leakRate, threshold float64) *rateLimiter {
+	return &rateLimiter{
+		buckets:   make(map[string]*bucket),
+		evictedAt: time.Now(),
+		size:      float64(size),
+		leakRate:  leakRate,
+		threshold: threshold,
+	}
+}
+
+// Wait blocks until store's bucket has enough room for a request and reserves it.
+// Requests are slowed down once bucket level reaches the threshold, so the limit is not hit.
+func (l *rateLimiter) Wait(ctx context.Context, storeName string) error {
+	l.mu.Lock()
+	b := l.bucket(storeName)
+	now := time.Now()
+	b.leak(now, l.leakRate)
+
+	var wait time.Duration
+	allowed := b.size * l.threshold
+	if b.level+1 > allowed && l.leakRate > 0 {
+		wait = time.Duration((b.level + 1 - allowed) / l.leakRate * float64(time.Second))
+	}
+	// Reserve the request, so concurrent callers wait for their own turn
+	b.level++
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Update synchronizes store's bucket with the call limit header returned by platform.
+func (l *rateLimiter) Update(storeName, callLimit string) {
+	parts := strings.Split(callLimit, "/")
+	if len(parts) != 2 {
+		return
+	}
+	used, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return
+	}
+	size, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || size <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bucket(storeName)
+	b.size = size
+	b.level = used
+	b.updatedAt = time.Now()
+}
+
+// Fill marks store's bucket as full after request has been throttled.
+// Bucket leaks down to the threshold once retryAfter passes, so requests of the store wait as long as the retry does.
+func (l *rateLimiter) Fill(storeName string, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.bucket(storeName)
+	b.level = b.size*l.threshold - 1 + retryAfter.Seconds()*l.leakRate
+	b.updatedAt = time.Now()
+}
+
+// bucket returns store's bucket, creating it if needed. Must be called with mu held.
+func (l *rateLimiter) bucket(storeName string) *bucket {
+	l.evictIdle(time.Now())
+
+	b, ok := l.buckets[storeName]
+	if !ok {
+		b = &bucket{size: l.size, updatedAt: time.Now()}
+		l.buckets[storeName] = b
+	}
+	return b
+}
+
+// evictIdle deletes buckets of stores, which haven't sent requests for bucketIdleTimeout, once per bucketIdleTimeout.
+// Drained bucket is the same as a new one, so a store sending requests again only has to learn its bucket size again.
+// Must be called with mu held.
+func (l *rateLimiter) evictIdle(now time.Time) {
+	if now.Sub(l.evictedAt) < bucketIdleTimeout {
+		return
+	}
+	l.evictedAt = now
+
+	for storeName, b := range l.buckets {
+		idle := now.Sub(b.updatedAt)
+		if idle >= bucketIdleTimeout && b.level <= idle.Seconds()*l.leakRate {
+			delete(l.buckets, storeName)
+		}
+	}
+}
+
+// leak drains the bucket according to the time passed since its last update.
+func (b *bucket) leak(now time.Time, leakRate float64) {
+	b.level -= now.Sub(b.updatedAt).Seconds() * leakRate
+	if b.level < 0 {
+		b.level = 0
+	}
+	b.updatedAt = now
+}
+
+// useRateLimiter makes client wait for the store's rate limit and retry throttled requests.
+func (s *shopifyAPI) useRateLimiter(client *resty.Client) *resty.Client {
+	return client.
+		OnBeforeRequest(func(c *resty.Client, r *resty.Request) error {
+			// GraphQL API has its own cost-based limits
+			if isGraphQLRequest(r) {
+				return nil
+			}
+			return s.limiter.Wait(r.Context(), rateLimiterKey(c, r))
+		}).
+		OnAfterResponse(func(c *resty.Client, res *resty.Response) error {
+			key := rateLimiterKey(c, res.Request)
+			if res.StatusCode() == http.StatusTooManyRequests {
+				s.limiter.Fill(key, retryAfter(res))
+				return nil
+			}
+			if callLimit := res.Header().Get(callLimitHeader); callLimit != "" {
+				s.limiter.Update(key, callLimit)
+			}
+			return nil
+		}).
+		SetRetryCount(s.retries).
+		SetRetryMaxWaitTime(maxRetryWait).
+		AddRetryCondition(func(res *resty.Response, err error) bool {
+			return res != nil && res.StatusCode() == http.StatusTooManyRequests
+		}).
+		SetRetryAfter(func(c *resty.Client, res *resty.Response) (time.Duration, error) {
+			return retryAfter(res), nil
+		})
+}
+
+// retryAfter returns the time to wait before retrying throttled request.
+func retryAfter(res *resty.Response) time.Duration {
+	seconds, err := strconv.ParseFloat(res.Header().Get("Retry-After"), 64)
+	if err != nil || seconds <= 0 {
+		return defaultRetryAfter
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// rateLimiterKey returns the store request is sent to.
+func rateLimiterKey(c *resty.Client, r *resty.Request) string {
+	if u, err := url.Parse(r.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	if u, err := url.Parse(c.BaseURL); err == nil {
+		return u.Host
+	}
+	return c.BaseURL
+}
+
+// isGraphQLRequest checks whether request is sent to GraphQL API.
+func isGraphQLRequest(r *resty.Request) bool {
+	return strings.HasSuffix(r.URL, "/graphql.json")
+}
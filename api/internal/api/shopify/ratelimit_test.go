@@ -0,0 +1,225 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+func TestRateLimiterWaitBlocksAtThreshold(t *testing.T) {
+	ctx := context.Background()
+	// Requests are slowed down once 5 of 10 calls are used, the bucket leaks 10 calls a second
+	limiter := newRateLimiter(10, 10, 0.5)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		err := limiter.Wait(ctx, "s.myshopify.com")
+		if err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Wait() below threshold took %s, want no wait", elapsed)
+	}
+
+	start = time.Now()
+	err := limiter.Wait(ctx, "s.myshopify.com")
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Wait() at threshold took %s, want a call to leak for 100ms", elapsed)
+	}
+
+	// Buckets of other stores are independent
+	start = time.Now()
+	err = limiter.Wait(ctx, "other.myshopify.com")
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Wait() of another store took %s, want no wait", elapsed)
+	}
+}
+
+func TestRateLimiterWaitCanceledContext(t *testing.T) {
+	limiter := newRateLimiter(10, 1, 0.5)
+	limiter.Fill("s.myshopify.com", time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := limiter.Wait(ctx, "s.myshopify.com")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRateLimiterUpdate(t *testing.T) {
+	limiter := newRateLimiter(40, 2, 0.8)
+
+	limiter.Update("s.myshopify.com", "32/80")
+	b := limiter.buckets["s.myshopify.com"]
+	if b.size != 80 || b.level != 32 {
+		t.Errorf("bucket after update = %+v, want size 80 and level 32", b)
+	}
+
+	for _, callLimit := range []string{"", "32", "a/80", "32/0"} {
+		limiter.Update("s.myshopify.com", callLimit)
+		if b.size != 80 || b.level != 32 {
+			t.Errorf("bucket after update with %q = %+v, want it unchanged", callLimit, b)
+		}
+	}
+}
+
+func TestRateLimiterFill(t *testing.T) {
+	ctx := context.Background()
+	// Bucket leaks 10 calls a second
+	limiter := newRateLimiter(40, 10, 0.8)
+	limiter.Update("s.myshopify.com", "3/40")
+
+	limiter.Fill("s.myshopify.com", 200*time.Millisecond)
+
+	// Requests wait until the throttled request is retried, but not longer
+	start := time.Now()
+	err := limiter.Wait(ctx, "s.myshopify.com")
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond || elapsed > 300*time.Millisecond {
+		t.Errorf("Wait() after fill took %s, want retry after of 200ms", elapsed)
+	}
+}
+
+func TestRateLimiterRetriesThrottledRequest(t *testing.T) {
+	var mu sync.Mutex
+	var requestedAt []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestedAt = append(requestedAt, time.Now())
+		first := len(requestedAt) == 1
+		mu.Unlock()
+
+		if first {
+			w.Header().Set("Retry-After", "0.2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set(callLimitHeader, "39/40")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"shop":{"id":1,"name":"Store"}}`))
+	}))
+	defer server.Close()
+
+	cfg := newTestConfig()
+	cfg.Shopify.MaxRetries = 2
+	api := NewAPI(Options{
+		Config:  cfg,
+		Logger:  logging.NewZap("error"),
+		BaseURL: func(string) string { return server.URL },
+	})
+
+	shop, err := api.WithConfig(context.Background(), &entity.Store{Name: "s.myshopify.com", AccessToken: "token"}).GetShop(context.Background())
+	if err != nil {
+		t.Fatalf("GetShop() error = %v", err)
+	}
+	if shop.ID != 1 {
+		t.Errorf("GetShop() = %+v, want shop of the retried request", shop)
+	}
+
+	if len(requestedAt) != 2 {
+		t.Fatalf("server got %d requests, want throttled request retried once", len(requestedAt))
+	}
+	if wait := requestedAt[1].Sub(requestedAt[0]); wait < 200*time.Millisecond || wait >= defaultRetryAfter {
+		t.Errorf("throttled request is retried in %s, want Retry-After of 200ms", wait)
+	}
+
+	// Bucket is synchronized with the call limit header of the last response
+	serverURL, _ := url.Parse(server.URL)
+	if b := api.limiter.buckets[serverURL.Host]; b == nil || b.size != 40 || b.level != 39 {
+		t.Errorf("bucket after response = %+v, want size 40 and level 39", b)
+	}
+}
+
+func TestRateLimiterFillsBucketOnThrottledResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(callLimitHeader, "10/40")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	api := NewAPI(Options{
+		Config:  newTestConfig(),
+		Logger:  logging.NewZap("error"),
+		BaseURL: func(string) string { return server.URL },
+	})
+
+	_, err := api.WithConfig(context.Background(), &entity.Store{Name: "s.myshopify.com", AccessToken: "token"}).GetShop(context.Background())
+	if err == nil {
+		t.Fatal("GetShop() error = nil, want throttled request failed without retries")
+	}
+
+	// Throttled response means the bucket is full, whatever its call limit header says,
+	// so it leaks down to the threshold of 32 calls by the default retry after
+	serverURL, _ := url.Parse(server.URL)
+	if b := api.limiter.buckets[serverURL.Host]; b == nil || b.level < 34.9 {
+		t.Errorf("bucket after throttled response = %+v, want level 35", b)
+	}
+}
+
+func TestRateLimiterEvictIdle(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(40, 2, 0.8)
+	limiter.evictedAt = now.Add(-bucketIdleTimeout)
+	limiter.buckets = map[string]*bucket{
+		"idle.myshopify.com":   {size: 40, level: 40, updatedAt: now.Add(-bucketIdleTimeout)},
+		"active.myshopify.com": {size: 40, level: 40, updatedAt: now.Add(-time.Second)},
+	}
+
+	limiter.evictIdle(now)
+
+	if _, ok := limiter.buckets["idle.myshopify.com"]; ok {
+		t.Error("idle bucket is not evicted")
+	}
+	if _, ok := limiter.buckets["active.myshopify.com"]; !ok {
+		t.Error("active bucket is evicted")
+	}
+}
+
+func TestRateLimiterEvictIdleKeepsUndrainedBucket(t *testing.T) {
+	now := time.Now()
+	// Bucket never leaks, so it stays full however long store is idle
+	limiter := newRateLimiter(40, 0, 0.8)
+	limiter.evictedAt = now.Add(-bucketIdleTimeout)
+	limiter.buckets = map[string]*bucket{
+		"full.myshopify.com": {size: 40, level: 40, updatedAt: now.Add(-2 * bucketIdleTimeout)},
+	}
+
+	limiter.evictIdle(now)
+
+	if _, ok := limiter.buckets["full.myshopify.com"]; !ok {
+		t.Error("undrained bucket is evicted")
+	}
+}
+
+func TestRateLimiterEvictIdleRunsOncePerTimeout(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(40, 2, 0.8)
+	limiter.evictedAt = now.Add(-time.Minute)
+	limiter.buckets = map[string]*bucket{
+		"idle.myshopify.com": {size: 40, updatedAt: now.Add(-bucketIdleTimeout)},
+	}
+
+	limiter.evictIdle(now)
+
+	if _, ok := limiter.buckets["idle.myshopify.com"]; !ok {
+		t.Error("buckets are evicted before timeout since the previous eviction")
+	}
+}
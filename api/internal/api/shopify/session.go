@@ -7,6 +7,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -159,5 +160,16 @@ func (s *shopifyAPI) getStoreName(token string) (string, error) {
 		return "", fmt.Errorf("failed to parse JWT token: %v", err)
 	}
 
-	return strings.Replace(claims.Dest, "https://", "", 1), nil
+	// The dest claim contains the shop's URL, e.g. https://example.myshopify.com
+	dest, err := url.Parse(claims.Dest)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse dest claim: %w", err)
+	}
+
+	shop, err := service.ParseShopDomain(dest.Host, s.cfg.Shopify.AllowedShopDomains)
+	if err != nil {
+		return "", err
+	}
+
+	return shop.String(), nil
 }
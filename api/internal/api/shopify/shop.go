@@ -0,0 +1,67 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+// shopResource is a shop of REST Admin API, which is also a payload of shop/update webhook.
+type shopResource struct {
+	ID              int64     `json:"id"`
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	Currency        string    `json:"currency"`
+	IANATimezone    string    `json:"iana_timezone"`
+	PlanName        string    `json:"plan_name"`
+	PlanDisplayName string    `json:"plan_display_name"`
+	Domain          string    `json:"domain"`
+	CountryCode     string    `json:"country_code"`
+	MyshopifyDomain string    `json:"myshopify_domain"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type getShopResponseBody struct {
+	Shop shopResource `json:"shop"`
+}
+
+func (s *shopifyAPI) GetShop(ctx context.Context) (*service.Shop, error) {
+	logger := s.logger.
+		Named("GetShop").
+		WithContext(ctx)
+
+	ctx, cancel := s.withTimeout(ctx, "GetShop")
+	defer cancel()
+
+	var responseBody getShopResponseBody
+	res, err := s.client.R().
+		SetContext(ctx).
+		SetResult(&responseBody).
+		Get(s.adminPath("/shop.json"))
+	if err != nil {
+		logger.Error("failed to get shop", "err", err)
+		return nil, err
+	}
+	if res.StatusCode() != http.StatusOK {
+		logger.Error("failed to get shop", "status", res.StatusCode(), "body", res.String())
+		return nil, fmt.Errorf("failed to get shop: http status %d", res.StatusCode())
+	}
+
+	shop := responseBody.Shop
+	return &service.Shop{
+		ID:              shop.ID,
+		Name:            shop.Name,
+		Email:           shop.Email,
+		Currency:        shop.Currency,
+		IANATimezone:    shop.IANATimezone,
+		PlanName:        shop.PlanName,
+		PlanDisplayName: shop.PlanDisplayName,
+		PrimaryDomain:   shop.Domain,
+		CountryCode:     shop.CountryCode,
+		MyshopifyDomain: shop.MyshopifyDomain,
+		UpdatedAt:       shop.UpdatedAt,
+	}, nil
+}
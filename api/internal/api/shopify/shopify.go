@@ -3,10 +3,9 @@ package shopify
 import (
 	"context"
 	"fmt"
-	"time"
+	"net/http"
 
 	"github.com/go-resty/resty/v2"
-	"github.com/hashicorp/go-retryablehttp"
 	"github.com/softcery/shopify-app-template-go/config"
 	"github.com/softcery/shopify-app-template-go/internal/entity"
 	"github.com/softcery/shopify-app-template-go/internal/service"
@@ -16,47 +15,103 @@ import (
 type Options struct {
 	Config *config.Config
 	Logger logging.Logger
+	// BaseURL resolves store's Admin API base URL, DefaultBaseURL is used if it is nil.
+	BaseURL BaseURLResolver
+	// Transport sends client's requests, e.g. to record or replay them in tests. Default transport is used if it is nil.
+	Transport http.RoundTripper
+}
+
+// BaseURLResolver returns base URL of the store's Admin API without trailing slash, e.g. "https://example.myshopify.com".
+// It is overridden to point the API at a fake server in tests.
+type BaseURLResolver func(storeName string) string
+
+// DefaultBaseURL resolves store's Admin API at its myshopify domain.
+func DefaultBaseURL(storeName string) string {
+	return fmt.Sprintf("https://%s", storeName)
 }
 
 var _ service.PlatformAPI = (*shopifyAPI)(nil)
 
 type shopifyAPI struct {
-	client  *resty.Client
-	logger  logging.Logger
-	cfg     *config.Config
-	retries int
+	client    *resty.Client
+	logger    logging.Logger
+	cfg       *config.Config
+	baseURL   BaseURLResolver
+	transport http.RoundTripper
+	retries   int
+	// limiter and deprecations are shared by all instances, so they track stores across them
+	limiter      *rateLimiter
+	deprecations *deprecationCounter
 }
 
 func NewAPI(opts Options) *shopifyAPI {
-	restyClient := resty.New()
-
-	return &shopifyAPI{
-		logger: opts.Logger.Named("shopifyAPI"),
-		cfg:    opts.Config,
-		client: restyClient,
+	s := &shopifyAPI{
+		logger:    opts.Logger.Named("shopifyAPI"),
+		cfg:       opts.Config,
+		baseURL:   opts.BaseURL,
+		transport: opts.Transport,
+		retries:   opts.Config.Shopify.MaxRetries,
+		limiter: newRateLimiter(
+			opts.Config.Shopify.RateLimitBucketSize,
+			opts.Config.Shopify.RateLimitLeakRate,
+			opts.Config.Shopify.RateLimitThreshold,
+		),
+		deprecations: newDeprecationCounter(),
 	}
-}
-func (s *shopifyAPI) WithConfig(ctx context.Context, store *entity.Store) service.PlatformAPI {
-	var h *resty.Client
-
-	if s.retries != 0 {
-		c := retryablehttp.NewClient()
-		c.RetryMax = s.retries
-		c.RetryWaitMax = time.Second * 30
-		h = resty.NewWithClient(c.StandardClient())
-	} else {
-		h = resty.New()
+	if s.baseURL == nil {
+		s.baseURL = DefaultBaseURL
 	}
+	s.client = s.newClient()
 
-	h = h.
-		SetBaseURL(fmt.Sprintf(`https://%s`, store.Name)).
+	return s
+}
+func (s *shopifyAPI) WithConfig(ctx context.Context, store *entity.Store) service.PlatformAPI {
+	h := s.newClient().
+		SetBaseURL(s.baseURL(store.Name)).
 		SetHeader("X-Shopify-Access-Token", store.AccessToken).
 		SetHeader("Content-Type", "application/json")
 
 	return &shopifyAPI{
-		client:  h,
-		logger:  s.logger,
-		cfg:     s.cfg,
-		retries: s.retries,
+		client:       h,
+		logger:       s.logger,
+		cfg:          s.cfg,
+		baseURL:      s.baseURL,
+		transport:    s.transport,
+		retries:      s.retries,
+		limiter:      s.limiter,
+		deprecations: s.deprecations,
+	}
+}
+
+// newClient creates resty client respecting store's rate limits and reporting deprecated calls.
+func (s *shopifyAPI) newClient() *resty.Client {
+	return s.reportDeprecations(s.useRateLimiter(s.newBaseClient()))
+}
+
+// newBaseClient creates resty client sending requests with configured transport, e.g. to download files outside of the store.
+func (s *shopifyAPI) newBaseClient() *resty.Client {
+	client := resty.New()
+	if s.transport != nil {
+		client.SetTransport(s.transport)
 	}
+
+	return client
+}
+
+// adminPath builds path of the Admin API endpoint of configured version, e.g. adminPath("/products.json").
+func (s *shopifyAPI) adminPath(path string) string {
+	return fmt.Sprintf("/admin/api/%s%s", s.cfg.Shopify.APIVersion, path)
+}
+
+// withTimeout bounds the operation with its configured timeout.
+func (s *shopifyAPI) withTimeout(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	timeout, ok := s.cfg.Shopify.OperationTimeouts[operation]
+	if !ok {
+		timeout = s.cfg.Shopify.RequestTimeout
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
 }
@@ -0,0 +1,121 @@
+package shopifytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bulkOperation is store's bulk operation, it completes as soon as it is started.
+type bulkOperation struct {
+	ID          int64
+	ObjectCount int
+	// Result is operation's JSONL result file.
+	Result      []byte
+	CreatedAt   time.Time
+	CompletedAt time.Time
+}
+
+// bulkOperationRunQuery runs bulk query, only queries of products with their variants are supported.
+// https://shopify.dev/docs/api/usage/bulk-operations/queries
+func (s *Server) bulkOperationRunQuery(shop *shopState, variables map[string]interface{}) map[string]interface{} {
+	query, _ := variables["query"].(string)
+	if !strings.Contains(query, "products") {
+		return mutationPayload("bulkOperation", nil, userError([]string{"query"}, "query is not supported by fake server"))
+	}
+
+	// Every line of nested connection follows its parent and references it with __parentId
+	var result bytes.Buffer
+	encoder := json.NewEncoder(&result)
+	objectCount := 0
+	for _, product := range shop.products {
+		node := productNode(product)
+		variants := node["variants"].(map[string]interface{})["nodes"].([]interface{})
+		delete(node, "variants")
+		delete(node, "images")
+		delete(node, "options")
+		_ = encoder.Encode(node)
+		objectCount++
+
+		for _, variant := range variants {
+			line := variant.(map[string]interface{})
+			delete(line, "selectedOptions")
+			line["__parentId"] = node["id"]
+			_ = encoder.Encode(line)
+			objectCount++
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	operation := &bulkOperation{
+		ID:          s.newID(),
+		ObjectCount: objectCount,
+		Result:      result.Bytes(),
+		CreatedAt:   now,
+		CompletedAt: now,
+	}
+	shop.bulkOperations = append(shop.bulkOperations, operation)
+
+	return mutationPayload("bulkOperation", map[string]interface{}{
+		"id":        gid("BulkOperation", operation.ID),
+		"status":    "CREATED",
+		"createdAt": operation.CreatedAt,
+	}, nil)
+}
+
+// bulkOperationNode returns bulk operation as node, its result file is served by the server.
+func (s *Server) bulkOperationNode(shop *shopState, operation *bulkOperation) map[string]interface{} {
+	var resultURL interface{}
+	// Operation without objects has no result file
+	if operation.ObjectCount > 0 {
+		resultURL = fmt.Sprintf("%s/%s/bulk/%d.jsonl", s.URL, shop.myshopifyDomain, operation.ID)
+	}
+
+	return map[string]interface{}{
+		"id":          gid("BulkOperation", operation.ID),
+		"status":      "COMPLETED",
+		"errorCode":   nil,
+		"objectCount": strconv.Itoa(operation.ObjectCount),
+		"url":         resultURL,
+		"createdAt":   operation.CreatedAt,
+		"completedAt": operation.CompletedAt,
+	}
+}
+
+// handleBulkOperationResult serves operation's result file, like the real one it requires no access token.
+func (s *Server) handleBulkOperationResult(w http.ResponseWriter, storeName, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var operation *bulkOperation
+	if shop := s.shops[storeName]; shop != nil {
+		id, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(path, "/bulk/"), ".jsonl"), 10, 64)
+		if err == nil {
+			operation = shop.bulkOperation(gid("BulkOperation", id))
+		}
+	}
+	if operation == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/jsonl")
+	_, _ = w.Write(operation.Result)
+}
+
+func (shop *shopState) bulkOperation(gid interface{}) *bulkOperation {
+	id, ok := parseGID("BulkOperation", gid)
+	if !ok {
+		return nil
+	}
+	for _, operation := range shop.bulkOperations {
+		if operation.ID == id {
+			return operation
+		}
+	}
+	return nil
+}
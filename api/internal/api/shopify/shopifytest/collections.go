@@ -0,0 +1,139 @@
+package shopifytest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Collection is store's custom collection.
+type Collection struct {
+	ID    int64
+	Title string
+	// Handle is generated from the title, unless it is set on creation.
+	Handle     string
+	ProductIDs []int64
+}
+
+// Collections returns store's collections.
+func (s *Server) Collections(storeName string) []Collection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shop := s.shops[storeName]
+	if shop == nil {
+		return nil
+	}
+
+	collections := make([]Collection, 0, len(shop.collections))
+	for _, collection := range shop.collections {
+		collections = append(collections, *collection)
+	}
+	return collections
+}
+
+func (shop *shopState) collection(gid interface{}) *Collection {
+	id, ok := parseGID("Collection", gid)
+	if !ok {
+		return nil
+	}
+	for _, collection := range shop.collections {
+		if collection.ID == id {
+			return collection
+		}
+	}
+	return nil
+}
+
+// nonHandleRe matches characters replaced when handle is generated from the title.
+var nonHandleRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// collectionsConnection serves collections page by page, search query is ignored.
+func (s *Server) collectionsConnection(shop *shopState, variables map[string]interface{}) map[string]interface{} {
+	first := 50
+	if value, ok := variables["first"].(float64); ok && value > 0 {
+		first = int(value)
+	}
+	// Cursor is an ID of the last collection of the previous page
+	after, _ := strconv.ParseInt(fmt.Sprint(variables["after"]), 10, 64)
+
+	nodes := []interface{}{}
+	var endCursor string
+	hasNextPage := false
+	for _, collection := range shop.collections {
+		if collection.ID <= after {
+			continue
+		}
+		if len(nodes) == first {
+			hasNextPage = true
+			break
+		}
+		nodes = append(nodes, map[string]interface{}{
+			"id":     gid("Collection", collection.ID),
+			"title":  collection.Title,
+			"handle": collection.Handle,
+		})
+		endCursor = strconv.FormatInt(collection.ID, 10)
+	}
+
+	return map[string]interface{}{
+		"nodes": nodes,
+		"pageInfo": map[string]interface{}{
+			"hasNextPage": hasNextPage,
+			"endCursor":   endCursor,
+		},
+	}
+}
+
+func (s *Server) collectionCreate(shop *shopState, input map[string]interface{}) map[string]interface{} {
+	title, _ := input["title"].(string)
+	if strings.TrimSpace(title) == "" {
+		return mutationPayload("collection", nil, userError([]string{"title"}, "Title can't be blank"))
+	}
+
+	handle, _ := input["handle"].(string)
+	if handle == "" {
+		handle = strings.Trim(nonHandleRe.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	}
+	for _, collection := range shop.collections {
+		if collection.Handle == handle {
+			return mutationPayload("collection", nil, userError([]string{"handle"}, "Handle has already been taken"))
+		}
+	}
+
+	collection := &Collection{ID: s.newID(), Title: title, Handle: handle}
+	products, _ := input["products"].([]interface{})
+	for i, value := range products {
+		product := shop.product(value)
+		if product == nil {
+			return mutationPayload("collection", nil, userError([]string{"products", strconv.Itoa(i)}, "Product does not exist"))
+		}
+		collection.ProductIDs = append(collection.ProductIDs, product.ID)
+	}
+	shop.collections = append(shop.collections, collection)
+
+	return mutationPayload("collection", map[string]interface{}{"id": gid("Collection", collection.ID)}, nil)
+}
+
+func (s *Server) collectionDelete(shop *shopState, input map[string]interface{}) map[string]interface{} {
+	collection := shop.collection(input["id"])
+	if collection == nil {
+		return map[string]interface{}{
+			"deletedCollectionId": nil,
+			"userErrors":          userError([]string{"id"}, "Collection does not exist"),
+		}
+	}
+
+	for i := range shop.collections {
+		if shop.collections[i] == collection {
+			shop.collections = append(shop.collections[:i], shop.collections[i+1:]...)
+			break
+		}
+	}
+
+	return map[string]interface{}{
+		"deletedCollectionId": gid("Collection", collection.ID),
+		"userErrors":          []interface{}{},
+	}
+}
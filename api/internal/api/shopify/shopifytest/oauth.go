@@ -0,0 +1,139 @@
+package shopifytest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InstallURL returns app's installation URL signed the way Shopify signs it, when store owner opens the app.
+func (s *Server) InstallURL(appURL, storeName string) string {
+	query := s.SignQuery(url.Values{
+		"shop":      {storeName},
+		"timestamp": {strconv.FormatInt(time.Now().Unix(), 10)},
+	})
+	return appURL + "?" + query.Encode()
+}
+
+// SignQuery returns a copy of query with hmac parameter signed with app's secret.
+// https://shopify.dev/docs/apps/auth/oauth/getting-started#step-1-verify-the-installation-request
+func (s *Server) SignQuery(query url.Values) url.Values {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if key != "hmac" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	signed := url.Values{}
+	for _, key := range keys {
+		escapedKey := strings.NewReplacer("%", "%25", "&", "%26", "=", "%3D").Replace(key)
+		escapedValue := strings.NewReplacer("%", "%25", "&", "%26").Replace(query.Get(key))
+		pairs = append(pairs, escapedKey+"="+escapedValue)
+		signed.Set(key, query.Get(key))
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.opts.APISecret))
+	mac.Write([]byte(strings.Join(pairs, "&")))
+	signed.Set("hmac", hex.EncodeToString(mac.Sum(nil)))
+
+	return signed
+}
+
+// handleAuthorize grants access to the app right away and redirects back to the app with authorization code.
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request, storeName string) {
+	query := r.URL.Query()
+	if query.Get("client_id") != s.opts.APIKey {
+		writeError(w, http.StatusBadRequest, "invalid client_id")
+		return
+	}
+	redirectURL, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirectURL.Host == "" {
+		writeError(w, http.StatusBadRequest, "invalid redirect_uri")
+		return
+	}
+
+	code, err := randomHex()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.getOrCreateShop(storeName).codes[code] = true
+	s.mu.Unlock()
+
+	redirectURL.RawQuery = s.SignQuery(url.Values{
+		"code":      {code},
+		"shop":      {storeName},
+		"state":     {query.Get("state")},
+		"timestamp": {strconv.FormatInt(time.Now().Unix(), 10)},
+	}).Encode()
+	http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+}
+
+// handleAccessToken exchanges authorization code for store's offline access token.
+func (s *Server) handleAccessToken(w http.ResponseWriter, r *http.Request, storeName string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	// Credentials are accepted either as query, form or JSON body
+	var credentials struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		Code         string `json:"code"`
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		_ = json.NewDecoder(r.Body).Decode(&credentials)
+	} else {
+		credentials.ClientID = r.FormValue("client_id")
+		credentials.ClientSecret = r.FormValue("client_secret")
+		credentials.Code = r.FormValue("code")
+	}
+	if credentials.ClientID != s.opts.APIKey || credentials.ClientSecret != s.opts.APISecret {
+		writeError(w, http.StatusBadRequest, "invalid client credentials")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shop := s.shops[storeName]
+	if shop == nil || !shop.codes[credentials.Code] {
+		writeError(w, http.StatusBadRequest, "authorization code was not found or was already used")
+		return
+	}
+	delete(shop.codes, credentials.Code)
+
+	err := s.install(shop)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": shop.accessToken,
+		"scope":        shop.scope,
+	})
+}
+
+func randomHex() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,572 @@
+package shopifytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Product is store's product.
+type Product struct {
+	ID              int64
+	Title           string
+	DescriptionHTML string
+	Handle          string
+	Status          string
+	Vendor          string
+	ProductType     string
+	Tags            []string
+	Variants        []ProductVariant
+	Images          []ProductImage
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// ProductVariant is product's variant.
+type ProductVariant struct {
+	ID                int64
+	Title             string
+	SKU               string
+	Barcode           string
+	Price             string
+	CompareAtPrice    *string
+	InventoryQuantity int
+}
+
+// ProductImage is product's image.
+type ProductImage struct {
+	ID      int64
+	URL     string
+	AltText string
+}
+
+// AddProduct adds product to the store and returns it with assigned IDs.
+// Product gets a default variant if it has none.
+func (s *Server) AddProduct(storeName string, product Product) Product {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return *s.addProduct(s.getOrCreateShop(storeName), product)
+}
+
+// Products returns store's products.
+func (s *Server) Products(storeName string) []Product {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shop := s.shops[storeName]
+	if shop == nil {
+		return nil
+	}
+
+	products := make([]Product, 0, len(shop.products))
+	for _, product := range shop.products {
+		products = append(products, *product)
+	}
+	return products
+}
+
+// addProduct must be called with mu locked.
+func (s *Server) addProduct(shop *shopState, product Product) *Product {
+	now := time.Now().UTC().Truncate(time.Second)
+	product.ID = s.newID()
+	if product.Handle == "" {
+		product.Handle = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(product.Title)), " ", "-")
+	}
+	if product.Status == "" {
+		product.Status = "ACTIVE"
+	}
+	if len(product.Variants) == 0 {
+		product.Variants = []ProductVariant{{Title: "Default Title", Price: "0.00"}}
+	}
+	for i := range product.Variants {
+		product.Variants[i].ID = s.newID()
+	}
+	for i := range product.Images {
+		product.Images[i].ID = s.newID()
+	}
+	product.CreatedAt = now
+	product.UpdatedAt = now
+
+	shop.products = append(shop.products, &product)
+	return &product
+}
+
+func (shop *shopState) product(gid interface{}) *Product {
+	id, ok := parseGID("Product", gid)
+	if !ok {
+		return nil
+	}
+	for _, product := range shop.products {
+		if product.ID == id {
+			return product
+		}
+	}
+	return nil
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// operationNameRe matches the name of the GraphQL operation, every query of the app is named after its root field.
+var operationNameRe = regexp.MustCompile(`^\s*(?:query|mutation)\s+(\w+)`)
+
+// handleGraphQL serves GraphQL product, collection and bulk operations, it must be called with mu locked.
+// Operations are recognized by their names, products query supports only tag terms of search query.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request, shop *shopState) {
+	var request graphQLRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	matches := operationNameRe.FindStringSubmatch(request.Query)
+	if matches == nil {
+		writeGraphQLError(w, "operation name is required by fake server")
+		return
+	}
+	variables := request.Variables
+	input, _ := variables["input"].(map[string]interface{})
+	media, _ := variables["media"].([]interface{})
+
+	var data interface{}
+	switch matches[1] {
+	case "productsCount":
+		data = map[string]interface{}{"productsCount": map[string]interface{}{"count": len(shop.products)}}
+	case "products":
+		data = map[string]interface{}{"products": s.productsConnection(shop, request.Query, variables)}
+	case "product", "productExists":
+		var node interface{}
+		if product := shop.product(variables["id"]); product != nil {
+			node = pageNested(productNode(product), request.Query)
+		}
+		data = map[string]interface{}{"product": node}
+	case "productVariants", "productImages":
+		field := strings.ToLower(strings.TrimPrefix(matches[1], "product"))
+		var node interface{}
+		if product := shop.product(variables["id"]); product != nil {
+			first, _ := variables["first"].(float64)
+			after, _ := strconv.Atoi(fmt.Sprint(variables["after"]))
+			nodes := productNode(product)[field].(map[string]interface{})["nodes"].([]interface{})
+			node = map[string]interface{}{field: nestedConnection(nodes, after, int(first))}
+		}
+		data = map[string]interface{}{"product": node}
+	case "productCreate":
+		data = map[string]interface{}{"productCreate": s.productCreate(shop, input, media)}
+	case "productUpdate":
+		data = map[string]interface{}{"productUpdate": s.productUpdate(shop, input)}
+	case "productVariantsBulkCreate":
+		data = map[string]interface{}{"productVariantsBulkCreate": s.productVariantsBulkCreate(shop, variables)}
+	case "productVariantsBulkUpdate":
+		data = map[string]interface{}{"productVariantsBulkUpdate": s.productVariantsBulkUpdate(shop, variables)}
+	case "productCreateMedia":
+		data = map[string]interface{}{"productCreateMedia": s.productCreateMedia(shop, variables)}
+	case "productDelete":
+		data = map[string]interface{}{"productDelete": s.productDelete(shop, input)}
+	case "bulkOperationRunQuery":
+		data = map[string]interface{}{"bulkOperationRunQuery": s.bulkOperationRunQuery(shop, variables)}
+	case "bulkOperation":
+		var node interface{}
+		if operation := shop.bulkOperation(variables["id"]); operation != nil {
+			node = s.bulkOperationNode(shop, operation)
+		}
+		data = map[string]interface{}{"node": node}
+	case "collectionExists":
+		var node interface{}
+		if collection := shop.collection(variables["id"]); collection != nil {
+			node = map[string]interface{}{"id": gid("Collection", collection.ID)}
+		}
+		data = map[string]interface{}{"collection": node}
+	case "collections":
+		data = map[string]interface{}{"collections": s.collectionsConnection(shop, variables)}
+	case "collectionCreate":
+		data = map[string]interface{}{"collectionCreate": s.collectionCreate(shop, input)}
+	case "collectionDelete":
+		data = map[string]interface{}{"collectionDelete": s.collectionDelete(shop, input)}
+	default:
+		writeGraphQLError(w, fmt.Sprintf("operation %s is not supported by fake server", matches[1]))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Server) productsConnection(shop *shopState, query string, variables map[string]interface{}) map[string]interface{} {
+	first := 50
+	if value, ok := variables["first"].(float64); ok && value > 0 {
+		first = int(value)
+	}
+	// Cursor is an ID of the last product of the previous page
+	after, _ := strconv.ParseInt(fmt.Sprint(variables["after"]), 10, 64)
+	search, _ := variables["query"].(string)
+	tags := searchQueryTags(search)
+
+	nodes := []interface{}{}
+	var endCursor string
+	hasNextPage := false
+	for _, product := range shop.products {
+		if product.ID <= after || !hasTags(product, tags) {
+			continue
+		}
+		if len(nodes) == first {
+			hasNextPage = true
+			break
+		}
+		nodes = append(nodes, pageNested(productNode(product), query))
+		endCursor = strconv.FormatInt(product.ID, 10)
+	}
+
+	return map[string]interface{}{
+		"nodes": nodes,
+		"pageInfo": map[string]interface{}{
+			"hasNextPage": hasNextPage,
+			"endCursor":   endCursor,
+		},
+	}
+}
+
+func (s *Server) productCreate(shop *shopState, input map[string]interface{}, media []interface{}) map[string]interface{} {
+	title, _ := input["title"].(string)
+	if strings.TrimSpace(title) == "" {
+		return mutationPayload("product", nil, userError([]string{"title"}, "Title can't be blank"))
+	}
+
+	product := &Product{}
+	applyProductInput(product, input)
+	// Product is created with a standalone variant of the first value of its option
+	if options, ok := input["productOptions"].([]interface{}); ok && len(options) > 0 {
+		option, _ := options[0].(map[string]interface{})
+		values, _ := option["values"].([]interface{})
+		if len(values) > 0 {
+			value, _ := values[0].(map[string]interface{})
+			title, _ := value["name"].(string)
+			product.Variants = []ProductVariant{{Title: title, Price: "0.00"}}
+		}
+	}
+	product.Images = mediaImages(media)
+	product = s.addProduct(shop, *product)
+
+	return mutationPayload("product", map[string]interface{}{"id": gid("Product", product.ID)}, nil)
+}
+
+func (s *Server) productUpdate(shop *shopState, input map[string]interface{}) map[string]interface{} {
+	product := shop.product(input["id"])
+	if product == nil {
+		return mutationPayload("product", nil, userError([]string{"id"}, "Product does not exist"))
+	}
+	if title, ok := input["title"].(string); ok && strings.TrimSpace(title) == "" {
+		return mutationPayload("product", nil, userError([]string{"title"}, "Title can't be blank"))
+	}
+
+	applyProductInput(product, input)
+	product.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	return mutationPayload("product", map[string]interface{}{"id": gid("Product", product.ID)}, nil)
+}
+
+func (s *Server) productVariantsBulkUpdate(shop *shopState, variables map[string]interface{}) map[string]interface{} {
+	product := shop.product(variables["productId"])
+	if product == nil {
+		return mutationPayload("product", nil, userError([]string{"productId"}, "Product does not exist"))
+	}
+
+	inputs, _ := variables["variants"].([]interface{})
+	for i, value := range inputs {
+		input, _ := value.(map[string]interface{})
+		id, _ := parseGID("ProductVariant", input["id"])
+
+		var variant *ProductVariant
+		for j := range product.Variants {
+			if product.Variants[j].ID == id {
+				variant = &product.Variants[j]
+			}
+		}
+		if variant == nil {
+			return mutationPayload("product", nil, userError([]string{"variants", strconv.Itoa(i), "id"}, "Product variant does not exist"))
+		}
+
+		if price, ok := input["price"].(string); ok {
+			variant.Price = price
+		}
+		if compareAtPrice, ok := input["compareAtPrice"].(string); ok {
+			variant.CompareAtPrice = &compareAtPrice
+		}
+		if barcode, ok := input["barcode"].(string); ok {
+			variant.Barcode = barcode
+		}
+		if inventoryItem, ok := input["inventoryItem"].(map[string]interface{}); ok {
+			if sku, ok := inventoryItem["sku"].(string); ok {
+				variant.SKU = sku
+			}
+		}
+	}
+	product.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	return mutationPayload("product", map[string]interface{}{"id": gid("Product", product.ID)}, nil)
+}
+
+func (s *Server) productVariantsBulkCreate(shop *shopState, variables map[string]interface{}) map[string]interface{} {
+	product := shop.product(variables["productId"])
+	if product == nil {
+		return mutationPayload("product", nil, userError([]string{"productId"}, "Product does not exist"))
+	}
+
+	inputs, _ := variables["variants"].([]interface{})
+	variants := make([]ProductVariant, 0, len(inputs))
+	for i, value := range inputs {
+		input, _ := value.(map[string]interface{})
+		optionValues, _ := input["optionValues"].([]interface{})
+		if len(optionValues) == 0 {
+			return mutationPayload("product", nil, userError([]string{"variants", strconv.Itoa(i), "optionValues"}, "Option values can't be blank"))
+		}
+		optionValue, _ := optionValues[0].(map[string]interface{})
+
+		variant := ProductVariant{ID: s.newID(), Price: "0.00"}
+		variant.Title, _ = optionValue["name"].(string)
+		if price, ok := input["price"].(string); ok {
+			variant.Price = price
+		}
+		if compareAtPrice, ok := input["compareAtPrice"].(string); ok {
+			variant.CompareAtPrice = &compareAtPrice
+		}
+		if inventoryItem, ok := input["inventoryItem"].(map[string]interface{}); ok {
+			variant.SKU, _ = inventoryItem["sku"].(string)
+		}
+		variants = append(variants, variant)
+	}
+
+	if variables["strategy"] == "REMOVE_STANDALONE_VARIANT" {
+		product.Variants = variants
+	} else {
+		product.Variants = append(product.Variants, variants...)
+	}
+	product.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+	return mutationPayload("product", map[string]interface{}{"id": gid("Product", product.ID)}, nil)
+}
+
+func (s *Server) productCreateMedia(shop *shopState, variables map[string]interface{}) map[string]interface{} {
+	product := shop.product(variables["productId"])
+	if product == nil {
+		return map[string]interface{}{"mediaUserErrors": userError([]string{"productId"}, "Product does not exist")}
+	}
+
+	media, _ := variables["media"].([]interface{})
+	for _, image := range mediaImages(media) {
+		image.ID = s.newID()
+		product.Images = append(product.Images, image)
+	}
+
+	return map[string]interface{}{"mediaUserErrors": []interface{}{}}
+}
+
+func (s *Server) productDelete(shop *shopState, input map[string]interface{}) map[string]interface{} {
+	product := shop.product(input["id"])
+	if product == nil {
+		return map[string]interface{}{
+			"deletedProductId": nil,
+			"userErrors":       userError([]string{"id"}, "Product does not exist"),
+		}
+	}
+
+	for i := range shop.products {
+		if shop.products[i] == product {
+			shop.products = append(shop.products[:i], shop.products[i+1:]...)
+			break
+		}
+	}
+
+	return map[string]interface{}{
+		"deletedProductId": gid("Product", product.ID),
+		"userErrors":       []interface{}{},
+	}
+}
+
+func applyProductInput(product *Product, input map[string]interface{}) {
+	fields := map[string]*string{
+		"title":           &product.Title,
+		"descriptionHtml": &product.DescriptionHTML,
+		"handle":          &product.Handle,
+		"status":          &product.Status,
+		"vendor":          &product.Vendor,
+		"productType":     &product.ProductType,
+	}
+	for name, field := range fields {
+		if value, ok := input[name].(string); ok {
+			*field = value
+		}
+	}
+
+	if tags, ok := input["tags"].([]interface{}); ok {
+		product.Tags = make([]string, 0, len(tags))
+		for _, tag := range tags {
+			product.Tags = append(product.Tags, fmt.Sprint(tag))
+		}
+	}
+}
+
+// mediaImages returns images of CreateMediaInput values without IDs.
+func mediaImages(media []interface{}) []ProductImage {
+	images := make([]ProductImage, 0, len(media))
+	for _, value := range media {
+		input, _ := value.(map[string]interface{})
+		url, _ := input["originalSource"].(string)
+		alt, _ := input["alt"].(string)
+		images = append(images, ProductImage{URL: url, AltText: alt})
+	}
+	return images
+}
+
+// searchTagRe matches tag term of the search query, e.g. tag:'summer'.
+var searchTagRe = regexp.MustCompile(`tag:'((?:[^'\\]|\\.)*)'`)
+
+func searchQueryTags(query string) []string {
+	var tags []string
+	for _, matches := range searchTagRe.FindAllStringSubmatch(query, -1) {
+		tags = append(tags, strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(matches[1]))
+	}
+	return tags
+}
+
+func hasTags(product *Product, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, productTag := range product.Tags {
+			if strings.EqualFold(productTag, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// productNode returns product with every field selected by the app's product queries.
+func productNode(product *Product) map[string]interface{} {
+	variants := make([]interface{}, 0, len(product.Variants))
+	values := make([]string, 0, len(product.Variants))
+	for _, variant := range product.Variants {
+		variants = append(variants, map[string]interface{}{
+			"id":                gid("ProductVariant", variant.ID),
+			"title":             variant.Title,
+			"sku":               variant.SKU,
+			"barcode":           variant.Barcode,
+			"price":             variant.Price,
+			"compareAtPrice":    variant.CompareAtPrice,
+			"inventoryQuantity": variant.InventoryQuantity,
+			"selectedOptions":   []interface{}{map[string]interface{}{"name": "Title", "value": variant.Title}},
+		})
+		values = append(values, variant.Title)
+	}
+
+	images := make([]interface{}, 0, len(product.Images))
+	for _, image := range product.Images {
+		images = append(images, map[string]interface{}{
+			"id":      gid("ProductImage", image.ID),
+			"url":     image.URL,
+			"altText": image.AltText,
+		})
+	}
+
+	tags := product.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return map[string]interface{}{
+		"id":              gid("Product", product.ID),
+		"title":           product.Title,
+		"descriptionHtml": product.DescriptionHTML,
+		"handle":          product.Handle,
+		"status":          product.Status,
+		"vendor":          product.Vendor,
+		"productType":     product.ProductType,
+		"tags":            tags,
+		"createdAt":       product.CreatedAt,
+		"updatedAt":       product.UpdatedAt,
+		"options": []interface{}{map[string]interface{}{
+			"id":     gid("ProductOption", product.ID),
+			"name":   "Title",
+			"values": values,
+		}},
+		"variants": map[string]interface{}{"nodes": variants},
+		"images":   map[string]interface{}{"nodes": images},
+	}
+}
+
+// nestedFirstRe matches page sizes of product's nested connections selected by the query.
+var nestedFirstRe = regexp.MustCompile(`(variants|images)\(first: (\d+)\)`)
+
+// pageNested limits product's nested connections to the first pages selected by the query.
+func pageNested(node map[string]interface{}, query string) map[string]interface{} {
+	for _, match := range nestedFirstRe.FindAllStringSubmatch(query, -1) {
+		first, _ := strconv.Atoi(match[2])
+		nodes := node[match[1]].(map[string]interface{})["nodes"].([]interface{})
+		node[match[1]] = nestedConnection(nodes, 0, first)
+	}
+	return node
+}
+
+// nestedConnection returns a page of nested connection, cursor is a number of nodes preceding the page.
+func nestedConnection(nodes []interface{}, after, first int) map[string]interface{} {
+	if after > len(nodes) {
+		after = len(nodes)
+	}
+	end := after + first
+	if end > len(nodes) {
+		end = len(nodes)
+	}
+	endCursor := ""
+	if end > after {
+		endCursor = strconv.Itoa(end)
+	}
+
+	return map[string]interface{}{
+		"nodes": nodes[after:end],
+		"pageInfo": map[string]interface{}{
+			"hasNextPage": end < len(nodes),
+			"endCursor":   endCursor,
+		},
+	}
+}
+
+func mutationPayload(resource string, node interface{}, userErrors []interface{}) map[string]interface{} {
+	if userErrors == nil {
+		userErrors = []interface{}{}
+	}
+	return map[string]interface{}{
+		resource:     node,
+		"userErrors": userErrors,
+	}
+}
+
+func userError(field []string, message string) []interface{} {
+	return []interface{}{map[string]interface{}{"field": field, "message": message}}
+}
+
+func writeGraphQLError(w http.ResponseWriter, message string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"errors": []interface{}{map[string]interface{}{"message": message}},
+	})
+}
+
+func gid(resource string, id int64) string {
+	return fmt.Sprintf("gid://shopify/%s/%d", resource, id)
+}
+
+// parseGID parses numeric ID from the global ID of the resource.
+func parseGID(resource string, value interface{}) (int64, bool) {
+	s, _ := value.(string)
+	id, err := strconv.ParseInt(strings.TrimPrefix(s, fmt.Sprintf("gid://shopify/%s/", resource)), 10, 64)
+	return id, err == nil
+}
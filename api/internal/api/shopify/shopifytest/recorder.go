@@ -0,0 +1,398 @@
+package shopifytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FixturesModeEnv is an environment variable, which switches recorders between RecorderModeReplay and RecorderModeRecord.
+const FixturesModeEnv = "SHOPIFY_FIXTURES_MODE"
+
+// RecorderMode defines whether recorder sends requests to Shopify or replays recorded responses.
+type RecorderMode string
+
+const (
+	// RecorderModeReplay serves responses from the fixture file without network, it is the default mode.
+	RecorderModeReplay RecorderMode = "replay"
+	// RecorderModeRecord sends requests to Shopify and overwrites the fixture file with them on Save.
+	RecorderModeRecord RecorderMode = "record"
+)
+
+// scrubbedValue replaces secrets and personal data in recorded requests and responses.
+const scrubbedValue = "[SCRUBBED]"
+
+// oauthAccessTokenPath is a path of OAuth endpoint exchanging authorization code for access token.
+const oauthAccessTokenPath = "/admin/oauth/access_token"
+
+// secretFields are query parameters and JSON fields, which carry secrets in any request.
+var secretFields = map[string]bool{
+	"access_token":  true,
+	"client_secret": true,
+	"hmac":          true,
+}
+
+// oauthSecretFields are scrubbed in OAuth access token requests and responses only,
+// since elsewhere they are ordinary fields, e.g. code of GraphQL user errors.
+var oauthSecretFields = map[string]bool{
+	"code": true,
+}
+
+// personalFields are JSON fields, which carry personal data of customers and store owners.
+// They are matched case-insensitively ignoring underscores, so first_name of REST and firstName of GraphQL are both scrubbed.
+// Fields, which are personal only in some objects, e.g. name of address, are kept, so fixtures are recorded
+// at development stores with test data only.
+var personalFields = map[string]bool{
+	"email":         true,
+	"contactemail":  true,
+	"customeremail": true,
+	"phone":         true,
+	"firstname":     true,
+	"lastname":      true,
+	"shopowner":     true,
+	"company":       true,
+	"address1":      true,
+	"address2":      true,
+	"city":          true,
+	"zip":           true,
+}
+
+// scrubber scrubs secrets and personal data of the request to the URL and of its response.
+type scrubber struct {
+	oauth bool
+}
+
+func newScrubber(u *url.URL) scrubber {
+	return scrubber{oauth: strings.HasSuffix(u.Path, oauthAccessTokenPath)}
+}
+
+// isScrubbed reports whether query parameter or JSON field with the name is scrubbed.
+func (s scrubber) isScrubbed(name string) bool {
+	name = strings.ToLower(name)
+	if secretFields[name] || s.oauth && oauthSecretFields[name] {
+		return true
+	}
+	return personalFields[strings.ReplaceAll(name, "_", "")]
+}
+
+// recordedHeaders are response headers kept in fixtures, since the API client depends on them.
+// Request headers and other response headers, e.g. access token or cookies, are never recorded.
+var recordedHeaders = []string{
+	"Content-Type",
+	"Link",
+	"Retry-After",
+	"X-Shopify-Shop-Api-Call-Limit",
+	"X-Shopify-API-Deprecated-Reason",
+	"X-Shopify-API-Version",
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request with secrets scrubbed.
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	// Text is a body, which isn't JSON.
+	Text string `json:"text,omitempty"`
+}
+
+// RecordedResponse is a response with secrets scrubbed.
+type RecordedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	// Text is a body, which isn't JSON.
+	Text string `json:"text,omitempty"`
+}
+
+var _ http.RoundTripper = (*Recorder)(nil)
+
+// Recorder is an http.RoundTripper, which records Shopify API interactions into a golden file or replays them from it.
+// It is used as shopify.Options.Transport.
+//
+// In replay mode every request is matched with the first not yet replayed interaction of the same method, URL and body.
+// Secrets are scrubbed before matching, so recorded access tokens are never needed.
+type Recorder struct {
+	path      string
+	mode      RecorderMode
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     []bool
+}
+
+// NewRecorder creates recorder of the fixture file at path in the mode set by FixturesModeEnv.
+func NewRecorder(path string) (*Recorder, error) {
+	mode := RecorderMode(os.Getenv(FixturesModeEnv))
+	if mode == "" {
+		mode = RecorderModeReplay
+	}
+	return NewRecorderWithMode(path, mode)
+}
+
+// NewRecorderWithMode creates recorder of the fixture file at path in the given mode.
+func NewRecorderWithMode(path string, mode RecorderMode) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+	}
+
+	switch mode {
+	case RecorderModeRecord:
+	case RecorderModeReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture, record it with %s=%s: %w", FixturesModeEnv, RecorderModeRecord, err)
+		}
+		err = json.Unmarshal(data, &r.interactions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode fixture: %w", err)
+		}
+		r.replayed = make([]bool, len(r.interactions))
+	default:
+		return nil, fmt.Errorf("unknown fixtures mode %q", mode)
+	}
+
+	return r, nil
+}
+
+// Mode returns recorder's mode.
+func (r *Recorder) Mode() RecorderMode {
+	return r.mode
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recordedReq, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == RecorderModeReplay {
+		return r.replay(req, recordedReq)
+	}
+
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	recordedRes, body, err := recordResponse(req, res)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{Request: recordedReq, Response: recordedRes})
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recordedReq RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.replayed[i] || !requestsEqual(interaction.Request, recordedReq) {
+			continue
+		}
+		r.replayed[i] = true
+
+		body := []byte(interaction.Response.Body)
+		if interaction.Response.Text != "" {
+			body = []byte(interaction.Response.Text)
+		}
+
+		header := http.Header{}
+		for name, value := range interaction.Response.Headers {
+			header.Set(name, value)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("interaction %s %s is not recorded in %s", recordedReq.Method, recordedReq.URL, r.path)
+}
+
+// Unreplayed returns recorded interactions, which weren't replayed, e.g. because the client stopped sending them.
+func (r *Recorder) Unreplayed() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unreplayed []Interaction
+	for i, interaction := range r.interactions {
+		if r.mode == RecorderModeReplay && !r.replayed[i] {
+			unreplayed = append(unreplayed, interaction)
+		}
+	}
+	return unreplayed
+}
+
+// Save writes recorded interactions into the fixture file, it does nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != RecorderModeRecord {
+		return nil
+	}
+
+	// HTML characters aren't escaped to keep URLs readable in diffs of the fixture
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	r.mu.Lock()
+	err := encoder.Encode(r.interactions)
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(r.path), 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	err = os.WriteFile(r.path, data.Bytes(), 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	return nil
+}
+
+func recordRequest(req *http.Request) (RecordedRequest, error) {
+	scrubber := newScrubber(req.URL)
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    scrubber.scrubURL(req.URL),
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return recorded, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return recorded, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	recorded.Body, recorded.Text = scrubber.scrubBody(body)
+	return recorded, nil
+}
+
+func recordResponse(req *http.Request, res *http.Response) (RecordedResponse, []byte, error) {
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return RecordedResponse{}, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	recorded := RecordedResponse{
+		Status:  res.StatusCode,
+		Headers: map[string]string{},
+	}
+	for _, name := range recordedHeaders {
+		if value := res.Header.Get(name); value != "" {
+			recorded.Headers[name] = value
+		}
+	}
+	recorded.Body, recorded.Text = newScrubber(req.URL).scrubBody(body)
+
+	return recorded, body, nil
+}
+
+// scrubURL returns URL with secret query parameters scrubbed.
+func (s scrubber) scrubURL(u *url.URL) string {
+	scrubbed := *u
+	scrubbed.User = nil
+	query := scrubbed.Query()
+	for name := range query {
+		if s.isScrubbed(name) {
+			query.Set(name, scrubbedValue)
+		}
+	}
+	scrubbed.RawQuery = query.Encode()
+	return scrubbed.String()
+}
+
+// scrubBody returns JSON body with secret and personal fields scrubbed, other bodies are returned as text.
+func (s scrubber) scrubBody(body []byte) (json.RawMessage, string) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, ""
+	}
+
+	var value interface{}
+	err := json.Unmarshal(body, &value)
+	if err != nil {
+		return nil, string(body)
+	}
+
+	var scrubbed bytes.Buffer
+	encoder := json.NewEncoder(&scrubbed)
+	encoder.SetEscapeHTML(false)
+	err = encoder.Encode(s.scrubValue(value))
+	if err != nil {
+		return nil, string(body)
+	}
+	return bytes.TrimSpace(scrubbed.Bytes()), ""
+}
+
+func (s scrubber) scrubValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			// Null fields are kept, since the client may distinguish them from set ones
+			if s.isScrubbed(key) && field != nil {
+				value[key] = scrubbedValue
+				continue
+			}
+			value[key] = s.scrubValue(field)
+		}
+	case []interface{}:
+		for i := range value {
+			value[i] = s.scrubValue(value[i])
+		}
+	}
+	return value
+}
+
+// requestsEqual compares requests ignoring formatting of their JSON bodies.
+func requestsEqual(recorded, actual RecordedRequest) bool {
+	if recorded.Method != actual.Method || recorded.URL != actual.URL || recorded.Text != actual.Text {
+		return false
+	}
+	if len(recorded.Body) == 0 || len(actual.Body) == 0 {
+		return len(recorded.Body) == len(actual.Body)
+	}
+
+	var recordedBody, actualBody interface{}
+	if json.Unmarshal(recorded.Body, &recordedBody) != nil || json.Unmarshal(actual.Body, &actualBody) != nil {
+		return bytes.Equal(recorded.Body, actual.Body)
+	}
+	recordedJSON, _ := json.Marshal(recordedBody)
+	actualJSON, _ := json.Marshal(actualBody)
+	return bytes.Equal(recordedJSON, actualJSON)
+}
@@ -0,0 +1,124 @@
+// Package shopifytest provides an in-process fake Shopify server, so the app's flows can be tested without network.
+//
+// The server keeps every store's state in memory. Stores are served under their own path prefix,
+// so the Shopify API is pointed at the server with shopify.Options{BaseURL: server.BaseURL}.
+package shopifytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options is used to create fake server.
+type Options struct {
+	// APIKey and APISecret are app's credentials, the server signs requests and webhooks with the secret.
+	APIKey    string
+	APISecret string
+	// Scopes are granted to the app on installation.
+	Scopes string
+}
+
+// Server is a fake Shopify server.
+type Server struct {
+	*httptest.Server
+
+	opts   Options
+	client *http.Client
+
+	mu     sync.Mutex
+	shops  map[string]*shopState
+	nextID int64
+}
+
+// NewServer starts fake server, it must be closed with Close once it is not needed.
+func NewServer(opts Options) *Server {
+	s := &Server{
+		opts:   opts,
+		client: &http.Client{Timeout: 10 * time.Second},
+		shops:  map[string]*shopState{},
+		nextID: 1000,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// BaseURL resolves store's Admin API base URL at the server, it is used as shopify.Options.BaseURL.
+func (s *Server) BaseURL(storeName string) string {
+	return fmt.Sprintf("%s/%s", s.URL, storeName)
+}
+
+// adminAPIPathRe matches Admin API path of any version, e.g. /admin/api/2024-04/shop.json.
+var adminAPIPathRe = regexp.MustCompile(`^/admin/api/[^/]+(/.+)$`)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	storeName, path, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if !ok || storeName == "" {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	path = "/" + path
+
+	switch path {
+	case "/admin/oauth/authorize":
+		s.handleAuthorize(w, r, storeName)
+		return
+	case "/admin/oauth/access_token":
+		s.handleAccessToken(w, r, storeName)
+		return
+	}
+
+	if strings.HasPrefix(path, "/bulk/") {
+		s.handleBulkOperationResult(w, storeName, path)
+		return
+	}
+
+	matches := adminAPIPathRe.FindStringSubmatch(path)
+	if matches == nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shop := s.shops[storeName]
+	if shop == nil || !shop.installed || shop.accessToken == "" || r.Header.Get("X-Shopify-Access-Token") != shop.accessToken {
+		writeError(w, http.StatusUnauthorized, "[API] Invalid API key or access token (unrecognized login or wrong password)")
+		return
+	}
+
+	switch resource := matches[1]; {
+	case resource == "/shop.json" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"shop": shop.resource()})
+	case resource == "/graphql.json" && r.Method == http.MethodPost:
+		s.handleGraphQL(w, r, shop)
+	case resource == "/webhooks.json" || strings.HasPrefix(resource, "/webhooks/"):
+		s.handleWebhooks(w, r, shop, resource)
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+// newID returns a new ID of any resource, IDs are unique across stores.
+// It must be called with mu locked.
+func (s *Server) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"errors": message})
+}
@@ -0,0 +1,27 @@
+package shopifytest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SessionToken returns session token App Bridge issues to the app's UI of the store, it is sent as bearer token.
+// https://shopify.dev/docs/apps/auth/oauth/session-tokens/getting-started#obtain-and-verify-session-details
+func (s *Server) SessionToken(storeName string) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":  fmt.Sprintf("https://%s/admin", storeName),
+		"dest": fmt.Sprintf("https://%s", storeName),
+		"aud":  s.opts.APIKey,
+		"sub":  "1",
+		"exp":  now.Add(time.Minute).Unix(),
+		"nbf":  now.Add(-time.Second).Unix(),
+		"iat":  now.Unix(),
+		"jti":  fmt.Sprintf("%d", now.UnixNano()),
+		"sid":  storeName,
+	})
+
+	return token.SignedString([]byte(s.opts.APISecret))
+}
@@ -0,0 +1,187 @@
+package shopifytest
+
+import (
+	"strings"
+	"time"
+)
+
+// Shop is store's metadata served by shop endpoint.
+type Shop struct {
+	ID              int64
+	Name            string
+	Email           string
+	Currency        string
+	IANATimezone    string
+	PlanName        string
+	PlanDisplayName string
+	// Domain is shop's primary domain, myshopify domain is used if it is empty.
+	Domain      string
+	CountryCode string
+	// UpdatedAt is the time shop's metadata was updated at, it is set to the current time if it is zero.
+	UpdatedAt time.Time
+}
+
+type shopState struct {
+	Shop
+	myshopifyDomain string
+
+	installed   bool
+	accessToken string
+	scope       string
+	// codes are authorization codes issued to the app and not exchanged for access token yet.
+	codes       map[string]bool
+	products    []*Product
+	collections []*Collection
+	webhooks    []*Webhook
+	// bulkOperations are kept after uninstallation, so their result files are still served.
+	bulkOperations []*bulkOperation
+}
+
+// AddShop adds store with given metadata, stores are otherwise created with default metadata on the first authorization.
+func (s *Server) AddShop(storeName string, shop Shop) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.getOrCreateShop(storeName).Shop = s.shopWithDefaults(storeName, shop)
+}
+
+// UpdateShop replaces store's metadata and sends shop/update webhook, e.g. to emulate store's plan change.
+func (s *Server) UpdateShop(storeName string, shop Shop) error {
+	s.mu.Lock()
+	state := s.getOrCreateShop(storeName)
+	state.Shop = s.shopWithDefaults(storeName, shop)
+	resource := state.resource()
+	s.mu.Unlock()
+
+	return s.SendWebhook(storeName, "shop/update", resource)
+}
+
+// AccessToken returns store's access token, it is empty if app is not installed.
+func (s *Server) AccessToken(storeName string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if shop := s.shops[storeName]; shop != nil && shop.installed {
+		return shop.accessToken
+	}
+	return ""
+}
+
+// Installed reports whether app is installed at the store.
+func (s *Server) Installed(storeName string) bool {
+	return s.AccessToken(storeName) != ""
+}
+
+// Install installs app at the store without OAuth flow and returns the access token,
+// e.g. to test services of stores, which installed the app earlier.
+func (s *Server) Install(storeName string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shop := s.getOrCreateShop(storeName)
+	err := s.install(shop)
+	if err != nil {
+		return "", err
+	}
+	return shop.accessToken, nil
+}
+
+// install issues a new access token to the app, it must be called with mu locked.
+func (s *Server) install(shop *shopState) error {
+	token, err := randomHex()
+	if err != nil {
+		return err
+	}
+
+	shop.installed = true
+	shop.accessToken = "shpat_" + token
+	shop.scope = s.opts.Scopes
+	return nil
+}
+
+// Uninstall revokes app's access token and sends app/uninstalled webhook.
+func (s *Server) Uninstall(storeName string) error {
+	s.mu.Lock()
+	shop := s.shops[storeName]
+	if shop == nil || !shop.installed {
+		s.mu.Unlock()
+		return nil
+	}
+	resource := shop.resource()
+	s.mu.Unlock()
+
+	// Webhook is sent while the subscriptions still exist, they are deleted with the access token afterwards
+	err := s.SendWebhook(storeName, "app/uninstalled", resource)
+
+	s.mu.Lock()
+	shop.installed = false
+	shop.accessToken = ""
+	shop.webhooks = nil
+	s.mu.Unlock()
+
+	return err
+}
+
+// getOrCreateShop must be called with mu locked.
+func (s *Server) getOrCreateShop(storeName string) *shopState {
+	shop := s.shops[storeName]
+	if shop == nil {
+		shop = &shopState{
+			Shop:            s.shopWithDefaults(storeName, Shop{}),
+			myshopifyDomain: storeName,
+			codes:           map[string]bool{},
+		}
+		s.shops[storeName] = shop
+	}
+	return shop
+}
+
+// shopWithDefaults must be called with mu locked.
+func (s *Server) shopWithDefaults(storeName string, shop Shop) Shop {
+	if shop.ID == 0 {
+		shop.ID = s.newID()
+	}
+	if shop.Name == "" {
+		shop.Name = strings.Split(storeName, ".")[0]
+	}
+	if shop.Email == "" {
+		shop.Email = "owner@example.com"
+	}
+	if shop.Currency == "" {
+		shop.Currency = "USD"
+	}
+	if shop.IANATimezone == "" {
+		shop.IANATimezone = "America/New_York"
+	}
+	if shop.PlanName == "" {
+		shop.PlanName = "partner_test"
+		shop.PlanDisplayName = "Developer Preview"
+	}
+	if shop.Domain == "" {
+		shop.Domain = storeName
+	}
+	if shop.CountryCode == "" {
+		shop.CountryCode = "US"
+	}
+	if shop.UpdatedAt.IsZero() {
+		shop.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	}
+	return shop
+}
+
+// resource returns shop as REST resource, which is also a payload of shop webhooks.
+func (shop *shopState) resource() map[string]interface{} {
+	return map[string]interface{}{
+		"id":                shop.ID,
+		"name":              shop.Name,
+		"email":             shop.Email,
+		"domain":            shop.Domain,
+		"currency":          shop.Currency,
+		"iana_timezone":     shop.IANATimezone,
+		"plan_name":         shop.PlanName,
+		"plan_display_name": shop.PlanDisplayName,
+		"country_code":      shop.CountryCode,
+		"myshopify_domain":  shop.myshopifyDomain,
+		"updated_at":        shop.UpdatedAt,
+	}
+}
@@ -0,0 +1,168 @@
+package shopifytest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Webhook is store's webhook subscription.
+type Webhook struct {
+	ID      int64    `json:"id"`
+	Topic   string   `json:"topic"`
+	Address string   `json:"address"`
+	Format  string   `json:"format"`
+	Fields  []string `json:"fields"`
+}
+
+// Webhooks returns store's webhook subscriptions.
+func (s *Server) Webhooks(storeName string) []Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shop := s.shops[storeName]
+	if shop == nil {
+		return nil
+	}
+
+	webhooks := make([]Webhook, 0, len(shop.webhooks))
+	for _, webhook := range shop.webhooks {
+		webhooks = append(webhooks, *webhook)
+	}
+	return webhooks
+}
+
+// SendWebhook delivers webhook of the topic to every store's subscription to it, signed with app's secret.
+// It fails if any delivery isn't acknowledged with 2xx status.
+func (s *Server) SendWebhook(storeName, topic string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.opts.APISecret))
+	mac.Write(body)
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	var addresses []string
+	for _, webhook := range s.Webhooks(storeName) {
+		if webhook.Topic == topic {
+			addresses = append(addresses, webhook.Address)
+		}
+	}
+
+	for _, address := range addresses {
+		webhookID, err := randomHex()
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequest(http.MethodPost, address, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Shopify-Topic", topic)
+		req.Header.Set("X-Shopify-Hmac-Sha256", signature)
+		req.Header.Set("X-Shopify-Shop-Domain", storeName)
+		req.Header.Set("X-Shopify-Webhook-Id", webhookID)
+
+		res, err := s.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to deliver %s webhook to %s: %w", topic, address, err)
+		}
+		res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return fmt.Errorf("failed to deliver %s webhook to %s: http status %d", topic, address, res.StatusCode)
+		}
+	}
+
+	return nil
+}
+
+type webhookBody struct {
+	Webhook Webhook `json:"webhook"`
+}
+
+// handleWebhooks serves REST webhook subscription endpoints, it must be called with mu locked.
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request, shop *shopState, resource string) {
+	if resource == "/webhooks.json" {
+		switch r.Method {
+		case http.MethodGet:
+			webhooks := make([]Webhook, 0, len(shop.webhooks))
+			for _, webhook := range shop.webhooks {
+				webhooks = append(webhooks, *webhook)
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"webhooks": webhooks})
+		case http.MethodPost:
+			var body webhookBody
+			err := json.NewDecoder(r.Body).Decode(&body)
+			if err != nil || body.Webhook.Topic == "" || body.Webhook.Address == "" {
+				writeError(w, http.StatusUnprocessableEntity, "topic and address are required")
+				return
+			}
+
+			webhook := body.Webhook
+			webhook.ID = s.newID()
+			if webhook.Format == "" {
+				webhook.Format = "json"
+			}
+			shop.webhooks = append(shop.webhooks, &webhook)
+			writeJSON(w, http.StatusCreated, webhookBody{Webhook: webhook})
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		}
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(resource, "/webhooks/"), ".json"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	index := -1
+	for i, webhook := range shop.webhooks {
+		if webhook.ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, webhookBody{Webhook: *shop.webhooks[index]})
+	case http.MethodPut:
+		var body webhookBody
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		// Topic can't be changed
+		webhook := shop.webhooks[index]
+		if body.Webhook.Address != "" {
+			webhook.Address = body.Webhook.Address
+		}
+		if body.Webhook.Format != "" {
+			webhook.Format = body.Webhook.Format
+		}
+		webhook.Fields = body.Webhook.Fields
+		writeJSON(w, http.StatusOK, webhookBody{Webhook: *webhook})
+	case http.MethodDelete:
+		shop.webhooks = append(shop.webhooks[:index], shop.webhooks[index+1:]...)
+		writeJSON(w, http.StatusOK, map[string]interface{}{})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
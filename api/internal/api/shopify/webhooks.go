@@ -1,46 +1,191 @@
 package shopify
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"sort"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/softcery/shopify-app-template-go/internal/service"
 )
 
-type subscribeToWebhookRequestBody struct {
-	Address string `json:"address"`
-	Topic   string `json:"topic"`
-	Format  string `json:"format"`
+type webhookSubscription struct {
+	ID      int64    `json:"id,omitempty"`
+	Address string   `json:"address"`
+	Topic   string   `json:"topic,omitempty"`
+	Format  string   `json:"format"`
+	Fields  []string `json:"fields"`
 }
 
-func (s *shopifyAPI) SubscribeToAppUninstallWebhook(opts service.SubscribeToAppUninstallWebhookOptions) error {
+type webhookSubscriptionRequestBody struct {
+	Webhook webhookSubscription `json:"webhook"`
+}
+
+type listWebhookSubscriptionsResponseBody struct {
+	Webhooks []webhookSubscription `json:"webhooks"`
+}
+
+func (s *shopifyAPI) ReconcileWebhooks(ctx context.Context, opts service.ReconcileWebhooksOptions) (service.ReconcileWebhooksOutput, error) {
 	logger := s.logger.
-		Named("SubscribeToAppUninstallWebhook").
-		With("opts", opts)
-
-	var res, err = s.client.R().
-		SetBody(map[string]interface{}{
-			"webhook": subscribeToWebhookRequestBody{
-				Address: opts.RedirectURL,
-				Topic:   "app/uninstalled",
-				Format:  "json",
-			},
-		}).
+		Named("ReconcileWebhooks").
+		WithContext(ctx).
+		With("storeName", opts.StoreName, "subscriptions", opts.Subscriptions)
+
+	ctx, cancel := s.withTimeout(ctx, "ReconcileWebhooks")
+	defer cancel()
+
+	var output service.ReconcileWebhooksOutput
+
+	existing, err := s.listWebhookSubscriptions(ctx, opts)
+	if err != nil {
+		logger.Error("failed to list webhook subscriptions", "err", err)
+		return output, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	logger = logger.With("existing", existing)
+	logger.Debug("listed webhook subscriptions")
+
+	// Index existing subscriptions by topic, so each topic is matched with a single subscription
+	existingByTopic := make(map[string]webhookSubscription, len(existing))
+	var redundant []webhookSubscription
+	for _, subscription := range existing {
+		if _, ok := existingByTopic[subscription.Topic]; ok {
+			redundant = append(redundant, subscription)
+			continue
+		}
+		existingByTopic[subscription.Topic] = subscription
+	}
+
+	for _, desired := range opts.Subscriptions {
+		logger := logger.With("topic", desired.Topic)
+
+		subscription := webhookSubscription{
+			Address: desired.Address,
+			Topic:   desired.Topic,
+			Format:  desired.Format,
+			Fields:  desired.Fields,
+		}
+		if subscription.Format == "" {
+			subscription.Format = "json"
+		}
+
+		current, ok := existingByTopic[desired.Topic]
+		delete(existingByTopic, desired.Topic)
+		switch {
+		case !ok:
+			err = s.sendWebhookSubscription(ctx, opts, http.MethodPost, "/webhooks.json", subscription, http.StatusCreated)
+			if err != nil {
+				logger.Error("failed to create webhook subscription", "err", err)
+				return output, fmt.Errorf("failed to create %s webhook subscription: %w", desired.Topic, err)
+			}
+			output.Created = append(output.Created, desired.Topic)
+		case !webhookSubscriptionsEqual(current, subscription):
+			// Topic can't be changed, so it is omitted from the update request
+			subscription.Topic = ""
+			err = s.sendWebhookSubscription(ctx, opts, http.MethodPut, fmt.Sprintf("/webhooks/%d.json", current.ID), subscription, http.StatusOK)
+			if err != nil {
+				logger.Error("failed to update webhook subscription", "err", err)
+				return output, fmt.Errorf("failed to update %s webhook subscription: %w", desired.Topic, err)
+			}
+			output.Updated = append(output.Updated, desired.Topic)
+		}
+	}
+
+	// Delete subscriptions, which are not desired anymore
+	for _, subscription := range existingByTopic {
+		redundant = append(redundant, subscription)
+	}
+	for _, subscription := range redundant {
+		err = s.deleteWebhookSubscription(ctx, opts, subscription.ID)
+		if err != nil {
+			logger.Error("failed to delete webhook subscription", "err", err, "topic", subscription.Topic)
+			return output, fmt.Errorf("failed to delete %s webhook subscription: %w", subscription.Topic, err)
+		}
+		output.Deleted = append(output.Deleted, subscription.Topic)
+	}
+
+	logger = logger.With("output", output)
+	logger.Info("successfully reconciled shopify webhooks")
+	return output, nil
+}
+
+// webhookRequest builds request authorized with store's access token.
+func (s *shopifyAPI) webhookRequest(ctx context.Context, opts service.ReconcileWebhooksOptions) *resty.Request {
+	return s.client.R().
+		SetContext(ctx).
 		SetHeaders(map[string]string{
 			"Content-Type":           "application/json",
 			"X-Shopify-Access-Token": opts.AccessToken,
-		}).
-		Post(fmt.Sprintf("https://%s/admin/api/2022-04/webhooks.json", opts.StoreName))
+		})
+}
+
+// webhooksURL builds URL of the store's webhooks endpoint.
+func (s *shopifyAPI) webhooksURL(storeName, path string) string {
+	return s.baseURL(storeName) + s.adminPath(path)
+}
+
+func (s *shopifyAPI) listWebhookSubscriptions(ctx context.Context, opts service.ReconcileWebhooksOptions) ([]webhookSubscription, error) {
+	var responseBody listWebhookSubscriptionsResponseBody
+	res, err := s.webhookRequest(ctx, opts).
+		SetQueryParam("limit", "250").
+		SetResult(&responseBody).
+		Get(s.webhooksURL(opts.StoreName, "/webhooks.json"))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("http status %d, body %s", res.StatusCode(), res.String())
+	}
+
+	return responseBody.Webhooks, nil
+}
+
+func (s *shopifyAPI) sendWebhookSubscription(ctx context.Context, opts service.ReconcileWebhooksOptions, method, path string, subscription webhookSubscription, expectedStatus int) error {
+	res, err := s.webhookRequest(ctx, opts).
+		SetBody(webhookSubscriptionRequestBody{Webhook: subscription}).
+		Execute(method, s.webhooksURL(opts.StoreName, path))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode() != expectedStatus {
+		return fmt.Errorf("http status %d, body %s", res.StatusCode(), res.String())
+	}
+
+	return nil
+}
+
+func (s *shopifyAPI) deleteWebhookSubscription(ctx context.Context, opts service.ReconcileWebhooksOptions, id int64) error {
+	res, err := s.webhookRequest(ctx, opts).
+		Delete(s.webhooksURL(opts.StoreName, fmt.Sprintf("/webhooks/%d.json", id)))
 	if err != nil {
-		logger.Error("failed to subscribe to shopify app/uninstalled webhook", "err", err)
-		return fmt.Errorf("failed to subscribe to shopify app/uninstalled webhook: %w", err)
+		return err
 	}
-	if res.StatusCode() != http.StatusCreated {
-		logger.Error("failed to subscribe to shopify app/uninstalled webhook", "resBody", res.String())
-		return fmt.Errorf("failed to subscribe to shopify app/uninstalled webhook: http status %d, body %s", res.StatusCode(), res.String())
+	// Subscription could be deleted concurrently, which is fine
+	if res.StatusCode() != http.StatusOK && res.StatusCode() != http.StatusNotFound {
+		return fmt.Errorf("http status %d, body %s", res.StatusCode(), res.String())
 	}
-	logger = logger.With("resBody", res.Body())
 
-	logger.Info("successfully subscribed to shopify app/uninstalled webhook")
 	return nil
 }
+
+// webhookSubscriptionsEqual checks whether existing subscription matches the desired one.
+func webhookSubscriptionsEqual(existing, desired webhookSubscription) bool {
+	if existing.Address != desired.Address || existing.Format != desired.Format {
+		return false
+	}
+	if len(existing.Fields) != len(desired.Fields) {
+		return false
+	}
+
+	existingFields := append([]string(nil), existing.Fields...)
+	desiredFields := append([]string(nil), desired.Fields...)
+	sort.Strings(existingFields)
+	sort.Strings(desiredFields)
+	for i := range existingFields {
+		if existingFields[i] != desiredFields[i] {
+			return false
+		}
+	}
+
+	return true
+}
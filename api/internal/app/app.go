@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
@@ -14,13 +15,74 @@ import (
 	"github.com/softcery/shopify-app-template-go/internal/service"
 	"github.com/softcery/shopify-app-template-go/internal/storage"
 	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"github.com/softcery/shopify-app-template-go/pkg/encryption"
 	"github.com/softcery/shopify-app-template-go/pkg/httpserver"
 	"github.com/softcery/shopify-app-template-go/pkg/logging"
+	"github.com/softcery/shopify-app-template-go/pkg/queue"
 )
 
 func Run(cfg *config.Config) {
 	logger := logging.NewZap(cfg.Log.Level)
 
+	deps := newDependencies(cfg, logger)
+
+	// Start job queue workers
+	for kind, handler := range newJobHandlers(deps.services) {
+		deps.queue.Register(kind, handler)
+	}
+	deps.queue.Start()
+
+	// Schedule periodic jobs, every app instance schedules them, but they are deduplicated by the queue
+	scheduleCtx, stopSchedule := context.WithCancel(context.Background())
+	defer stopSchedule()
+	go runPeriodically(scheduleCtx, cfg.Shopify.OrdersSyncInterval, logger, "ScheduleOrdersSync", deps.services.Order.ScheduleOrdersSync)
+
+	httpHandler := newHTTPHandler(cfg, logger, deps.services, deps.storages)
+
+	httpServer := httpserver.New(
+		httpHandler,
+		httpserver.Port(cfg.HTTP.Port),
+		httpserver.ReadTimeout(120*time.Second),
+		httpserver.WriteTimeout(120*time.Second),
+		httpserver.ShutdownTimeout(30*time.Second),
+	)
+
+	// Waiting for a signal
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case s := <-interrupt:
+		logger.Info("app - Run - signal: " + s.String())
+
+	case err := <-httpServer.Notify():
+		logger.Error("app - Run - httpServer.Notify", "err", err)
+	}
+
+	stopSchedule()
+
+	// Shutdown HTTP server
+	err := httpServer.Shutdown()
+	if err != nil {
+		logger.Error("app - Run - httpServer.Shutdown", "err", err)
+	}
+
+	// Drain job queue
+	err = deps.queue.Shutdown()
+	if err != nil {
+		logger.Error("app - Run - jobQueue.Shutdown", "err", err)
+	}
+}
+
+// dependencies contains initialized storages and services of the app.
+type dependencies struct {
+	storages service.Storages
+	services service.Services
+	queue    *queue.Queue
+}
+
+// newDependencies connects to the database and initializes app's dependencies.
+func newDependencies(cfg *config.Config, logger logging.Logger) *dependencies {
 	// Init db
 	sql, err := database.NewPostgreSQL(&database.PostgreSQLConfig{
 		User:     cfg.Postgres.User,
@@ -34,13 +96,36 @@ func Run(cfg *config.Config) {
 
 	err = sql.DB.AutoMigrate(
 		&entity.Store{},
+		&entity.OAuthState{},
+		&entity.ComplianceRequest{},
+		&entity.ProcessedWebhook{},
+		&entity.BulkOperation{},
+		&entity.Product{},
+		&entity.ProductVariant{},
+		&entity.Order{},
+		&entity.Customer{},
+		&entity.SeedRun{},
+		&queue.Job{},
 	)
 	if err != nil {
 		logger.Fatal("automigration failed", "err", err)
 	}
 
+	cipher, err := encryption.NewAESGCMFromBase64(cfg.Encryption.Key)
+	if err != nil {
+		logger.Fatal("failed to init encryption, ENCRYPTION_KEY must be base64 encoded 32 bytes key", "err", err)
+	}
+
 	storages := service.Storages{
-		Store: storage.NewStoreStorage(sql),
+		Store:             storage.NewStoreStorage(sql),
+		OAuthState:        storage.NewOAuthStateStorage(sql),
+		ComplianceRequest: storage.NewComplianceRequestStorage(sql, cipher),
+		ProcessedWebhook:  storage.NewProcessedWebhookStorage(sql),
+		BulkOperation:     storage.NewBulkOperationStorage(sql),
+		Product:           storage.NewProductStorage(sql),
+		Order:             storage.NewOrderStorage(sql, cipher),
+		Customer:          storage.NewCustomerStorage(sql, cipher),
+		SeedRun:           storage.NewSeedRunStorage(sql),
 	}
 
 	apis := service.APIs{
@@ -50,19 +135,68 @@ func Run(cfg *config.Config) {
 		}),
 	}
 
+	jobQueue := queue.New(sql, logger,
+		queue.Workers(cfg.Queue.Workers),
+		queue.PollInterval(cfg.Queue.PollInterval),
+		queue.MaxAttempts(cfg.Queue.MaxAttempts),
+		queue.Backoff(cfg.Queue.BackoffBase, cfg.Queue.BackoffMax),
+		queue.LockTimeout(cfg.Queue.LockTimeout),
+		queue.ShutdownTimeout(cfg.Queue.ShutdownTimeout),
+		// Webhook payloads carry customers' data, so it is encrypted at rest like the one in storages
+		queue.Cipher(cipher),
+	)
+
+	return &dependencies{
+		storages: storages,
+		services: newServices(cfg, logger, storages, apis, jobQueue),
+		queue:    jobQueue,
+	}
+}
+
+// newServices initializes app's services.
+func newServices(cfg *config.Config, logger logging.Logger, storages service.Storages, apis service.APIs, jobQueue service.JobQueue) service.Services {
 	serviceOptions := &service.Options{
 		Apis:     apis,
 		Storages: storages,
+		Webhooks: service.NewWebhookRegistry(),
+		Queue:    jobQueue,
 		Config:   cfg,
 		Logger:   logger,
 	}
 
+	bulkOperationService := service.NewBulkOperationService(serviceOptions)
+	serviceOptions.BulkOperations = bulkOperationService
+
 	services := service.Services{
-		Platform: service.NewPlatformService(serviceOptions),
+		Platform:      service.NewPlatformService(serviceOptions),
+		Webhook:       service.NewWebhookService(serviceOptions),
+		BulkOperation: bulkOperationService,
+		Catalog:       service.NewCatalogService(serviceOptions),
+		Order:         service.NewOrderService(serviceOptions),
+		Customer:      service.NewCustomerService(serviceOptions),
+		Billing:       service.NewBillingService(serviceOptions),
+		Seed:          service.NewSeedService(serviceOptions),
+	}
+
+	return services
+}
+
+// newJobHandlers returns handlers of every job kind enqueued by the services.
+func newJobHandlers(services service.Services) map[string]queue.Handler {
+	return map[string]queue.Handler{
+		service.JobKindWebhook:           queue.JSONHandler(services.Webhook.HandleWebhook),
+		service.JobKindPollBulkOperation: queue.JSONHandler(services.BulkOperation.PollBulkOperation),
+		service.JobKindImportProducts:    queue.JSONHandler(services.Catalog.ImportProducts),
+		service.JobKindSyncOrders:        queue.JSONHandler(services.Order.SyncOrders),
+		service.JobKindImportCustomers:   queue.JSONHandler(services.Customer.ImportCustomers),
 	}
+}
 
-	// Init HTTP framework of choice
+// newHTTPHandler initializes HTTP framework of choice with app's routes.
+func newHTTPHandler(cfg *config.Config, logger logging.Logger, services service.Services, storages service.Storages) *gin.Engine {
 	httpHandler := gin.New()
+	// Handlers' contexts are cancelled once client disconnects or server shuts down
+	httpHandler.ContextWithFallback = true
 
 	httpcontroller.New(&httpcontroller.Options{
 		Handler:  httpHandler,
@@ -72,29 +206,5 @@ func Run(cfg *config.Config) {
 		Config:   cfg,
 	})
 
-	httpServer := httpserver.New(
-		httpHandler,
-		httpserver.Port(cfg.HTTP.Port),
-		httpserver.ReadTimeout(120*time.Second),
-		httpserver.WriteTimeout(120*time.Second),
-		httpserver.ShutdownTimeout(30*time.Second),
-	)
-
-	// Waiting for a signal
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
-
-	select {
-	case s := <-interrupt:
-		logger.Info("app - Run - signal: " + s.String())
-
-	case err = <-httpServer.Notify():
-		logger.Error("app - Run - httpServer.Notify", "err", err)
-	}
-
-	// Shutdown HTTP server
-	err = httpServer.Shutdown()
-	if err != nil {
-		logger.Error("app - Run - httpServer.Shutdown", "err", err)
-	}
+	return httpHandler
 }
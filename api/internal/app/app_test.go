@@ -0,0 +1,354 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/api/shopify"
+	"github.com/softcery/shopify-app-template-go/internal/api/shopify/shopifytest"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/internal/storage/storagetest"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+	"github.com/softcery/shopify-app-template-go/pkg/queue"
+)
+
+const testStoreName = "flow-test.myshopify.com"
+
+// testQueue keeps enqueued jobs in memory, so the test runs them explicitly.
+type testQueue struct {
+	mu   sync.Mutex
+	jobs []*queue.Job
+}
+
+var _ service.JobQueue = (*testQueue)(nil)
+
+func (q *testQueue) Enqueue(ctx context.Context, kind string, payload interface{}, opts ...queue.EnqueueOption) error {
+	return q.EnqueueAt(ctx, kind, payload, time.Now(), opts...)
+}
+
+func (q *testQueue) EnqueueAt(ctx context.Context, kind string, payload interface{}, runAt time.Time, opts ...queue.EnqueueOption) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	job := &queue.Job{Kind: kind, Payload: string(encoded), RunAt: runAt}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.jobs = append(q.jobs, job)
+	return nil
+}
+
+func (q *testQueue) DeleteByKey(ctx context.Context, key string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := q.jobs[:0]
+	for _, job := range q.jobs {
+		if job.Key != key {
+			jobs = append(jobs, job)
+		}
+	}
+	q.jobs = jobs
+	return nil
+}
+
+// take removes enqueued jobs of the kind from the queue and returns them.
+func (q *testQueue) take(kind string) []*queue.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var taken []*queue.Job
+	jobs := q.jobs[:0]
+	for _, job := range q.jobs {
+		if job.Kind == kind {
+			taken = append(taken, job)
+		} else {
+			jobs = append(jobs, job)
+		}
+	}
+	q.jobs = jobs
+	return taken
+}
+
+// testApp is the app wired with in-memory storages and job queue, and pointed at fake Shopify server.
+type testApp struct {
+	*httptest.Server
+
+	config   *config.Config
+	fake     *shopifytest.Server
+	storages service.Storages
+	queue    *testQueue
+	handlers map[string]queue.Handler
+}
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	cfg.Shopify.ApiKey = "api-key"
+	cfg.Shopify.ApiSecret = "hush"
+	cfg.Shopify.Scopes = "read_products,write_products"
+	cfg.Shopify.APIVersion = "2024-04"
+	cfg.Shopify.HMACMaxAge = 90 * time.Second
+	cfg.Shopify.OAuthStateTTL = 10 * time.Minute
+	cfg.Shopify.RateLimitBucketSize = 40
+	cfg.Shopify.RateLimitLeakRate = 2
+	cfg.Shopify.RateLimitThreshold = 0.8
+	cfg.Shopify.BulkOperationPollInterval = time.Second
+	cfg.Shopify.BulkOperationProcessTimeout = time.Minute
+	cfg.Shopify.BulkOperationMaxAttempts = 3
+	cfg.Shopify.RequestTimeout = 10 * time.Second
+	cfg.Shopify.CreateProductsConcurrency = 2
+
+	fake := shopifytest.NewServer(shopifytest.Options{
+		APIKey:    cfg.Shopify.ApiKey,
+		APISecret: cfg.Shopify.ApiSecret,
+		Scopes:    cfg.Shopify.Scopes,
+	})
+	t.Cleanup(fake.Close)
+
+	// App's URL is known once its server is started, while routes depend on it
+	var httpHandler http.Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpHandler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	cfg.App.BaseURL = server.URL
+
+	logger := logging.NewZap("error")
+	storages := storagetest.New()
+	apis := service.APIs{
+		Platform: shopify.NewAPI(shopify.Options{
+			Config:  cfg,
+			Logger:  logger,
+			BaseURL: fake.BaseURL,
+		}),
+	}
+	jobQueue := &testQueue{}
+	services := newServices(cfg, logger, storages, apis, jobQueue)
+	httpHandler = newHTTPHandler(cfg, logger, services, storages)
+
+	return &testApp{
+		Server:   server,
+		config:   cfg,
+		fake:     fake,
+		storages: storages,
+		queue:    jobQueue,
+		handlers: newJobHandlers(services),
+	}
+}
+
+// runJobs runs enqueued jobs of the kind, including ones scheduled for later.
+func (a *testApp) runJobs(t *testing.T, kind string) {
+	t.Helper()
+
+	jobs := a.queue.take(kind)
+	if len(jobs) == 0 {
+		t.Fatalf("no %s jobs are enqueued", kind)
+	}
+	for _, job := range jobs {
+		err := a.handlers[kind](context.Background(), []byte(job.Payload))
+		if err != nil {
+			t.Fatalf("failed to run %s job: %v", kind, err)
+		}
+	}
+}
+
+// install opens the app from the store like store owner does and follows redirects through OAuth flow.
+// It returns the URL app finally redirects to.
+func (a *testApp) install(t *testing.T) string {
+	t.Helper()
+
+	appURL, _ := url.Parse(a.URL)
+	fakeURL, _ := url.Parse(a.fake.URL)
+	client := &http.Client{
+		// Redirects out of the app and the fake server are not followed, e.g. to the store's admin
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Host != appURL.Host && req.URL.Host != fakeURL.Host {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+
+	res, err := client.Get(a.fake.InstallURL(a.URL+"/", testStoreName))
+	if err != nil {
+		t.Fatalf("failed to install app: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("installation finished with status %d, want %d", res.StatusCode, http.StatusFound)
+	}
+
+	return res.Header.Get("Location")
+}
+
+// get requests the app's API like its UI embedded into the store does and decodes the response into v.
+func (a *testApp) get(t *testing.T, path string, v interface{}) {
+	t.Helper()
+
+	sessionToken, err := a.fake.SessionToken(testStoreName)
+	if err != nil {
+		t.Fatalf("failed to create session token: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, a.URL+path, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+sessionToken)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", path, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("%s responded with status %d, want %d", path, res.StatusCode, http.StatusOK)
+	}
+
+	err = json.NewDecoder(res.Body).Decode(v)
+	if err != nil {
+		t.Fatalf("failed to decode %s response: %v", path, err)
+	}
+}
+
+// getProductsCount requests number of products from the app's UI embedded into the store.
+func (a *testApp) getProductsCount(t *testing.T) int {
+	t.Helper()
+
+	var body struct {
+		Count int `json:"count"`
+	}
+	a.get(t, "/api/products/count", &body)
+	return body.Count
+}
+
+func TestInstallAndUninstall(t *testing.T) {
+	ctx := context.Background()
+	app := newTestApp(t)
+	app.fake.AddProduct(testStoreName, shopifytest.Product{Title: "Blue Shirt", Tags: []string{"shirts"}})
+	app.fake.AddProduct(testStoreName, shopifytest.Product{
+		Title: "Red Shirt",
+		Variants: []shopifytest.ProductVariant{
+			{Title: "S", SKU: "RED-S", Price: "10.00"},
+			{Title: "M", SKU: "RED-M", Price: "12.00"},
+		},
+	})
+
+	redirectURL := app.install(t)
+	wantRedirectURL := "https://" + testStoreName + "/admin/apps/" + app.config.Shopify.ApiKey
+	if redirectURL != wantRedirectURL {
+		t.Errorf("installation redirected to %q, want %q", redirectURL, wantRedirectURL)
+	}
+
+	store, err := app.storages.Store.Get(ctx, testStoreName)
+	if err != nil {
+		t.Fatalf("failed to get store: %v", err)
+	}
+	if store == nil || !store.Installed {
+		t.Fatalf("store is not installed: %+v", store)
+	}
+	if store.AccessToken != app.fake.AccessToken(testStoreName) {
+		t.Errorf("store's access token = %q, want the one issued by platform", store.AccessToken)
+	}
+	if len(app.fake.Webhooks(testStoreName)) == 0 {
+		t.Errorf("no webhooks are subscribed to on installation")
+	}
+
+	// Products are imported with bulk operation, which result is handled once it's polled
+	app.runJobs(t, service.JobKindImportProducts)
+	app.runJobs(t, service.JobKindPollBulkOperation)
+
+	count := app.getProductsCount(t)
+	if count != 2 {
+		t.Errorf("products count = %d, want 2", count)
+	}
+
+	err = app.fake.Uninstall(testStoreName)
+	if err != nil {
+		t.Fatalf("failed to uninstall app: %v", err)
+	}
+	app.runJobs(t, service.JobKindWebhook)
+
+	store, err = app.storages.Store.Get(ctx, testStoreName)
+	if err != nil {
+		t.Fatalf("failed to get store: %v", err)
+	}
+	if store != nil {
+		t.Errorf("store is not deleted on uninstallation: %+v", store)
+	}
+}
+
+func TestGetListedProduct(t *testing.T) {
+	app := newTestApp(t)
+	variants := make([]shopifytest.ProductVariant, 0, 60)
+	for i := 1; i <= cap(variants); i++ {
+		variants = append(variants, shopifytest.ProductVariant{Title: fmt.Sprintf("Size %d", i), Price: "10.00"})
+	}
+	app.fake.AddProduct(testStoreName, shopifytest.Product{Title: "Sized Shirt", Variants: variants})
+	app.install(t)
+
+	var page service.ProductsPage
+	app.get(t, "/api/products", &page)
+	if len(page.Products) != 1 {
+		t.Fatalf("listed %d products, want 1", len(page.Products))
+	}
+	if got := len(page.Products[0].Variants); got != len(variants) {
+		t.Errorf("listed product has %d variants, want %d", got, len(variants))
+	}
+
+	// Listed product's ID is passed back in the URL path
+	var product service.Product
+	app.get(t, "/api/products/"+page.Products[0].ID, &product)
+	if product.ID != page.Products[0].ID || product.Title != "Sized Shirt" {
+		t.Errorf("got product %q %q, want the listed one", product.ID, product.Title)
+	}
+	if len(product.Variants) != len(variants) {
+		t.Errorf("product has %d variants, want %d", len(product.Variants), len(variants))
+	}
+}
+
+func TestShopUpdateSkipsOutdatedPayload(t *testing.T) {
+	ctx := context.Background()
+	app := newTestApp(t)
+	app.install(t)
+
+	updatedAt := time.Now().UTC().Truncate(time.Second).Add(time.Minute)
+	err := app.fake.UpdateShop(testStoreName, shopifytest.Shop{PlanName: "basic", PlanDisplayName: "Basic", UpdatedAt: updatedAt})
+	if err != nil {
+		t.Fatalf("failed to update shop: %v", err)
+	}
+	// Delivery of the previous update is retried after the newer one
+	err = app.fake.SendWebhook(testStoreName, service.WebhookTopicShopUpdate, map[string]interface{}{
+		"plan_name":  "partner_test",
+		"updated_at": updatedAt.Add(-time.Second),
+	})
+	if err != nil {
+		t.Fatalf("failed to send outdated webhook: %v", err)
+	}
+	app.runJobs(t, service.JobKindWebhook)
+
+	store, err := app.storages.Store.Get(ctx, testStoreName)
+	if err != nil {
+		t.Fatalf("failed to get store: %v", err)
+	}
+	if store.PlanName != "basic" || store.ShopUpdatedAt == nil || !store.ShopUpdatedAt.Equal(updatedAt) {
+		t.Errorf("store's plan = %q updated at %v, want basic updated at %s", store.PlanName, store.ShopUpdatedAt, updatedAt)
+	}
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+// ReconcileWebhooks reconciles webhook subscriptions of all installed stores with the registered ones.
+func ReconcileWebhooks(cfg *config.Config) {
+	logger := logging.NewZap(cfg.Log.Level)
+
+	deps := newDependencies(cfg, logger)
+
+	// Interrupt cancels requests to platform
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := deps.services.Platform.ReconcileWebhooks(ctx)
+	if err != nil {
+		logger.Fatal("app - ReconcileWebhooks - failed to reconcile webhooks", "err", err)
+	}
+
+	logger.Info("app - ReconcileWebhooks - reconciled webhooks")
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+// runPeriodically calls task right away and then every interval until ctx is done.
+// Task failures are logged, they don't stop the schedule.
+func runPeriodically(ctx context.Context, interval time.Duration, logger logging.Logger, name string, task func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := task(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("app - runPeriodically - "+name, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+// SeedDeleteAll is a value of SeedOptions.Delete, which deletes data of all seed runs.
+const SeedDeleteAll = "all"
+
+type SeedOptions struct {
+	StoreName string
+	// File is a path to JSON or CSV file with products, embedded fixtures are used if it is empty.
+	File string
+	// Delete is an ID of the seed run to delete data of, or SeedDeleteAll. Store is seeded if it is empty.
+	Delete string
+	// Force allows seeding stores, which aren't development ones.
+	Force bool
+}
+
+// Seed seeds store with products and collections or deletes the seeded ones.
+func Seed(cfg *config.Config, opts SeedOptions) {
+	logger := logging.NewZap(cfg.Log.Level).With("storeName", opts.StoreName)
+
+	deps := newDependencies(cfg, logger)
+
+	// Interrupt cancels requests to platform
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if opts.Delete != "" {
+		runID := opts.Delete
+		if runID == SeedDeleteAll {
+			runID = ""
+		}
+
+		result, err := deps.services.Seed.DeleteSeeded(ctx, service.DeleteSeededOptions{
+			StoreName: opts.StoreName,
+			RunID:     runID,
+		})
+		if err != nil {
+			logger.Fatal("app - Seed - failed to delete seeded data", "err", err, "result", result)
+		}
+
+		logger.Info("app - Seed - deleted seeded data",
+			"products", result.DeletedProducts,
+			"collections", result.DeletedCollections,
+		)
+		return
+	}
+
+	var products []service.SeedProduct
+	if opts.File != "" {
+		file, err := os.Open(opts.File)
+		if err != nil {
+			logger.Fatal("app - Seed - failed to open products file", "err", err)
+		}
+		defer file.Close()
+
+		format := strings.TrimPrefix(strings.ToLower(filepath.Ext(opts.File)), ".")
+		products, err = service.ParseSeedProducts(file, format)
+		if err != nil {
+			logger.Fatal("app - Seed - failed to parse products file", "err", err)
+		}
+	}
+
+	result, err := deps.services.Seed.Seed(ctx, service.SeedOptions{
+		StoreName: opts.StoreName,
+		Products:  products,
+		Force:     opts.Force,
+	})
+	if err != nil {
+		logger.Fatal("app - Seed - failed to seed store", "err", err, "result", result)
+	}
+
+	failed := 0
+	for _, product := range result.Products {
+		if product.Err != nil {
+			failed++
+			logger.Info("app - Seed - failed to create product", "index", product.Index, "id", product.ID, "err", product.Err)
+		}
+	}
+	logger.Info("app - Seed - seeded store, delete seeded data with -delete "+result.RunID,
+		"runID", result.RunID,
+		"products", len(result.Products)-failed,
+		"failedProducts", failed,
+		"collections", len(result.CollectionIDs),
+	)
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+// SyncShops updates metadata (e.g. plan, currency and timezone) of all installed stores from platform.
+func SyncShops(cfg *config.Config) {
+	logger := logging.NewZap(cfg.Log.Level)
+
+	deps := newDependencies(cfg, logger)
+
+	// Interrupt cancels requests to platform
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := deps.services.Platform.SyncShops(ctx)
+	if err != nil {
+		logger.Fatal("app - SyncShops - failed to sync shops", "err", err)
+	}
+
+	logger.Info("app - SyncShops - synced shops")
+}
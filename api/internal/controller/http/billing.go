@@ -0,0 +1,163 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/errs"
+)
+
+type billingRoutes struct {
+	RouterContext
+}
+
+func newBillingRoutes(options RouterOptions) {
+	r := &billingRoutes{RouterContext{
+		services: options.Services,
+		storages: options.Storages,
+		logger:   options.Logger.Named("billingRoutes"),
+		cfg:      options.Config,
+	}}
+
+	options.Handler.GET("/billing/callback", wrapHandler(options, r.callbackHandler))
+
+	p := options.Handler.Group("/api/billing")
+	{
+		p.GET("", wrapHandler(options, r.getBilling))
+		p.POST("/subscribe", wrapHandler(options, r.subscribe))
+	}
+}
+
+type billingCallbackRequestQuery struct {
+	StoreName string `form:"shop" binding:"required"`
+	ChargeID  string `form:"charge_id" binding:"required"`
+}
+
+func (r *billingRoutes) callbackHandler(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("callbackHandler").WithContext(c)
+
+	var requestQuery billingCallbackRequestQuery
+	err := c.ShouldBindQuery(&requestQuery)
+	if err != nil {
+		logger.Info("failed to parse request query", "err", err)
+		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request query", Details: err}
+	}
+	logger = logger.With("requestQuery", requestQuery)
+
+	redirectURL, err := r.services.Billing.HandleCallback(c, service.HandleBillingCallbackOptions{
+		StoreName: requestQuery.StoreName,
+		ChargeID:  requestQuery.ChargeID,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
+		}
+		logger.Error("failed to handle billing callback", "err", err)
+		return nil, &httpErr{
+			Type:    ErrorTypeServer,
+			Message: "failed to handle billing callback",
+			Details: err,
+		}
+	}
+	// After subscription is approved, redirect user to app's UI at their platform store
+	c.Redirect(http.StatusFound, redirectURL)
+
+	logger.Info("successfully handled billing callback")
+	return nil, nil
+}
+
+func (r *billingRoutes) getBilling(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("getBilling").WithContext(c)
+
+	billing, err := r.services.Billing.GetBilling(c)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
+		}
+		logger.Error("failed to get billing", "err", err)
+		return nil, &httpErr{
+			Type:    ErrorTypeServer,
+			Message: "failed to get billing",
+			Details: err,
+		}
+	}
+
+	logger.Info("successfully got billing")
+	return billing, nil
+}
+
+type subscribeRequestBody struct {
+	Plan string `json:"plan" binding:"required"`
+}
+
+type subscribeResponse struct {
+	ConfirmationURL string `json:"confirmationUrl"`
+}
+
+func (r *billingRoutes) subscribe(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("subscribe").WithContext(c)
+
+	var requestBody subscribeRequestBody
+	err := c.ShouldBindJSON(&requestBody)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("requestBody", requestBody)
+
+	confirmationURL, err := r.services.Billing.Subscribe(c, requestBody.Plan)
+	if err != nil {
+		if userErrors, ok := err.(service.PlatformUserErrors); ok {
+			logger.Info("subscription is invalid", "err", err)
+			return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid subscription", Details: userErrors}
+		}
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
+		}
+		logger.Error("failed to subscribe", "err", err)
+		return nil, &httpErr{
+			Type:    ErrorTypeServer,
+			Message: "failed to subscribe",
+			Details: err,
+		}
+	}
+
+	logger.Info("successfully subscribed")
+	return subscribeResponse{ConfirmationURL: confirmationURL}, nil
+}
+
+// newBillingMiddleware is used to reject calls of app's API from stores without active subscription.
+// It is attached to the groups of gated routes, billing API itself stays available, so store can subscribe.
+func newBillingMiddleware(options RouterOptions) gin.HandlerFunc {
+	logger := options.Logger.Named("billingMiddleware")
+
+	return func(c *gin.Context) {
+		logger := logger.WithContext(c)
+
+		c.Set("Authorization", c.Request.Header.Get("Authorization"))
+
+		err := options.Services.Billing.CheckSubscription(c)
+		if err != nil {
+			if errors.Is(err, service.ErrSubscriptionRequired) {
+				logger.Info(err.Error())
+				c.AbortWithStatusJSON(http.StatusPaymentRequired, &httpErr{Type: ErrorTypeClient, Message: err.Error()})
+				return
+			}
+			if errs.IsExpected(err) {
+				logger.Info(err.Error())
+				c.AbortWithStatusJSON(http.StatusUnauthorized, &httpErr{Type: ErrorTypeClient, Message: err.Error()})
+				return
+			}
+			logger.Error("failed to check subscription", "err", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, &httpErr{Type: ErrorTypeServer, Message: "failed to check subscription"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -58,6 +58,10 @@ func New(options *Options) {
 	// Routers
 	{
 		newPlatformRoutes(routerOptions)
+		newWebhookRoutes(routerOptions)
+		newProductRoutes(routerOptions)
+		newOrderRoutes(routerOptions)
+		newBillingRoutes(routerOptions)
 	}
 }
 
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/errs"
+)
+
+type orderRoutes struct {
+	RouterContext
+}
+
+func newOrderRoutes(options RouterOptions) {
+	r := &orderRoutes{RouterContext{
+		services: options.Services,
+		storages: options.Storages,
+		logger:   options.Logger.Named("orderRoutes"),
+		cfg:      options.Config,
+	}}
+
+	p := options.Handler.Group("/api/orders", newBillingMiddleware(options))
+	{
+		p.GET("", wrapHandler(options, r.listOrders))
+		p.GET("/:id", wrapHandler(options, r.getOrder))
+	}
+}
+
+type listOrdersRequestQuery struct {
+	FinancialStatus   string     `form:"financialStatus"`
+	FulfillmentStatus string     `form:"fulfillmentStatus"`
+	CreatedAtMin      *time.Time `form:"createdAtMin" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedAtMax      *time.Time `form:"createdAtMax" time_format:"2006-01-02T15:04:05Z07:00"`
+	Query             string     `form:"query"`
+	Limit             int        `form:"limit,default=50" binding:"min=1,max=250"`
+	Offset            int        `form:"offset" binding:"min=0"`
+}
+
+func (r *orderRoutes) listOrders(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("listOrders").WithContext(c)
+
+	var requestQuery listOrdersRequestQuery
+	err := c.ShouldBindQuery(&requestQuery)
+	if err != nil {
+		logger.Info("failed to parse request query", "err", err)
+		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request query", Details: err}
+	}
+	logger = logger.With("requestQuery", requestQuery)
+
+	page, err := r.services.Order.ListOrders(c, service.ListOrdersOptions{
+		FinancialStatus:   requestQuery.FinancialStatus,
+		FulfillmentStatus: requestQuery.FulfillmentStatus,
+		CreatedAtMin:      requestQuery.CreatedAtMin,
+		CreatedAtMax:      requestQuery.CreatedAtMax,
+		Query:             requestQuery.Query,
+		Limit:             requestQuery.Limit,
+		Offset:            requestQuery.Offset,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
+		}
+		logger.Error("failed to list orders", "err", err)
+		return nil, &httpErr{
+			Type:    ErrorTypeServer,
+			Message: "failed to list orders",
+			Details: err,
+		}
+	}
+
+	logger.Info("successfully listed orders")
+	return page, nil
+}
+
+type orderRequestURI struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+func (r *orderRoutes) getOrder(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("getOrder").WithContext(c)
+
+	var requestURI orderRequestURI
+	err := c.ShouldBindUri(&requestURI)
+	if err != nil {
+		logger.Info("failed to parse request uri", "err", err)
+		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request uri", Details: err}
+	}
+	logger = logger.With("requestURI", requestURI)
+
+	order, err := r.services.Order.GetOrder(c, requestURI.ID)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
+		}
+		logger.Error("failed to get order", "err", err)
+		return nil, &httpErr{
+			Type:    ErrorTypeServer,
+			Message: "failed to get order",
+			Details: err,
+		}
+	}
+
+	logger.Info("successfully got order")
+	return order, nil
+}
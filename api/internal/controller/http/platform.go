@@ -1,7 +1,8 @@
 package http
 
 import (
-	"fmt"
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -25,9 +26,12 @@ func newPlatformRoutes(options RouterOptions) {
 	{
 		p.GET("", wrapHandler(options, r.handler))
 		p.GET("/auth/callback", wrapHandler(options, r.redirectHandler))
-		p.POST("/uninstall", wrapHandler(options, r.uninstallHandler))
-		p.GET("/api/products/count", wrapHandler(options, r.getProductsCount))
-		p.GET("/api/products/create", wrapHandler(options, r.createProducts))
+	}
+
+	products := options.Handler.Group("/api/products", newBillingMiddleware(options))
+	{
+		products.GET("/count", wrapHandler(options, r.getProductsCount))
+		products.POST("/create", wrapHandler(options, r.createProducts))
 	}
 }
 
@@ -69,6 +73,7 @@ func (r *platformRoutes) handler(c *gin.Context) (interface{}, *httpErr) {
 
 type redirectHandlerRequestQuery struct {
 	StoreName string `form:"shop" binding:"required"`
+	State     string `form:"state" binding:"required"`
 }
 
 func (r *platformRoutes) redirectHandler(c *gin.Context) (interface{}, *httpErr) {
@@ -82,8 +87,9 @@ func (r *platformRoutes) redirectHandler(c *gin.Context) (interface{}, *httpErr)
 	}
 	logger = logger.With("requestQuery", requestQuery)
 
-	err = r.services.Platform.HandleRedirect(c, service.ServiceHandleRedirectOptions{
+	redirectURL, err := r.services.Platform.HandleRedirect(c, service.ServiceHandleRedirectOptions{
 		StoreName:     requestQuery.StoreName,
+		State:         requestQuery.State,
 		RedirectedURL: c.Request.URL.String(),
 	})
 	if err != nil {
@@ -99,47 +105,12 @@ func (r *platformRoutes) redirectHandler(c *gin.Context) (interface{}, *httpErr)
 		}
 	}
 	// After successful handling of redirect call, redirect user to app's UI at their platform store
-	c.Redirect(http.StatusFound, fmt.Sprintf("https://%s/admin/apps/%s", requestQuery.StoreName, r.cfg.Shopify.ApiKey))
+	c.Redirect(http.StatusFound, redirectURL)
 
 	logger.Info("successfully handled redirect call")
 	return nil, nil
 }
 
-type uninstallHandlerRequestQuery struct {
-	StoreName string `form:"shop" binding:"required"`
-}
-
-func (r *platformRoutes) uninstallHandler(c *gin.Context) (interface{}, *httpErr) {
-	logger := r.logger.
-		Named("uninstallHandler").
-		WithContext(c)
-
-	var requestQuery uninstallHandlerRequestQuery
-	err := c.ShouldBindQuery(&requestQuery)
-	if err != nil {
-		logger.Info("failed to parse request query", "err", err)
-		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request query", Details: err}
-	}
-	logger = logger.With("requestQuery", requestQuery)
-
-	err = r.services.Platform.HandleUninstall(c, requestQuery.StoreName)
-	if err != nil {
-		if errs.IsExpected(err) {
-			logger.Info(err.Error())
-			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
-		}
-		logger.Error("failed to uninstall app", "err", err)
-		return nil, &httpErr{
-			Type:    ErrorTypeServer,
-			Message: "failed to failed to uninstall app",
-			Details: err,
-		}
-	}
-
-	logger.Info("successfully uninstalled the application")
-	return nil, nil
-}
-
 type getProductsCountResponse struct {
 	Count int `json:"count"`
 }
@@ -165,14 +136,48 @@ func (r *platformRoutes) getProductsCount(c *gin.Context) (interface{}, *httpErr
 	return getProductsCountResponse{Count: count}, nil
 }
 
+type createProductsRequestBody struct {
+	Count     int                       `json:"count"`
+	Templates []service.ProductTemplate `json:"templates"`
+}
+
+type createProductResult struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+	// UserErrors are set if product is rejected by platform as invalid.
+	UserErrors service.PlatformUserErrors `json:"userErrors,omitempty"`
+}
+
+type createProductsResponse struct {
+	Created int                   `json:"created"`
+	Failed  int                   `json:"failed"`
+	Results []createProductResult `json:"results"`
+}
+
 func (r *platformRoutes) createProducts(c *gin.Context) (interface{}, *httpErr) {
-	logger := r.logger.Named("createProducts")
+	logger := r.logger.Named("createProducts").WithContext(c)
 
 	c.Set("Authorization", c.Request.Header.Get("Authorization"))
 
-	err := r.services.Platform.CreateProducts(c)
+	// Body is optional, default number of random products is created without it
+	var requestBody createProductsRequestBody
+	err := c.ShouldBindJSON(&requestBody)
+	if err != nil && !errors.Is(err, io.EOF) {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("requestBody", requestBody)
+
+	results, err := r.services.Platform.CreateProducts(c, service.CreateProductsOptions{
+		Count:     requestBody.Count,
+		Templates: requestBody.Templates,
+	})
 	if err != nil {
-		// TODO: return custom errors to client, instead of 500
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
+		}
 		logger.Error("failed to create products", "err", err)
 		return nil, &httpErr{
 			Type:    ErrorTypeServer,
@@ -181,6 +186,21 @@ func (r *platformRoutes) createProducts(c *gin.Context) (interface{}, *httpErr)
 		}
 	}
 
-	logger.Info("successfully create products")
-	return "", nil
+	response := createProductsResponse{Results: make([]createProductResult, 0, len(results))}
+	for _, result := range results {
+		item := createProductResult{Index: result.Index, ID: result.ID}
+		if result.Err != nil {
+			response.Failed++
+			item.Error = result.Err.Error()
+			if userErrors, ok := result.Err.(service.PlatformUserErrors); ok {
+				item.UserErrors = userErrors
+			}
+		} else {
+			response.Created++
+		}
+		response.Results = append(response.Results, item)
+	}
+
+	logger.Info("created products", "created", response.Created, "failed", response.Failed)
+	return response, nil
 }
@@ -0,0 +1,228 @@
+package http
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/errs"
+)
+
+type productRoutes struct {
+	RouterContext
+}
+
+func newProductRoutes(options RouterOptions) {
+	r := &productRoutes{RouterContext{
+		services: options.Services,
+		storages: options.Storages,
+		logger:   options.Logger.Named("productRoutes"),
+		cfg:      options.Config,
+	}}
+
+	p := options.Handler.Group("/api/products", newBillingMiddleware(options))
+	{
+		p.GET("", wrapHandler(options, r.listProducts))
+		p.GET("/:id", wrapHandler(options, r.getProduct))
+		p.PUT("/:id", wrapHandler(options, r.updateProduct))
+		p.DELETE("/:id", wrapHandler(options, r.deleteProduct))
+	}
+}
+
+type listProductsRequestQuery struct {
+	Title        string     `form:"title"`
+	Status       string     `form:"status" binding:"omitempty,oneof=ACTIVE DRAFT ARCHIVED"`
+	Vendor       string     `form:"vendor"`
+	ProductType  string     `form:"productType"`
+	Tag          string     `form:"tag"`
+	UpdatedAtMin *time.Time `form:"updatedAtMin" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit        int        `form:"limit" binding:"omitempty,min=1,max=15"`
+	Cursor       string     `form:"cursor"`
+}
+
+func (r *productRoutes) listProducts(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("listProducts").WithContext(c)
+
+	var requestQuery listProductsRequestQuery
+	err := c.ShouldBindQuery(&requestQuery)
+	if err != nil {
+		logger.Info("failed to parse request query", "err", err)
+		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request query", Details: err}
+	}
+	logger = logger.With("requestQuery", requestQuery)
+
+	page, err := r.services.Platform.ListProducts(c, service.ListProductsOptions{
+		Filter: service.ProductFilter{
+			Title:        requestQuery.Title,
+			Status:       requestQuery.Status,
+			Vendor:       requestQuery.Vendor,
+			ProductType:  requestQuery.ProductType,
+			Tag:          requestQuery.Tag,
+			UpdatedAtMin: requestQuery.UpdatedAtMin,
+		},
+		Limit:  requestQuery.Limit,
+		Cursor: requestQuery.Cursor,
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
+		}
+		logger.Error("failed to list products", "err", err)
+		return nil, &httpErr{
+			Type:    ErrorTypeServer,
+			Message: "failed to list products",
+			Details: err,
+		}
+	}
+
+	logger.Info("successfully listed products")
+	return page, nil
+}
+
+type productRequestURI struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+func (r *productRoutes) getProduct(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("getProduct").WithContext(c)
+
+	var requestURI productRequestURI
+	err := c.ShouldBindUri(&requestURI)
+	if err != nil {
+		logger.Info("failed to parse request uri", "err", err)
+		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request uri", Details: err}
+	}
+	logger = logger.With("requestURI", requestURI)
+
+	product, err := r.services.Platform.GetProduct(c, requestURI.ID)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
+		}
+		logger.Error("failed to get product", "err", err)
+		return nil, &httpErr{
+			Type:    ErrorTypeServer,
+			Message: "failed to get product",
+			Details: err,
+		}
+	}
+
+	logger.Info("successfully got product")
+	return product, nil
+}
+
+type updateProductRequestBody struct {
+	Title           *string  `json:"title"`
+	DescriptionHTML *string  `json:"descriptionHtml"`
+	Vendor          *string  `json:"vendor"`
+	ProductType     *string  `json:"productType"`
+	Tags            []string `json:"tags"`
+	Status          *string  `json:"status" binding:"omitempty,oneof=ACTIVE DRAFT ARCHIVED"`
+	Variants        []struct {
+		ID             string  `json:"id" binding:"required"`
+		Price          *string `json:"price"`
+		CompareAtPrice *string `json:"compareAtPrice"`
+		SKU            *string `json:"sku"`
+		Barcode        *string `json:"barcode"`
+	} `json:"variants" binding:"dive"`
+	Images []struct {
+		URL     string `json:"url" binding:"required,url"`
+		AltText string `json:"altText"`
+	} `json:"images" binding:"dive"`
+}
+
+func (r *productRoutes) updateProduct(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("updateProduct").WithContext(c)
+
+	var requestURI productRequestURI
+	err := c.ShouldBindUri(&requestURI)
+	if err != nil {
+		logger.Info("failed to parse request uri", "err", err)
+		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request uri", Details: err}
+	}
+
+	var requestBody updateProductRequestBody
+	err = c.ShouldBindJSON(&requestBody)
+	if err != nil {
+		logger.Info("failed to parse request body", "err", err)
+		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request body", Details: err}
+	}
+	logger = logger.With("requestURI", requestURI).With("requestBody", requestBody)
+
+	opts := service.UpdateProductOptions{
+		ID:              requestURI.ID,
+		Title:           requestBody.Title,
+		DescriptionHTML: requestBody.DescriptionHTML,
+		Vendor:          requestBody.Vendor,
+		ProductType:     requestBody.ProductType,
+		Tags:            requestBody.Tags,
+		Status:          requestBody.Status,
+	}
+	for _, variant := range requestBody.Variants {
+		opts.Variants = append(opts.Variants, service.UpdateProductVariantOptions{
+			ID:             variant.ID,
+			Price:          variant.Price,
+			CompareAtPrice: variant.CompareAtPrice,
+			SKU:            variant.SKU,
+			Barcode:        variant.Barcode,
+		})
+	}
+	for _, image := range requestBody.Images {
+		opts.Images = append(opts.Images, service.CreateProductImageOptions{
+			URL:     image.URL,
+			AltText: image.AltText,
+		})
+	}
+
+	product, err := r.services.Platform.UpdateProduct(c, opts)
+	if err != nil {
+		if userErrors, ok := err.(service.PlatformUserErrors); ok {
+			logger.Info("product is invalid", "err", err)
+			return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid product", Details: userErrors}
+		}
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
+		}
+		logger.Error("failed to update product", "err", err)
+		return nil, &httpErr{
+			Type:    ErrorTypeServer,
+			Message: "failed to update product",
+			Details: err,
+		}
+	}
+
+	logger.Info("successfully updated product")
+	return product, nil
+}
+
+func (r *productRoutes) deleteProduct(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("deleteProduct").WithContext(c)
+
+	var requestURI productRequestURI
+	err := c.ShouldBindUri(&requestURI)
+	if err != nil {
+		logger.Info("failed to parse request uri", "err", err)
+		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request uri", Details: err}
+	}
+	logger = logger.With("requestURI", requestURI)
+
+	err = r.services.Platform.DeleteProduct(c, requestURI.ID)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
+		}
+		logger.Error("failed to delete product", "err", err)
+		return nil, &httpErr{
+			Type:    ErrorTypeServer,
+			Message: "failed to delete product",
+			Details: err,
+		}
+	}
+
+	logger.Info("successfully deleted product")
+	return "", nil
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/errs"
+)
+
+const (
+	// webhookShopKey is a gin context key of the store verified webhook is sent for.
+	webhookShopKey = "webhookShop"
+	// webhookBodyKey is a gin context key of the verified webhook's raw body.
+	webhookBodyKey = "webhookBody"
+)
+
+type webhookRoutes struct {
+	RouterContext
+}
+
+func newWebhookRoutes(options RouterOptions) {
+	r := &webhookRoutes{RouterContext{
+		services: options.Services,
+		storages: options.Storages,
+		logger:   options.Logger.Named("webhookRoutes"),
+		cfg:      options.Config,
+	}}
+
+	p := options.Handler.Group("/webhooks", newWebhookAuthMiddleware(options))
+	{
+		p.POST("", wrapHandler(options, r.webhookHandler))
+
+		// Compliance webhooks, configured in app's settings
+		p.POST("/customers/data_request", r.setTopic(service.WebhookTopicCustomersDataRequest), wrapHandler(options, r.webhookHandler))
+		p.POST("/customers/redact", r.setTopic(service.WebhookTopicCustomersRedact), wrapHandler(options, r.webhookHandler))
+		p.POST("/shop/redact", r.setTopic(service.WebhookTopicShopRedact), wrapHandler(options, r.webhookHandler))
+	}
+}
+
+// setTopic is used to handle webhooks of the given topic at their dedicated endpoint.
+func (r *webhookRoutes) setTopic(topic string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Header.Set("X-Shopify-Topic", topic)
+		c.Next()
+	}
+}
+
+type webhookHandlerRequestHeader struct {
+	Topic      string `header:"X-Shopify-Topic" binding:"required"`
+	WebhookID  string `header:"X-Shopify-Webhook-Id"`
+	APIVersion string `header:"X-Shopify-API-Version"`
+}
+
+func (r *webhookRoutes) webhookHandler(c *gin.Context) (interface{}, *httpErr) {
+	logger := r.logger.Named("webhookHandler").WithContext(c)
+
+	var requestHeader webhookHandlerRequestHeader
+	err := c.ShouldBindHeader(&requestHeader)
+	if err != nil {
+		logger.Info("failed to parse request header", "err", err)
+		return nil, &httpErr{Type: ErrorTypeClient, Message: "invalid request header", Details: err}
+	}
+	logger = logger.With("requestHeader", requestHeader)
+
+	// Webhook is handled asynchronously, so platform's delivery timeout is not exceeded
+	err = r.services.Webhook.EnqueueWebhook(c, service.WebhookEvent{
+		ID:         requestHeader.WebhookID,
+		Topic:      requestHeader.Topic,
+		Shop:       c.MustGet(webhookShopKey).(service.ShopDomain),
+		APIVersion: requestHeader.APIVersion,
+		Payload:    c.MustGet(webhookBodyKey).([]byte),
+	})
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, &httpErr{Type: ErrorTypeClient, Message: err.Error()}
+		}
+		logger.Error("failed to enqueue webhook", "err", err)
+		return nil, &httpErr{
+			Type:    ErrorTypeServer,
+			Message: "failed to enqueue webhook",
+			Details: err,
+		}
+	}
+
+	logger.Info("successfully enqueued webhook")
+	return nil, nil
+}
+
+// newWebhookAuthMiddleware is used to reject webhooks that are not signed by platform.
+func newWebhookAuthMiddleware(options RouterOptions) gin.HandlerFunc {
+	logger := options.Logger.Named("webhookAuthMiddleware")
+
+	return func(c *gin.Context) {
+		logger := logger.WithContext(c)
+
+		// Read raw body, since signature is calculated over the exact bytes sent by platform
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			logger.Info("failed to read request body", "err", err)
+			c.AbortWithStatusJSON(http.StatusBadRequest, &httpErr{Type: ErrorTypeClient, Message: "invalid request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		shop, err := options.Services.Platform.VerifyWebhook(c, service.ServiceVerifyWebhookOptions{
+			Body:      body,
+			HMAC:      c.GetHeader("X-Shopify-Hmac-Sha256"),
+			StoreName: c.GetHeader("X-Shopify-Shop-Domain"),
+		})
+		if err != nil {
+			if errs.IsExpected(err) {
+				logger.Info(err.Error())
+				c.AbortWithStatusJSON(http.StatusUnauthorized, &httpErr{Type: ErrorTypeClient, Message: err.Error()})
+				return
+			}
+			logger.Error("failed to verify webhook", "err", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, &httpErr{Type: ErrorTypeServer, Message: "failed to verify webhook"})
+			return
+		}
+		logger = logger.With("shop", shop)
+
+		// Store passed in query (if any) must be the one webhook is signed for
+		if storeName := c.Query("shop"); storeName != "" {
+			queryShop, err := service.ParseShopDomain(storeName, options.Config.Shopify.AllowedShopDomains)
+			if err != nil || queryShop != shop {
+				logger.Info("shop domain mismatch", "queryShop", storeName)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, &httpErr{Type: ErrorTypeClient, Message: "shop domain mismatch"})
+				return
+			}
+		}
+
+		c.Set(webhookShopKey, shop)
+		c.Set(webhookBodyKey, body)
+		logger.Debug("verified webhook")
+
+		c.Next()
+	}
+}
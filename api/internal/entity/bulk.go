@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+)
+
+// BulkOperation model represents bulk operation started by the app at platform store.
+type BulkOperation struct {
+	database.Model
+	// ID is platform's ID of the bulk operation.
+	ID        string `gorm:"primaryKey"`
+	StoreName string `gorm:"index"`
+	// Kind determines handler of the operation's result.
+	Kind        string `gorm:"index"`
+	Query       string
+	Status      string
+	ErrorCode   string
+	ObjectCount int64
+	// Attempt is a number of times the query has been run, failed operations are run again.
+	Attempt     int
+	CompletedAt *time.Time
+	// LockedUntil is set while the operation's result is being handled by a worker.
+	LockedUntil *time.Time
+	// ProcessedAt is set once the operation's result and its completion are handled.
+	ProcessedAt *time.Time `gorm:"index"`
+}
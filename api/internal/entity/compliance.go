@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+)
+
+// ComplianceRequest model represents privacy request (e.g. data export or erasure) received from platform.
+type ComplianceRequest struct {
+	database.Model
+	ID        string `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	StoreName string `gorm:"index"`
+	Topic     string `gorm:"index"`
+	WebhookID string `gorm:"index"`
+	// Payload is webhook's payload encrypted at rest, it is cleared once customer's erasure request is completed.
+	Payload     string `gorm:"type:text"`
+	CompletedAt *time.Time
+}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"github.com/softcery/shopify-app-template-go/pkg/database/datatypes"
+)
+
+// Customer model represents a local copy of platform store's customer.
+// Personal data (name, email, phone and address) is encrypted at rest by storage.
+type Customer struct {
+	database.Model
+	// ID is platform's global ID of the customer.
+	ID        string `gorm:"primaryKey"`
+	StoreName string `gorm:"index"`
+	FirstName string
+	LastName  string
+	Email     string
+	Phone     string
+	// Address is JSON encoded customer's default address.
+	Address string
+	State   string
+	Tags    datatypes.Slice[string] `gorm:"type:jsonb"`
+	// PlatformCreatedAt and PlatformUpdatedAt are customer's timestamps at platform.
+	PlatformCreatedAt time.Time
+	PlatformUpdatedAt time.Time
+}
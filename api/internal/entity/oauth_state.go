@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"time"
+)
+
+// OAuthState model represents a single-use state parameter of platform's OAuth flow.
+type OAuthState struct {
+	State     string `gorm:"primaryKey"`
+	StoreName string `gorm:"index"`
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"index"`
+	Consumed  bool
+}
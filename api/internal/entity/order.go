@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"github.com/softcery/shopify-app-template-go/pkg/database/datatypes"
+)
+
+// Order model represents a local copy of platform store's order.
+type Order struct {
+	database.Model
+	// ID is platform's global ID of the order.
+	ID        string `gorm:"primaryKey"`
+	StoreName string `gorm:"index"`
+	Name      string
+	// Email is encrypted at rest.
+	Email             string
+	Currency          string
+	FinancialStatus   string                            `gorm:"index"`
+	FulfillmentStatus string                            `gorm:"index"`
+	TotalPrice        string                            `gorm:"type:numeric"`
+	SubtotalPrice     string                            `gorm:"type:numeric"`
+	TotalTax          string                            `gorm:"type:numeric"`
+	LineItems         datatypes.Slice[OrderLineItem]    `gorm:"type:jsonb"`
+	Fulfillments      datatypes.Slice[OrderFulfillment] `gorm:"type:jsonb"`
+	// PlatformCreatedAt and PlatformUpdatedAt are order's timestamps at platform.
+	PlatformCreatedAt time.Time `gorm:"index"`
+	PlatformUpdatedAt time.Time `gorm:"index"`
+	ProcessedAt       *time.Time
+	CancelledAt       *time.Time
+}
+
+// OrderLineItem represents a purchased product of the order.
+type OrderLineItem struct {
+	ID           string `json:"id"`
+	ProductID    string `json:"productId"`
+	VariantID    string `json:"variantId"`
+	Title        string `json:"title"`
+	VariantTitle string `json:"variantTitle"`
+	SKU          string `json:"sku"`
+	Quantity     int    `json:"quantity"`
+	Price        string `json:"price"`
+}
+
+// OrderFulfillment represents a shipment of the order's line items.
+type OrderFulfillment struct {
+	ID              string    `json:"id"`
+	Status          string    `json:"status"`
+	TrackingCompany string    `json:"trackingCompany"`
+	TrackingNumbers []string  `json:"trackingNumbers"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
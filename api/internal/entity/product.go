@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"github.com/softcery/shopify-app-template-go/pkg/database/datatypes"
+)
+
+// Product model represents a local copy of platform store's product.
+type Product struct {
+	database.Model
+	// ID is platform's global ID of the product.
+	ID          string `gorm:"primaryKey"`
+	StoreName   string `gorm:"index"`
+	Title       string
+	Description string
+	Handle      string
+	Status      string                  `gorm:"index"`
+	Vendor      string                  `gorm:"index"`
+	ProductType string                  `gorm:"index"`
+	Tags        datatypes.Slice[string] `gorm:"type:jsonb"`
+	// PlatformCreatedAt and PlatformUpdatedAt are product's timestamps at platform.
+	PlatformCreatedAt time.Time
+	PlatformUpdatedAt time.Time
+}
+
+// ProductVariant model represents a local copy of product's variant.
+type ProductVariant struct {
+	database.Model
+	// ID is platform's global ID of the variant.
+	ID                string `gorm:"primaryKey"`
+	ProductID         string `gorm:"index"`
+	StoreName         string `gorm:"index"`
+	Title             string
+	SKU               string `gorm:"index"`
+	Barcode           string
+	Price             string  `gorm:"type:numeric"`
+	CompareAtPrice    *string `gorm:"type:numeric"`
+	InventoryQuantity int
+}
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"github.com/softcery/shopify-app-template-go/pkg/database/datatypes"
+)
+
+// SeedRun model represents a single run of seed data generation at platform store.
+// Seeded products are found by the run's tag and collections by their handles prefixed with it,
+// so the run's data is found at platform even if the run failed to be recorded.
+type SeedRun struct {
+	database.Model
+	// ID is a run ID, which seeded data is tagged with.
+	ID            string `gorm:"primaryKey"`
+	StoreName     string `gorm:"index"`
+	ProductsCount int
+	// CollectionIDs are IDs of the created collections.
+	CollectionIDs datatypes.Slice[string] `gorm:"type:jsonb"`
+}
@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"time"
+
 	"github.com/softcery/shopify-app-template-go/pkg/database"
 )
 
@@ -11,9 +13,31 @@ type Store struct {
 	Name string `gorm:"index"`
 
 	// Shopify
-	Nonce       string
 	AccessToken string
 	Installed   bool
+	// OrdersSyncedAt is the time store's orders were last synced at.
+	OrdersSyncedAt *time.Time
+
+	// Shop metadata
+	ShopID   int64
+	Email    string
+	Currency string
+	// Timezone is an IANA timezone of the shop, e.g. "America/New_York".
+	Timezone        string
+	PlanName        string
+	PlanDisplayName string
+	PrimaryDomain   string
+	CountryCode     string
+	MyshopifyDomain string
+	// ShopUpdatedAt is the time shop's metadata was updated at platform, older metadata is skipped.
+	ShopUpdatedAt *time.Time
+
+	// Billing
+	SubscriptionID     string
+	SubscriptionPlan   string
+	SubscriptionStatus string
+	// UsageLineItemID is an ID of subscription's line item usage charges are created for.
+	UsageLineItemID string
 }
 
 type Session struct {
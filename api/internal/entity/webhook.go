@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+)
+
+// ProcessedWebhook model represents progress of webhook delivery handling.
+type ProcessedWebhook struct {
+	WebhookID string `gorm:"primaryKey"`
+	Topic     string
+	StoreName string `gorm:"index"`
+	// HandledCount is a number of topic's handlers that have succeeded, so retries run the rest of them only.
+	HandledCount int
+	// ProcessedAt is set once every handler has succeeded, duplicate deliveries are skipped afterwards.
+	ProcessedAt *time.Time `gorm:"index"`
+	UpdatedAt   time.Time
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/softcery/shopify-app-template-go/internal/entity"
 	"github.com/softcery/shopify-app-template-go/pkg/errs"
@@ -15,20 +17,73 @@ type APIs struct {
 // PlatformAPI is used to communicate with shop platform.
 type PlatformAPI interface {
 	// HandleInstall verifies installation URL and returns url to redirect user to.
-	HandleInstall(opts HandleInstallOptions) (APIHandleInstallOutput, error)
+	HandleInstall(ctx context.Context, opts HandleInstallOptions) (APIHandleInstallOutput, error)
 	// HandleRedirect verifies redirected URL and requests access token from shop platform
 	// and then returns the access token.
-	HandleRedirect(opts APIHandleRedirectOptions) (string, error)
-	// SubscribeToAppUninstallWebhook subscribes application to platform's webhook.
-	SubscribeToAppUninstallWebhook(opts SubscribeToAppUninstallWebhookOptions) error
+	HandleRedirect(ctx context.Context, opts APIHandleRedirectOptions) (string, error)
+	// ReconcileWebhooks creates, updates and deletes store's webhook subscriptions,
+	// so they match the desired ones.
+	ReconcileWebhooks(ctx context.Context, opts ReconcileWebhooksOptions) (ReconcileWebhooksOutput, error)
+	// VerifyRequestURL verifies hmac signature and freshness of the URL signed by shop platform.
+	VerifyRequestURL(ctx context.Context, requestURL string) error
+	// VerifyWebhook verifies hmac signature of the webhook sent by shop platform.
+	VerifyWebhook(ctx context.Context, opts VerifyWebhookOptions) error
 	// VerifySession verifies session and returns true if session is valid.
 	VerifySession(ctx context.Context) (*VerifySessionOutput, error)
 	// WithConfig returns a new instance of PlatformAPI with provided store config.
 	WithConfig(ctx context.Context, store *entity.Store) PlatformAPI
-	// CreateProducts creates random products in shopify store.
-	CreateProducts(ctx context.Context) error
+	// CreateProducts creates products with bounded concurrency and returns result of every product.
+	// Failure of a single product doesn't stop creation of the others.
+	CreateProducts(ctx context.Context, opts CreateProductsOptions) []CreateProductResult
 	// GetProductsCount returns number of products in store.
 	GetProductsCount(ctx context.Context) (int, error)
+	// GetShop returns store's metadata.
+	GetShop(ctx context.Context) (*Shop, error)
+	// IterateProducts returns iterator over store's products, which are fetched page by page.
+	IterateProducts(ctx context.Context, opts IterateProductsOptions) Iterator[Product]
+	// ListProducts returns a single page of store's products.
+	ListProducts(ctx context.Context, opts ListProductsOptions) (*ProductsPage, error)
+	// GetProduct returns product by its ID or nil if it is not found.
+	GetProduct(ctx context.Context, id string) (*Product, error)
+	// UpdateProduct updates product and returns the updated one or nil if it is not found.
+	UpdateProduct(ctx context.Context, opts UpdateProductOptions) (*Product, error)
+	// DeleteProduct deletes product and returns false if it is not found.
+	DeleteProduct(ctx context.Context, id string) (bool, error)
+	// CreateCollection creates custom collection with the products and returns its ID.
+	CreateCollection(ctx context.Context, opts CreateCollectionOptions) (string, error)
+	// DeleteCollection deletes collection and returns false if it is not found.
+	DeleteCollection(ctx context.Context, id string) (bool, error)
+	// IterateCollections returns iterator over store's custom collections, which are fetched page by page.
+	IterateCollections(ctx context.Context) Iterator[Collection]
+	// IterateOrders returns iterator over store's orders, which are fetched page by page.
+	IterateOrders(ctx context.Context, opts IterateOrdersOptions) Iterator[Order]
+	// GetOrder returns order by its ID or nil if it is not found.
+	GetOrder(ctx context.Context, id string) (*Order, error)
+	// IterateCustomers returns iterator over store's customers, which are fetched page by page.
+	IterateCustomers(ctx context.Context, opts IterateCustomersOptions) Iterator[Customer]
+	// CreateAppSubscription creates app subscription, which store owner has to approve at the confirmation URL.
+	CreateAppSubscription(ctx context.Context, opts CreateAppSubscriptionOptions) (*CreatedAppSubscription, error)
+	// GetAppSubscription returns app subscription by its ID or nil if it is not found.
+	GetAppSubscription(ctx context.Context, id string) (*AppSubscription, error)
+	// CreateUsageRecord charges store for app usage and returns ID of the usage record.
+	CreateUsageRecord(ctx context.Context, opts CreateUsageRecordOptions) (string, error)
+	// RunBulkQuery starts bulk operation executing the query.
+	RunBulkQuery(ctx context.Context, query string) (*BulkOperation, error)
+	// GetBulkOperation returns bulk operation by its ID or nil if it is not found.
+	GetBulkOperation(ctx context.Context, id string) (*BulkOperation, error)
+	// DownloadBulkOperationResult returns iterator streaming records of bulk operation's result file.
+	DownloadBulkOperationResult(ctx context.Context, url string) (BulkRecordIterator, error)
+}
+
+// Iterator iterates over items fetched from platform page by page.
+type Iterator[T any] interface {
+	// Next advances iterator to the next item, fetching the next page if needed.
+	// It returns false when there are no more items, an error occurred or ctx is cancelled.
+	Next(ctx context.Context) bool
+	// Item returns the current item.
+	Item() T
+	// Err returns an error occurred during iteration.
+	Err() error
 }
 
 var (
@@ -36,8 +91,40 @@ var (
 	ErrHandleRedirectInvalidRedirectedURL = errs.New("invalid redirected url")
 	// ErrHandleRedirectInvalidScopes is returned when user didn't allow all the requested scopes when installing app.
 	ErrHandleRedirectInvalidScopes = errs.New("allowed access scopes are different from requested")
+	// ErrVerifyRequestURLInvalidHMAC is returned when request's hmac signature is missing or invalid.
+	ErrVerifyRequestURLInvalidHMAC = errs.New("invalid hmac signature")
+	// ErrVerifyRequestURLExpired is returned when request's timestamp is missing or too old.
+	ErrVerifyRequestURLExpired = errs.New("request has expired")
+	// ErrVerifyWebhookInvalidHMAC is returned when webhook's hmac signature is missing or invalid.
+	ErrVerifyWebhookInvalidHMAC = errs.New("invalid webhook hmac signature")
 )
 
+// PlatformUserError is a validation error of the input sent to platform.
+type PlatformUserError struct {
+	Field   []string
+	Message string
+}
+
+// PlatformUserErrors is returned when platform rejects the input due to validation errors.
+type PlatformUserErrors []PlatformUserError
+
+func (e PlatformUserErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, userError := range e {
+		if len(userError.Field) == 0 {
+			messages = append(messages, userError.Message)
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("%s: %s", strings.Join(userError.Field, "."), userError.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
+type VerifyWebhookOptions struct {
+	Body []byte
+	HMAC string
+}
+
 type VerifySessionOutput struct {
 	StoreName  string
 	IsVerified bool
@@ -60,8 +147,21 @@ type APIHandleRedirectOptions struct {
 	StoreName     string
 }
 
-type SubscribeToAppUninstallWebhookOptions struct {
-	RedirectURL string
-	StoreName   string
-	AccessToken string
+type WebhookSubscription struct {
+	Topic   string
+	Address string
+	Format  string
+	Fields  []string
+}
+
+type ReconcileWebhooksOptions struct {
+	Subscriptions []WebhookSubscription
+	StoreName     string
+	AccessToken   string
+}
+
+type ReconcileWebhooksOutput struct {
+	Created []string
+	Updated []string
+	Deleted []string
 }
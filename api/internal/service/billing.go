@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/pkg/errs"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+const (
+	// AppSubscriptionStatusActive is a status of the approved app subscription.
+	AppSubscriptionStatusActive = "ACTIVE"
+
+	// WebhookTopicAppSubscriptionsUpdate is sent when app subscription's status or capped amount changes.
+	WebhookTopicAppSubscriptionsUpdate = "app_subscriptions/update"
+)
+
+var (
+	// ErrBillingStoreNotFound is returned when store is not found.
+	ErrBillingStoreNotFound = errs.New("store is not found")
+	// ErrBillingPlanNotFound is returned when plan is not configured.
+	ErrBillingPlanNotFound = errs.New("plan is not found")
+	// ErrSubscriptionNotFound is returned when app subscription is not found.
+	ErrSubscriptionNotFound = errs.New("subscription is not found")
+	// ErrSubscriptionNotActive is returned when app subscription is declined or expired.
+	ErrSubscriptionNotActive = errs.New("subscription is not active")
+	// ErrSubscriptionRequired is returned when store has no active subscription.
+	ErrSubscriptionRequired = errs.New("active subscription is required")
+	// ErrUsageChargesDisabled is returned when store's plan has no usage charges.
+	ErrUsageChargesDisabled = errs.New("plan has no usage charges")
+)
+
+// AppSubscription is a recurring charge of the app, approved by store owner.
+type AppSubscription struct {
+	ID               string
+	Name             string
+	Status           string
+	Test             bool
+	TrialDays        int
+	CurrentPeriodEnd *time.Time
+	// UsageLineItemID is an ID of subscription's usage charges line item, empty if plan has no usage charges.
+	UsageLineItemID string
+}
+
+type CreateAppSubscriptionOptions struct {
+	Plan config.BillingPlan
+	// ReturnURL is a URL store owner is redirected to after approving subscription.
+	ReturnURL string
+	Test      bool
+}
+
+type CreatedAppSubscription struct {
+	Subscription AppSubscription
+	// ConfirmationURL is a URL, where store owner approves subscription.
+	ConfirmationURL string
+}
+
+type CreateUsageRecordOptions struct {
+	LineItemID   string
+	Description  string
+	Amount       float64
+	CurrencyCode string
+	// IdempotencyKey prevents charging the same usage twice. Optional.
+	IdempotencyKey string
+}
+
+type HandleBillingCallbackOptions struct {
+	StoreName string
+	ChargeID  string
+}
+
+type CreateUsageChargeOptions struct {
+	StoreName   string
+	Description string
+	Amount      float64
+	// IdempotencyKey prevents charging the same usage twice. Optional.
+	IdempotencyKey string
+}
+
+// Billing describes available plans and store's subscription.
+type Billing struct {
+	Plans []config.BillingPlan `json:"plans"`
+	// Plan and Status are empty if store has no subscription.
+	Plan   string `json:"plan"`
+	Status string `json:"status"`
+}
+
+// billingService service implements BillingService interface.
+type billingService struct {
+	apis     APIs
+	storages Storages
+	config   *config.Config
+	logger   logging.Logger
+}
+
+var _ BillingService = (*billingService)(nil)
+
+func NewBillingService(opts *Options) *billingService {
+	s := &billingService{
+		apis:     opts.Apis,
+		storages: opts.Storages,
+		config:   opts.Config,
+		logger:   opts.Logger.Named("Billing"),
+	}
+
+	opts.Webhooks.Register(WebhookTopicAppSubscriptionsUpdate, s.handleAppSubscriptionsUpdateWebhook)
+
+	return s
+}
+
+func (s *billingService) GetBilling(ctx context.Context) (*Billing, error) {
+	logger := s.logger.Named("GetBilling").WithContext(ctx)
+
+	store, err := getSessionStore(ctx, s.apis, s.storages)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, err
+		}
+		logger.Error("failed to get session store", "err", err)
+		return nil, fmt.Errorf("failed to get session store: %w", err)
+	}
+
+	return &Billing{
+		Plans:  s.config.Billing.Plans,
+		Plan:   store.SubscriptionPlan,
+		Status: store.SubscriptionStatus,
+	}, nil
+}
+
+func (s *billingService) Subscribe(ctx context.Context, plan string) (string, error) {
+	logger := s.logger.
+		Named("Subscribe").
+		WithContext(ctx).
+		With("plan", plan)
+
+	store, err := getSessionStore(ctx, s.apis, s.storages)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return "", err
+		}
+		logger.Error("failed to get session store", "err", err)
+		return "", fmt.Errorf("failed to get session store: %w", err)
+	}
+
+	billingPlan := s.config.Billing.Plans.Plan(plan)
+	if billingPlan == nil {
+		logger.Info("plan is not found")
+		return "", ErrBillingPlanNotFound
+	}
+
+	confirmationURL, err := createAppSubscription(ctx, s.apis, s.config, store, *billingPlan)
+	if err != nil {
+		if _, ok := err.(PlatformUserErrors); ok {
+			logger.Info("subscription is invalid", "err", err)
+			return "", err
+		}
+		logger.Error("failed to create app subscription", "err", err)
+		return "", fmt.Errorf("failed to create app subscription: %w", err)
+	}
+
+	logger.Info("created app subscription")
+	return confirmationURL, nil
+}
+
+func (s *billingService) HandleCallback(ctx context.Context, opts HandleBillingCallbackOptions) (string, error) {
+	logger := s.logger.
+		Named("HandleCallback").
+		WithContext(ctx).
+		With("opts", opts)
+
+	shop, err := ParseShopDomain(opts.StoreName, s.config.Shopify.AllowedShopDomains)
+	if err != nil {
+		logger.Info(err.Error())
+		return "", err
+	}
+	opts.StoreName = shop.String()
+
+	store, err := s.storages.Store.Get(ctx, opts.StoreName)
+	if err != nil {
+		logger.Error("failed to get store from storage", "err", err)
+		return "", fmt.Errorf("failed to get store from storage: %w", err)
+	}
+	if store == nil || !store.Installed {
+		logger.Info("store is not found")
+		return "", ErrBillingStoreNotFound
+	}
+
+	// Callback is not signed, so subscription is fetched from platform with store's token
+	subscription, err := s.apis.Platform.WithConfig(ctx, store).GetAppSubscription(ctx, opts.ChargeID)
+	if err != nil {
+		logger.Error("failed to get app subscription", "err", err)
+		return "", fmt.Errorf("failed to get app subscription: %w", err)
+	}
+	if subscription == nil {
+		logger.Info("subscription is not found")
+		return "", ErrSubscriptionNotFound
+	}
+	logger = logger.With("subscription", subscription)
+
+	if subscription.Status != AppSubscriptionStatusActive {
+		logger.Info("subscription is not active")
+		return "", ErrSubscriptionNotActive
+	}
+
+	err = s.saveSubscription(ctx, store.Name, subscription)
+	if err != nil {
+		logger.Error("failed to save subscription", "err", err)
+		return "", err
+	}
+
+	logger.Info("activated subscription")
+	return appAdminURL(s.config, store.Name), nil
+}
+
+func (s *billingService) CheckSubscription(ctx context.Context) error {
+	logger := s.logger.Named("CheckSubscription").WithContext(ctx)
+
+	if len(s.config.Billing.Plans) == 0 {
+		return nil
+	}
+
+	store, err := getSessionStore(ctx, s.apis, s.storages)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return err
+		}
+		logger.Error("failed to get session store", "err", err)
+		return fmt.Errorf("failed to get session store: %w", err)
+	}
+
+	if store.SubscriptionStatus != AppSubscriptionStatusActive {
+		logger.Info("store has no active subscription", "storeName", store.Name, "status", store.SubscriptionStatus)
+		return ErrSubscriptionRequired
+	}
+
+	return nil
+}
+
+func (s *billingService) CreateUsageCharge(ctx context.Context, opts CreateUsageChargeOptions) error {
+	logger := s.logger.
+		Named("CreateUsageCharge").
+		WithContext(ctx).
+		With("opts", opts)
+
+	store, err := s.storages.Store.Get(ctx, opts.StoreName)
+	if err != nil {
+		logger.Error("failed to get store from storage", "err", err)
+		return fmt.Errorf("failed to get store from storage: %w", err)
+	}
+	if store == nil || !store.Installed {
+		logger.Info("store is not found")
+		return ErrBillingStoreNotFound
+	}
+	if store.SubscriptionStatus != AppSubscriptionStatusActive {
+		logger.Info("store has no active subscription")
+		return ErrSubscriptionRequired
+	}
+
+	plan := s.config.Billing.Plans.Plan(store.SubscriptionPlan)
+	if store.UsageLineItemID == "" || plan == nil {
+		logger.Info("plan has no usage charges")
+		return ErrUsageChargesDisabled
+	}
+
+	_, err = s.apis.Platform.WithConfig(ctx, store).CreateUsageRecord(ctx, CreateUsageRecordOptions{
+		LineItemID:     store.UsageLineItemID,
+		Description:    opts.Description,
+		Amount:         opts.Amount,
+		CurrencyCode:   plan.CurrencyCode,
+		IdempotencyKey: opts.IdempotencyKey,
+	})
+	if err != nil {
+		if _, ok := err.(PlatformUserErrors); ok {
+			logger.Info("usage charge is invalid", "err", err)
+			return err
+		}
+		logger.Error("failed to create usage record", "err", err)
+		return fmt.Errorf("failed to create usage record: %w", err)
+	}
+
+	logger.Info("created usage charge")
+	return nil
+}
+
+// handleAppSubscriptionsUpdateWebhook updates store's subscription status.
+func (s *billingService) handleAppSubscriptionsUpdateWebhook(ctx context.Context, event WebhookEvent) error {
+	logger := s.logger.
+		Named("handleAppSubscriptionsUpdateWebhook").
+		WithContext(ctx).
+		With("id", event.ID, "shop", event.Shop)
+
+	var payload struct {
+		AppSubscription struct {
+			AdminGraphQLAPIID string `json:"admin_graphql_api_id"`
+			Status            string `json:"status"`
+		} `json:"app_subscription"`
+	}
+	err := json.Unmarshal(event.Payload, &payload)
+	if err != nil {
+		logger.Error("failed to decode webhook payload", "err", err)
+		return fmt.Errorf("failed to decode webhook payload: %w", err)
+	}
+	id := payload.AppSubscription.AdminGraphQLAPIID
+	status := payload.AppSubscription.Status
+	logger = logger.With("subscription", id, "status", status)
+
+	store, err := s.storages.Store.Get(ctx, event.Shop.String())
+	if err != nil {
+		logger.Error("failed to get store from storage", "err", err)
+		return fmt.Errorf("failed to get store from storage: %w", err)
+	}
+	if store == nil {
+		logger.Info("store is not found")
+		return nil
+	}
+
+	if store.SubscriptionID == id {
+		_, err = s.storages.Store.Update(ctx, &entity.Store{
+			Name:               store.Name,
+			SubscriptionStatus: status,
+		})
+		if err != nil {
+			logger.Error("failed to update store in storage", "err", err)
+			return fmt.Errorf("failed to update store in storage: %w", err)
+		}
+
+		logger.Info("updated subscription status")
+		return nil
+	}
+
+	// Another subscription, e.g. approved before callback is handled, replaces the current one once active
+	if status != AppSubscriptionStatusActive {
+		logger.Debug("subscription is not the store's one")
+		return nil
+	}
+
+	subscription, err := s.apis.Platform.WithConfig(ctx, store).GetAppSubscription(ctx, id)
+	if err != nil {
+		logger.Error("failed to get app subscription", "err", err)
+		return fmt.Errorf("failed to get app subscription: %w", err)
+	}
+	if subscription == nil {
+		logger.Info("subscription is not found")
+		return nil
+	}
+
+	err = s.saveSubscription(ctx, store.Name, subscription)
+	if err != nil {
+		logger.Error("failed to save subscription", "err", err)
+		return err
+	}
+
+	logger.Info("activated subscription")
+	return nil
+}
+
+// saveSubscription records subscription as store's current one.
+func (s *billingService) saveSubscription(ctx context.Context, storeName string, subscription *AppSubscription) error {
+	err := s.storages.Store.UpdateSubscription(ctx, &entity.Store{
+		Name:               storeName,
+		SubscriptionID:     subscription.ID,
+		SubscriptionPlan:   subscription.Name,
+		SubscriptionStatus: subscription.Status,
+		UsageLineItemID:    subscription.UsageLineItemID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update store in storage: %w", err)
+	}
+	return nil
+}
+
+// createAppSubscription creates subscription to the plan and returns URL, where store owner approves it.
+func createAppSubscription(ctx context.Context, apis APIs, cfg *config.Config, store *entity.Store, plan config.BillingPlan) (string, error) {
+	returnURL := fmt.Sprintf("%s/billing/callback?shop=%s", cfg.App.BaseURL, url.QueryEscape(store.Name))
+
+	created, err := apis.Platform.WithConfig(ctx, store).CreateAppSubscription(ctx, CreateAppSubscriptionOptions{
+		Plan:      plan,
+		ReturnURL: returnURL,
+		Test:      cfg.Billing.Test,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return created.ConfirmationURL, nil
+}
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/pkg/errs"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+	"github.com/softcery/shopify-app-template-go/pkg/queue"
+)
+
+const (
+	// BulkOperationStatusCreated is a status of the bulk operation that hasn't started yet.
+	BulkOperationStatusCreated = "CREATED"
+	// BulkOperationStatusRunning is a status of the bulk operation in progress.
+	BulkOperationStatusRunning = "RUNNING"
+	// BulkOperationStatusCanceling is a status of the bulk operation being canceled.
+	BulkOperationStatusCanceling = "CANCELING"
+	// BulkOperationStatusCompleted is a status of the successfully completed bulk operation.
+	BulkOperationStatusCompleted = "COMPLETED"
+
+	// BulkOperationErrorCodeAccessDenied is an error code of the operation, which query the app has no access to.
+	BulkOperationErrorCodeAccessDenied = "ACCESS_DENIED"
+)
+
+// BulkOperation is an asynchronous query executed by platform.
+type BulkOperation struct {
+	ID          string
+	Status      string
+	ErrorCode   string
+	ObjectCount int64
+	// URL is a URL of the result file, it is empty if operation has no results.
+	URL         string
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+// BulkRecord is a record of bulk operation's result with the records of its nested connections.
+type BulkRecord struct {
+	ID string
+	// Type is a type of the record's object, e.g. "Product" or "ProductVariant".
+	Type     string
+	Data     json.RawMessage
+	Children []*BulkRecord
+}
+
+// Decode decodes record's data into v.
+func (r *BulkRecord) Decode(v interface{}) error {
+	return json.Unmarshal(r.Data, v)
+}
+
+// BulkRecordIterator iterates over bulk operation's result file.
+// It must be closed after iteration.
+type BulkRecordIterator interface {
+	Iterator[*BulkRecord]
+	// Close releases underlying result file.
+	Close() error
+}
+
+const (
+	// WebhookTopicBulkOperationsFinish is sent when bulk operation finishes.
+	WebhookTopicBulkOperationsFinish = "bulk_operations/finish"
+	// JobKindPollBulkOperation is a kind of jobs checking status of bulk operations.
+	JobKindPollBulkOperation = "bulk_operation.poll"
+)
+
+var (
+	// ErrRunBulkOperationStoreNotFound is returned when store is not found.
+	ErrRunBulkOperationStoreNotFound = errs.New("store is not found")
+)
+
+// BulkRecordHandler handles a single record of bulk operation's result.
+type BulkRecordHandler func(ctx context.Context, store *entity.Store, record *BulkRecord) error
+
+// BulkCompletionHandler is called once every record of the completed bulk operation's result is handled.
+type BulkCompletionHandler func(ctx context.Context, store *entity.Store, operation *entity.BulkOperation) error
+
+type RunBulkOperationOptions struct {
+	StoreName string
+	// Kind determines handler of the operation's result.
+	Kind  string
+	Query string
+}
+
+// bulkOperationService service implements BulkOperationService interface.
+type bulkOperationService struct {
+	apis     APIs
+	storages Storages
+	queue    JobQueue
+	config   *config.Config
+	logger   logging.Logger
+
+	mu                 sync.RWMutex
+	handlers           map[string]BulkRecordHandler
+	completionHandlers map[string]BulkCompletionHandler
+}
+
+var _ BulkOperationService = (*bulkOperationService)(nil)
+
+func NewBulkOperationService(opts *Options) *bulkOperationService {
+	s := &bulkOperationService{
+		apis:     opts.Apis,
+		storages: opts.Storages,
+		queue:    opts.Queue,
+		config:   opts.Config,
+		logger:   opts.Logger.Named("BulkOperation"),
+		handlers: make(map[string]BulkRecordHandler),
+
+		completionHandlers: make(map[string]BulkCompletionHandler),
+	}
+
+	opts.Webhooks.Register(WebhookTopicBulkOperationsFinish, s.handleBulkOperationsFinishWebhook)
+
+	return s
+}
+
+func (s *bulkOperationService) RegisterHandler(kind string, handler BulkRecordHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[kind] = handler
+}
+
+func (s *bulkOperationService) RegisterCompletionHandler(kind string, handler BulkCompletionHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.completionHandlers[kind] = handler
+}
+
+func (s *bulkOperationService) Run(ctx context.Context, opts RunBulkOperationOptions) (*entity.BulkOperation, error) {
+	logger := s.logger.
+		Named("Run").
+		WithContext(ctx).
+		With("storeName", opts.StoreName, "kind", opts.Kind)
+
+	// Resume unfinished operation instead of running the same query again
+	operation, err := s.storages.BulkOperation.GetUnprocessed(ctx, opts.StoreName, opts.Kind)
+	if err != nil {
+		logger.Error("failed to get unprocessed bulk operation from storage", "err", err)
+		return nil, fmt.Errorf("failed to get unprocessed bulk operation from storage: %w", err)
+	}
+	if operation != nil {
+		logger.Info("bulk operation is already running", "operation", operation.ID)
+		return operation, nil
+	}
+
+	store, err := s.storages.Store.Get(ctx, opts.StoreName)
+	if err != nil {
+		logger.Error("failed to get store from storage", "err", err)
+		return nil, fmt.Errorf("failed to get store from storage: %w", err)
+	}
+	if store == nil {
+		logger.Info("store is not found")
+		return nil, ErrRunBulkOperationStoreNotFound
+	}
+
+	operation, err = s.start(ctx, s.apis.Platform.WithConfig(ctx, store), opts, 1)
+	if err != nil {
+		logger.Error("failed to start bulk operation", "err", err)
+		return nil, err
+	}
+
+	logger.Info("started bulk operation", "operation", operation.ID)
+	return operation, nil
+}
+
+// start runs the query and polls the started operation.
+func (s *bulkOperationService) start(ctx context.Context, api PlatformAPI, opts RunBulkOperationOptions, attempt int) (*entity.BulkOperation, error) {
+	started, err := api.RunBulkQuery(ctx, opts.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run bulk query: %w", err)
+	}
+
+	operation, err := s.storages.BulkOperation.Create(ctx, &entity.BulkOperation{
+		ID:        started.ID,
+		StoreName: opts.StoreName,
+		Kind:      opts.Kind,
+		Query:     opts.Query,
+		Status:    started.Status,
+		Attempt:   attempt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bulk operation in storage: %w", err)
+	}
+
+	// Poll operation in case finish webhook is not delivered
+	err = s.queue.EnqueueAt(ctx, JobKindPollBulkOperation, operation.ID, time.Now().Add(s.config.Shopify.BulkOperationPollInterval), queue.Key(operation.StoreName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue bulk operation polling: %w", err)
+	}
+
+	return operation, nil
+}
+
+func (s *bulkOperationService) PollBulkOperation(ctx context.Context, id string) error {
+	logger := s.logger.
+		Named("PollBulkOperation").
+		WithContext(ctx).
+		With("id", id)
+
+	finished, err := s.sync(ctx, id)
+	if err != nil {
+		logger.Error("failed to sync bulk operation", "err", err)
+		return err
+	}
+	if finished {
+		logger.Debug("bulk operation has finished")
+		return nil
+	}
+
+	operation, err := s.storages.BulkOperation.Get(ctx, id)
+	if err != nil {
+		logger.Error("failed to get bulk operation from storage", "err", err)
+		return fmt.Errorf("failed to get bulk operation from storage: %w", err)
+	}
+	if operation == nil {
+		logger.Info("bulk operation is not found")
+		return nil
+	}
+
+	err = s.queue.EnqueueAt(ctx, JobKindPollBulkOperation, id, time.Now().Add(s.config.Shopify.BulkOperationPollInterval), queue.Key(operation.StoreName))
+	if err != nil {
+		logger.Error("failed to enqueue bulk operation polling", "err", err)
+		return fmt.Errorf("failed to enqueue bulk operation polling: %w", err)
+	}
+
+	logger.Debug("bulk operation is still running")
+	return nil
+}
+
+// handleBulkOperationsFinishWebhook processes result of the finished bulk operation.
+func (s *bulkOperationService) handleBulkOperationsFinishWebhook(ctx context.Context, event WebhookEvent) error {
+	var payload struct {
+		AdminGraphQLAPIID string `json:"admin_graphql_api_id"`
+	}
+	err := json.Unmarshal(event.Payload, &payload)
+	if err != nil {
+		return fmt.Errorf("failed to decode webhook payload: %w", err)
+	}
+
+	_, err = s.sync(ctx, payload.AdminGraphQLAPIID)
+	return err
+}
+
+// sync updates bulk operation's status from platform and processes its result once it finishes.
+// It returns true if operation has been processed or is not known anymore.
+func (s *bulkOperationService) sync(ctx context.Context, id string) (bool, error) {
+	logger := s.logger.
+		Named("sync").
+		WithContext(ctx).
+		With("id", id)
+
+	operation, err := s.storages.BulkOperation.Get(ctx, id)
+	if err != nil {
+		logger.Error("failed to get bulk operation from storage", "err", err)
+		return false, fmt.Errorf("failed to get bulk operation from storage: %w", err)
+	}
+	if operation == nil || operation.ProcessedAt != nil {
+		logger.Info("bulk operation is not found or has already been processed")
+		return true, nil
+	}
+
+	store, err := s.storages.Store.Get(ctx, operation.StoreName)
+	if err != nil {
+		logger.Error("failed to get store from storage", "err", err)
+		return false, fmt.Errorf("failed to get store from storage: %w", err)
+	}
+	if store == nil {
+		logger.Info("store is not found")
+		return true, nil
+	}
+	api := s.apis.Platform.WithConfig(ctx, store)
+
+	current, err := api.GetBulkOperation(ctx, id)
+	if err != nil {
+		logger.Error("failed to get bulk operation", "err", err)
+		return false, fmt.Errorf("failed to get bulk operation: %w", err)
+	}
+	if current == nil {
+		logger.Info("bulk operation is not found at platform")
+		return true, nil
+	}
+
+	_, err = s.storages.BulkOperation.Update(ctx, &entity.BulkOperation{
+		ID:          id,
+		Status:      current.Status,
+		ErrorCode:   current.ErrorCode,
+		ObjectCount: current.ObjectCount,
+		CompletedAt: current.CompletedAt,
+	})
+	if err != nil {
+		logger.Error("failed to update bulk operation in storage", "err", err)
+		return false, fmt.Errorf("failed to update bulk operation in storage: %w", err)
+	}
+
+	switch current.Status {
+	case BulkOperationStatusCreated, BulkOperationStatusRunning, BulkOperationStatusCanceling:
+		return false, nil
+	}
+
+	processed, err := s.process(ctx, api, store, operation, current)
+	if err != nil {
+		logger.Error("failed to process bulk operation", "err", err)
+		return false, err
+	}
+
+	return processed, nil
+}
+
+// process handles the finished bulk operation and marks it as processed.
+// It returns false if the operation is being processed by another worker.
+func (s *bulkOperationService) process(ctx context.Context, api PlatformAPI, store *entity.Store, operation *entity.BulkOperation, current *BulkOperation) (bool, error) {
+	logger := s.logger.
+		Named("process").
+		WithContext(ctx).
+		With("id", operation.ID, "kind", operation.Kind, "status", current.Status)
+
+	// Lease outlives processing, so the operation is not handled by two workers at once,
+	// and is handled again once the lease expires if the worker crashes
+	timeout := s.config.Shopify.BulkOperationProcessTimeout
+	locked, err := s.storages.BulkOperation.Lock(ctx, operation.ID, time.Now().Add(timeout))
+	if err != nil {
+		logger.Error("failed to lock bulk operation", "err", err)
+		return false, fmt.Errorf("failed to lock bulk operation: %w", err)
+	}
+	if !locked {
+		logger.Info("bulk operation is processed or is being processed by another worker")
+		return false, nil
+	}
+
+	processCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if current.Status == BulkOperationStatusCompleted {
+		err = s.handleResult(processCtx, api, store, operation, current.URL)
+	} else {
+		err = s.rerun(processCtx, api, operation, current)
+	}
+	if err != nil {
+		// Lease expires anyway, unlocking lets the retry process the operation sooner
+		unlockErr := s.storages.BulkOperation.Unlock(ctx, operation.ID)
+		if unlockErr != nil {
+			logger.Error("failed to unlock bulk operation", "err", unlockErr)
+		}
+		return false, err
+	}
+
+	err = s.storages.BulkOperation.MarkProcessed(ctx, operation.ID, time.Now())
+	if err != nil {
+		logger.Error("failed to mark bulk operation as processed", "err", err)
+		return false, fmt.Errorf("failed to mark bulk operation as processed: %w", err)
+	}
+
+	logger.Info("processed bulk operation")
+	return true, nil
+}
+
+// rerun runs the query of failed operation again, unless it has run out of attempts or the app has no access to it.
+func (s *bulkOperationService) rerun(ctx context.Context, api PlatformAPI, operation *entity.BulkOperation, current *BulkOperation) error {
+	logger := s.logger.
+		Named("rerun").
+		WithContext(ctx).
+		With("id", operation.ID, "kind", operation.Kind, "status", current.Status, "errorCode", current.ErrorCode)
+
+	attempt := operation.Attempt
+	if attempt < 1 {
+		attempt = 1
+	}
+	if current.ErrorCode == BulkOperationErrorCodeAccessDenied || attempt >= s.config.Shopify.BulkOperationMaxAttempts {
+		logger.Error("bulk operation has failed", "attempt", attempt)
+		return nil
+	}
+
+	started, err := s.start(ctx, api, RunBulkOperationOptions{
+		StoreName: operation.StoreName,
+		Kind:      operation.Kind,
+		Query:     operation.Query,
+	}, attempt+1)
+	if err != nil {
+		logger.Error("failed to start bulk operation again", "err", err)
+		return err
+	}
+
+	logger.Warn("bulk operation has failed, started it again", "attempt", attempt, "operation", started.ID)
+	return nil
+}
+
+// handleResult passes every record of the result file to the handler of the operation's kind,
+// then calls completion handler of the kind, if any.
+func (s *bulkOperationService) handleResult(ctx context.Context, api PlatformAPI, store *entity.Store, operation *entity.BulkOperation, url string) error {
+	s.mu.RLock()
+	handler, ok := s.handlers[operation.Kind]
+	completionHandler := s.completionHandlers[operation.Kind]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no handler registered for bulk operation kind %q", operation.Kind)
+	}
+
+	// Operation without results has matched nothing
+	if url != "" {
+		records, err := api.DownloadBulkOperationResult(ctx, url)
+		if err != nil {
+			return fmt.Errorf("failed to download bulk operation result: %w", err)
+		}
+		defer records.Close()
+
+		for records.Next(ctx) {
+			err = handler(ctx, store, records.Item())
+			if err != nil {
+				return fmt.Errorf("failed to handle bulk operation record: %w", err)
+			}
+		}
+		if err = records.Err(); err != nil {
+			return fmt.Errorf("failed to read bulk operation result: %w", err)
+		}
+	}
+
+	if completionHandler != nil {
+		err := completionHandler(ctx, store, operation)
+		if err != nil {
+			return fmt.Errorf("failed to complete bulk operation: %w", err)
+		}
+	}
+
+	return nil
+}
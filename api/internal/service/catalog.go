@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+const (
+	// WebhookTopicProductsCreate is sent when product is created.
+	WebhookTopicProductsCreate = "products/create"
+	// WebhookTopicProductsUpdate is sent when product is updated.
+	WebhookTopicProductsUpdate = "products/update"
+	// WebhookTopicProductsDelete is sent when product is deleted.
+	WebhookTopicProductsDelete = "products/delete"
+
+	// JobKindImportProducts is a kind of jobs importing all store's products into local catalog.
+	JobKindImportProducts = "catalog.import_products"
+	// BulkOperationKindImportProducts is a kind of bulk operations fetching all store's products.
+	BulkOperationKindImportProducts = "catalog.import_products"
+)
+
+// importProductsQuery is a bulk query fetching all store's products with their variants.
+const importProductsQuery = `
+{
+	products {
+		edges {
+			node {
+				id
+				title
+				descriptionHtml
+				handle
+				status
+				vendor
+				productType
+				tags
+				createdAt
+				updatedAt
+				variants {
+					edges {
+						node {
+							id
+							title
+							sku
+							barcode
+							price
+							compareAtPrice
+							inventoryQuantity
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+// catalogService service implements CatalogService interface.
+type catalogService struct {
+	storages       Storages
+	bulkOperations BulkOperationService
+	config         *config.Config
+	logger         logging.Logger
+}
+
+var _ CatalogService = (*catalogService)(nil)
+
+func NewCatalogService(opts *Options) *catalogService {
+	s := &catalogService{
+		storages:       opts.Storages,
+		bulkOperations: opts.BulkOperations,
+		config:         opts.Config,
+		logger:         opts.Logger.Named("Catalog"),
+	}
+
+	opts.Webhooks.Register(WebhookTopicProductsCreate, s.handleProductsUpdateWebhook)
+	opts.Webhooks.Register(WebhookTopicProductsUpdate, s.handleProductsUpdateWebhook)
+	opts.Webhooks.Register(WebhookTopicProductsDelete, s.handleProductsDeleteWebhook)
+	s.bulkOperations.RegisterHandler(BulkOperationKindImportProducts, s.handleImportProductsRecord)
+	s.bulkOperations.RegisterCompletionHandler(BulkOperationKindImportProducts, s.handleImportProductsCompletion)
+
+	return s
+}
+
+func (s *catalogService) ImportProducts(ctx context.Context, storeName string) error {
+	logger := s.logger.
+		Named("ImportProducts").
+		WithContext(ctx).
+		With("storeName", storeName)
+
+	operation, err := s.bulkOperations.Run(ctx, RunBulkOperationOptions{
+		StoreName: storeName,
+		Kind:      BulkOperationKindImportProducts,
+		Query:     importProductsQuery,
+	})
+	if err != nil {
+		if errors.Is(err, ErrRunBulkOperationStoreNotFound) {
+			logger.Info(err.Error())
+			return nil
+		}
+		logger.Error("failed to run bulk operation", "err", err)
+		return fmt.Errorf("failed to run bulk operation: %w", err)
+	}
+
+	logger.Info("started products import", "operation", operation.ID)
+	return nil
+}
+
+// bulkProductRecord is a product of import bulk operation's result.
+type bulkProductRecord struct {
+	ID              string    `json:"id"`
+	Title           string    `json:"title"`
+	DescriptionHTML string    `json:"descriptionHtml"`
+	Handle          string    `json:"handle"`
+	Status          string    `json:"status"`
+	Vendor          string    `json:"vendor"`
+	ProductType     string    `json:"productType"`
+	Tags            []string  `json:"tags"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// bulkProductVariantRecord is a product variant of import bulk operation's result.
+type bulkProductVariantRecord struct {
+	ID                string  `json:"id"`
+	Title             string  `json:"title"`
+	SKU               string  `json:"sku"`
+	Barcode           string  `json:"barcode"`
+	Price             string  `json:"price"`
+	CompareAtPrice    *string `json:"compareAtPrice"`
+	InventoryQuantity int     `json:"inventoryQuantity"`
+}
+
+// handleImportProductsRecord saves product of import bulk operation's result with its variants.
+func (s *catalogService) handleImportProductsRecord(ctx context.Context, store *entity.Store, record *BulkRecord) error {
+	if record.Type != "Product" {
+		return nil
+	}
+
+	var product bulkProductRecord
+	err := record.Decode(&product)
+	if err != nil {
+		return fmt.Errorf("failed to decode product: %w", err)
+	}
+
+	variants := make([]entity.ProductVariant, 0, len(record.Children))
+	for _, child := range record.Children {
+		if child.Type != "ProductVariant" {
+			continue
+		}
+
+		var variant bulkProductVariantRecord
+		err = child.Decode(&variant)
+		if err != nil {
+			return fmt.Errorf("failed to decode product variant: %w", err)
+		}
+
+		variants = append(variants, entity.ProductVariant{
+			ID:                variant.ID,
+			Title:             variant.Title,
+			SKU:               variant.SKU,
+			Barcode:           variant.Barcode,
+			Price:             variant.Price,
+			CompareAtPrice:    variant.CompareAtPrice,
+			InventoryQuantity: variant.InventoryQuantity,
+		})
+	}
+
+	_, err = s.storages.Product.Save(ctx, &entity.Product{
+		ID:                product.ID,
+		StoreName:         store.Name,
+		Title:             product.Title,
+		Description:       product.DescriptionHTML,
+		Handle:            product.Handle,
+		Status:            product.Status,
+		Vendor:            product.Vendor,
+		ProductType:       product.ProductType,
+		Tags:              product.Tags,
+		PlatformCreatedAt: product.CreatedAt,
+		PlatformUpdatedAt: product.UpdatedAt,
+	}, variants)
+	if err != nil {
+		return fmt.Errorf("failed to save product in storage: %w", err)
+	}
+
+	return nil
+}
+
+// handleImportProductsCompletion deletes products, which weren't saved since import has started,
+// e.g. left from the previous installation, since they don't exist at platform anymore.
+// Products are replaced only once import completes, so catalog isn't empty while it runs.
+func (s *catalogService) handleImportProductsCompletion(ctx context.Context, store *entity.Store, operation *entity.BulkOperation) error {
+	logger := s.logger.
+		Named("handleImportProductsCompletion").
+		WithContext(ctx).
+		With("storeName", store.Name, "operation", operation.ID)
+
+	err := s.storages.Product.DeleteNotUpdatedSince(ctx, store.Name, operation.CreatedAt)
+	if err != nil {
+		logger.Error("failed to delete stale products from storage", "err", err)
+		return fmt.Errorf("failed to delete stale products from storage: %w", err)
+	}
+
+	logger.Info("imported products")
+	return nil
+}
+
+// productWebhookPayload is a payload of products/create and products/update webhooks.
+type productWebhookPayload struct {
+	AdminGraphQLAPIID string    `json:"admin_graphql_api_id"`
+	Title             string    `json:"title"`
+	BodyHTML          string    `json:"body_html"`
+	Handle            string    `json:"handle"`
+	Status            string    `json:"status"`
+	Vendor            string    `json:"vendor"`
+	ProductType       string    `json:"product_type"`
+	Tags              string    `json:"tags"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	Variants          []struct {
+		AdminGraphQLAPIID string  `json:"admin_graphql_api_id"`
+		Title             string  `json:"title"`
+		SKU               string  `json:"sku"`
+		Barcode           string  `json:"barcode"`
+		Price             string  `json:"price"`
+		CompareAtPrice    *string `json:"compare_at_price"`
+		InventoryQuantity int     `json:"inventory_quantity"`
+	} `json:"variants"`
+}
+
+// handleProductsUpdateWebhook saves created or updated product with its variants.
+func (s *catalogService) handleProductsUpdateWebhook(ctx context.Context, event WebhookEvent) error {
+	logger := s.logger.
+		Named("handleProductsUpdateWebhook").
+		WithContext(ctx).
+		With("id", event.ID, "topic", event.Topic, "shop", event.Shop)
+
+	var payload productWebhookPayload
+	err := json.Unmarshal(event.Payload, &payload)
+	if err != nil {
+		logger.Error("failed to decode webhook payload", "err", err)
+		return fmt.Errorf("failed to decode webhook payload: %w", err)
+	}
+	logger = logger.With("product", payload.AdminGraphQLAPIID)
+
+	variants := make([]entity.ProductVariant, 0, len(payload.Variants))
+	for _, variant := range payload.Variants {
+		variants = append(variants, entity.ProductVariant{
+			ID:                variant.AdminGraphQLAPIID,
+			Title:             variant.Title,
+			SKU:               variant.SKU,
+			Barcode:           variant.Barcode,
+			Price:             variant.Price,
+			CompareAtPrice:    variant.CompareAtPrice,
+			InventoryQuantity: variant.InventoryQuantity,
+		})
+	}
+
+	saved, err := s.storages.Product.Save(ctx, &entity.Product{
+		ID:          payload.AdminGraphQLAPIID,
+		StoreName:   event.Shop.String(),
+		Title:       payload.Title,
+		Description: payload.BodyHTML,
+		Handle:      payload.Handle,
+		// Webhooks use REST representation, where status is lowercase
+		Status:            strings.ToUpper(payload.Status),
+		Vendor:            payload.Vendor,
+		ProductType:       payload.ProductType,
+		Tags:              SplitTags(payload.Tags),
+		PlatformCreatedAt: payload.CreatedAt,
+		PlatformUpdatedAt: payload.UpdatedAt,
+	}, variants)
+	if err != nil {
+		logger.Error("failed to save product in storage", "err", err)
+		return fmt.Errorf("failed to save product in storage: %w", err)
+	}
+	if !saved {
+		logger.Info("stored product is more recent")
+		return nil
+	}
+
+	logger.Info("saved product")
+	return nil
+}
+
+// handleProductsDeleteWebhook deletes product with its variants.
+func (s *catalogService) handleProductsDeleteWebhook(ctx context.Context, event WebhookEvent) error {
+	logger := s.logger.
+		Named("handleProductsDeleteWebhook").
+		WithContext(ctx).
+		With("id", event.ID, "topic", event.Topic, "shop", event.Shop)
+
+	var payload struct {
+		ID int64 `json:"id"`
+	}
+	err := json.Unmarshal(event.Payload, &payload)
+	if err != nil {
+		logger.Error("failed to decode webhook payload", "err", err)
+		return fmt.Errorf("failed to decode webhook payload: %w", err)
+	}
+	// Payload of products/delete webhook contains only numeric ID
+	id := fmt.Sprintf("gid://shopify/Product/%d", payload.ID)
+	logger = logger.With("product", id)
+
+	err = s.storages.Product.Delete(ctx, event.Shop.String(), id)
+	if err != nil {
+		logger.Error("failed to delete product from storage", "err", err)
+		return fmt.Errorf("failed to delete product from storage: %w", err)
+	}
+
+	logger.Info("deleted product")
+	return nil
+}
+
+// SplitTags splits comma separated tags of REST representation.
+func SplitTags(tags string) []string {
+	split := make([]string, 0)
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			split = append(split, tag)
+		}
+	}
+	return split
+}
@@ -0,0 +1,18 @@
+package service
+
+// Collection is store's custom collection.
+type Collection struct {
+	ID    string
+	Title string
+	// Handle is collection's unique URL handle.
+	Handle string
+}
+
+// CreateCollectionOptions are options of creating custom collection.
+type CreateCollectionOptions struct {
+	Title string
+	// Handle is collection's URL handle, platform generates it from the title if it is empty.
+	Handle string
+	// ProductIDs are IDs of products added to the collection.
+	ProductIDs []string
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+)
+
+const (
+	// WebhookTopicCustomersDataRequest is sent when customer requests their data from the store.
+	WebhookTopicCustomersDataRequest = "customers/data_request"
+	// WebhookTopicCustomersRedact is sent when store owner requests deletion of customer's data.
+	WebhookTopicCustomersRedact = "customers/redact"
+	// WebhookTopicShopRedact is sent 48 hours after app is uninstalled to request deletion of store's data.
+	WebhookTopicShopRedact = "shop/redact"
+)
+
+// complianceWebhookTopics are configured in app's settings and can't be subscribed to with API.
+var complianceWebhookTopics = map[string]bool{
+	WebhookTopicCustomersDataRequest: true,
+	WebhookTopicCustomersRedact:      true,
+	WebhookTopicShopRedact:           true,
+}
+
+// ComplianceHandler is implemented by the app to export or erase data it stores about customers and stores.
+type ComplianceHandler interface {
+	// ExportCustomerData provides store owner with the data app stores about the customer.
+	// Data contains customer's records from app's own storages.
+	ExportCustomerData(ctx context.Context, request CustomersDataRequest, data CustomerData) error
+	// RedactCustomer erases data app stores about the customer.
+	// Customer's records in app's own storages are deleted after it is called.
+	RedactCustomer(ctx context.Context, request CustomersRedactRequest) error
+	// RedactShop erases data app stores about the store.
+	// Store's records in app's own storages are deleted after it is called.
+	RedactShop(ctx context.Context, request ShopRedactRequest) error
+}
+
+// ComplianceCustomer is a customer compliance request is made for.
+type ComplianceCustomer struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// https://shopify.dev/docs/apps/webhooks/configuration/mandatory-webhooks#customers-data_request
+type CustomersDataRequest struct {
+	ShopID          int64              `json:"shop_id"`
+	ShopDomain      string             `json:"shop_domain"`
+	OrdersRequested []int64            `json:"orders_requested"`
+	Customer        ComplianceCustomer `json:"customer"`
+	DataRequest     struct {
+		ID int64 `json:"id"`
+	} `json:"data_request"`
+}
+
+// CustomerData is the data app's own storages keep about the customer.
+type CustomerData struct {
+	// Customer is nil if customer is not stored.
+	Customer *Customer `json:"customer"`
+	Orders   []Order   `json:"orders"`
+}
+
+// https://shopify.dev/docs/apps/webhooks/configuration/mandatory-webhooks#customers-redact
+type CustomersRedactRequest struct {
+	ShopID         int64              `json:"shop_id"`
+	ShopDomain     string             `json:"shop_domain"`
+	Customer       ComplianceCustomer `json:"customer"`
+	OrdersToRedact []int64            `json:"orders_to_redact"`
+}
+
+// https://shopify.dev/docs/apps/webhooks/configuration/mandatory-webhooks#shop-redact
+type ShopRedactRequest struct {
+	ShopID     int64  `json:"shop_id"`
+	ShopDomain string `json:"shop_domain"`
+}
+
+// noopComplianceHandler is used when app doesn't store any customer or store data besides its own storages.
+type noopComplianceHandler struct{}
+
+func (noopComplianceHandler) ExportCustomerData(ctx context.Context, request CustomersDataRequest, data CustomerData) error {
+	return nil
+}
+
+func (noopComplianceHandler) RedactCustomer(ctx context.Context, request CustomersRedactRequest) error {
+	return nil
+}
+
+func (noopComplianceHandler) RedactShop(ctx context.Context, request ShopRedactRequest) error {
+	return nil
+}
+
+// handleCustomersDataRequestWebhook collects customer's data from app's own storages
+// and passes it with customer's data request to app's compliance handler.
+func (s *platformService) handleCustomersDataRequestWebhook(ctx context.Context, event WebhookEvent) error {
+	var request CustomersDataRequest
+	return s.handleComplianceWebhook(ctx, event, &request, func() error {
+		data, err := s.customerData(ctx, event.Shop.String(), request.Customer.ID, request.OrdersRequested)
+		if err != nil {
+			return err
+		}
+
+		return s.compliance.ExportCustomerData(ctx, request, *data)
+	})
+}
+
+// customerData returns customer's records from app's own storages.
+func (s *platformService) customerData(ctx context.Context, storeName string, customerID int64, orderIDs []int64) (*CustomerData, error) {
+	data := &CustomerData{Orders: make([]Order, 0, len(orderIDs))}
+
+	customer, err := s.storages.Customer.Get(ctx, storeName, customerGID(customerID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer from storage: %w", err)
+	}
+	if customer != nil {
+		data.Customer, err = customerFromEntity(customer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range orderIDs {
+		order, err := s.storages.Order.Get(ctx, storeName, orderGID(id))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get order from storage: %w", err)
+		}
+		if order != nil {
+			data.Orders = append(data.Orders, orderFromEntity(order))
+		}
+	}
+
+	return data, nil
+}
+
+// handleCustomersRedactWebhook passes customer's erasure request to app's compliance handler
+// and then permanently deletes customer's records from app's own storages.
+func (s *platformService) handleCustomersRedactWebhook(ctx context.Context, event WebhookEvent) error {
+	var request CustomersRedactRequest
+	return s.handleComplianceWebhook(ctx, event, &request, func() error {
+		err := s.compliance.RedactCustomer(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		err = s.storages.Customer.Delete(ctx, event.Shop.String(), customerGID(request.Customer.ID))
+		if err != nil {
+			return fmt.Errorf("failed to delete customer: %w", err)
+		}
+
+		orderIDs := make([]string, 0, len(request.OrdersToRedact))
+		for _, id := range request.OrdersToRedact {
+			orderIDs = append(orderIDs, orderGID(id))
+		}
+		err = s.storages.Order.Delete(ctx, event.Shop.String(), orderIDs)
+		if err != nil {
+			return fmt.Errorf("failed to delete customer's orders: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// handleShopRedactWebhook passes store's erasure request to app's compliance handler
+// and then permanently deletes everything app stores about the store.
+func (s *platformService) handleShopRedactWebhook(ctx context.Context, event WebhookEvent) error {
+	var request ShopRedactRequest
+	return s.handleComplianceWebhook(ctx, event, &request, func() error {
+		err := s.compliance.RedactShop(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		err = s.storages.OAuthState.DeleteByStore(ctx, event.Shop.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete store's oauth states: %w", err)
+		}
+
+		err = s.storages.BulkOperation.DeleteByStore(ctx, event.Shop.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete store's bulk operations: %w", err)
+		}
+
+		err = s.storages.Product.DeleteByStore(ctx, event.Shop.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete store's products: %w", err)
+		}
+
+		err = s.storages.Order.DeleteByStore(ctx, event.Shop.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete store's orders: %w", err)
+		}
+
+		err = s.storages.Customer.DeleteByStore(ctx, event.Shop.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete store's customers: %w", err)
+		}
+
+		err = s.storages.SeedRun.DeleteByStore(ctx, event.Shop.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete store's seed runs: %w", err)
+		}
+
+		err = s.storages.ComplianceRequest.DeleteByStore(ctx, event.Shop.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete store's compliance requests: %w", err)
+		}
+
+		err = s.storages.ProcessedWebhook.DeleteByStore(ctx, event.Shop.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete store's processed webhooks: %w", err)
+		}
+
+		err = s.storages.Store.Purge(ctx, event.Shop.String())
+		if err != nil {
+			return fmt.Errorf("failed to purge store: %w", err)
+		}
+
+		// Deletes this webhook's job as well, so it's done last, once failures above can still be retried
+		err = s.queue.DeleteByKey(ctx, event.Shop.String())
+		if err != nil {
+			return fmt.Errorf("failed to delete store's jobs: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// handleComplianceWebhook persists compliance request, decodes its payload into request and runs handle.
+// Request is marked as completed once handle succeeds.
+func (s *platformService) handleComplianceWebhook(ctx context.Context, event WebhookEvent, request interface{}, handle func() error) error {
+	logger := s.logger.
+		Named("handleComplianceWebhook").
+		WithContext(ctx).
+		With("id", event.ID, "topic", event.Topic, "shop", event.Shop)
+
+	// Request is saved on the first attempt only, so retries of the webhook don't record it again
+	complianceRequest, err := s.storages.ComplianceRequest.GetByWebhookID(ctx, event.ID)
+	if err != nil {
+		logger.Error("failed to get compliance request from storage", "err", err)
+		return fmt.Errorf("failed to get compliance request from storage: %w", err)
+	}
+	if complianceRequest == nil {
+		complianceRequest = &entity.ComplianceRequest{
+			StoreName: event.Shop.String(),
+			Topic:     event.Topic,
+			WebhookID: event.ID,
+			Payload:   string(event.Payload),
+		}
+		// Shop erasure request outlives store's data, so it keeps no payload
+		if event.Topic == WebhookTopicShopRedact {
+			complianceRequest.Payload = "{}"
+		}
+		complianceRequest, err = s.storages.ComplianceRequest.Create(ctx, complianceRequest)
+		if err != nil {
+			logger.Error("failed to create compliance request in storage", "err", err)
+			return fmt.Errorf("failed to create compliance request in storage: %w", err)
+		}
+		logger.Debug("saved compliance request")
+	}
+	logger = logger.With("complianceRequest", complianceRequest.ID)
+
+	err = json.Unmarshal(event.Payload, request)
+	if err != nil {
+		logger.Error("failed to decode compliance request", "err", err)
+		return fmt.Errorf("failed to decode compliance request: %w", err)
+	}
+
+	err = handle()
+	if err != nil {
+		logger.Error("failed to handle compliance request", "err", err)
+		return fmt.Errorf("failed to handle compliance request: %w", err)
+	}
+
+	// Customer's data is erased, so its erasure request doesn't keep it either
+	err = s.storages.ComplianceRequest.Complete(ctx, complianceRequest.ID, time.Now(), event.Topic == WebhookTopicCustomersRedact)
+	if err != nil {
+		logger.Error("failed to update compliance request in storage", "err", err)
+		return fmt.Errorf("failed to update compliance request in storage: %w", err)
+	}
+
+	logger.Info("handled compliance request")
+	return nil
+}
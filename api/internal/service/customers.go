@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+const (
+	// WebhookTopicCustomersCreate is sent when customer is created.
+	WebhookTopicCustomersCreate = "customers/create"
+	// WebhookTopicCustomersUpdate is sent when customer is updated.
+	WebhookTopicCustomersUpdate = "customers/update"
+	// WebhookTopicCustomersDelete is sent when customer is deleted.
+	WebhookTopicCustomersDelete = "customers/delete"
+
+	// JobKindImportCustomers is a kind of jobs importing all store's customers.
+	JobKindImportCustomers = "customers.import"
+)
+
+// Customer is a customer of platform store.
+type Customer struct {
+	ID             string           `json:"id"`
+	FirstName      string           `json:"firstName"`
+	LastName       string           `json:"lastName"`
+	Email          string           `json:"email"`
+	Phone          string           `json:"phone"`
+	State          string           `json:"state"`
+	Tags           []string         `json:"tags"`
+	DefaultAddress *CustomerAddress `json:"defaultAddress"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	UpdatedAt      time.Time        `json:"updatedAt"`
+}
+
+// CustomerAddress is a postal address of the customer.
+type CustomerAddress struct {
+	Address1 string `json:"address1"`
+	Address2 string `json:"address2"`
+	City     string `json:"city"`
+	Province string `json:"province"`
+	Country  string `json:"country"`
+	Zip      string `json:"zip"`
+	Phone    string `json:"phone"`
+}
+
+type IterateCustomersOptions struct {
+	// UpdatedAtMin limits customers to the ones updated at or after the time.
+	UpdatedAtMin *time.Time
+}
+
+// customerService service implements CustomerService interface.
+type customerService struct {
+	apis     APIs
+	storages Storages
+	config   *config.Config
+	logger   logging.Logger
+}
+
+var _ CustomerService = (*customerService)(nil)
+
+func NewCustomerService(opts *Options) *customerService {
+	s := &customerService{
+		apis:     opts.Apis,
+		storages: opts.Storages,
+		config:   opts.Config,
+		logger:   opts.Logger.Named("Customer"),
+	}
+
+	opts.Webhooks.Register(WebhookTopicCustomersCreate, s.handleCustomersUpdateWebhook)
+	opts.Webhooks.Register(WebhookTopicCustomersUpdate, s.handleCustomersUpdateWebhook)
+	opts.Webhooks.Register(WebhookTopicCustomersDelete, s.handleCustomersDeleteWebhook)
+
+	return s
+}
+
+func (s *customerService) ImportCustomers(ctx context.Context, storeName string) error {
+	logger := s.logger.
+		Named("ImportCustomers").
+		WithContext(ctx).
+		With("storeName", storeName)
+
+	store, err := s.storages.Store.Get(ctx, storeName)
+	if err != nil {
+		logger.Error("failed to get store from storage", "err", err)
+		return fmt.Errorf("failed to get store from storage: %w", err)
+	}
+	if store == nil || !store.Installed {
+		logger.Info("store is not found or not installed")
+		return nil
+	}
+
+	// Customers left from the previous installation may not exist anymore
+	err = s.storages.Customer.DeleteByStore(ctx, storeName)
+	if err != nil {
+		logger.Error("failed to delete store's customers from storage", "err", err)
+		return fmt.Errorf("failed to delete store's customers from storage: %w", err)
+	}
+
+	imported := 0
+	customers := s.apis.Platform.WithConfig(ctx, store).IterateCustomers(ctx, IterateCustomersOptions{})
+	for customers.Next(ctx) {
+		customer := customers.Item()
+
+		entityCustomer, err := customerToEntity(storeName, customer)
+		if err != nil {
+			logger.Error("failed to convert customer", "err", err, "customer", customer.ID)
+			return fmt.Errorf("failed to convert customer: %w", err)
+		}
+
+		_, err = s.storages.Customer.Save(ctx, entityCustomer)
+		if err != nil {
+			logger.Error("failed to save customer in storage", "err", err, "customer", customer.ID)
+			return fmt.Errorf("failed to save customer in storage: %w", err)
+		}
+		imported++
+	}
+	if err = customers.Err(); err != nil {
+		logger.Error("failed to iterate customers", "err", err)
+		return fmt.Errorf("failed to iterate customers: %w", err)
+	}
+
+	logger.Info("imported customers", "imported", imported)
+	return nil
+}
+
+// customerWebhookPayload is a payload of customers/create and customers/update webhooks.
+type customerWebhookPayload struct {
+	AdminGraphQLAPIID string           `json:"admin_graphql_api_id"`
+	FirstName         string           `json:"first_name"`
+	LastName          string           `json:"last_name"`
+	Email             string           `json:"email"`
+	Phone             string           `json:"phone"`
+	State             string           `json:"state"`
+	Tags              string           `json:"tags"`
+	DefaultAddress    *CustomerAddress `json:"default_address"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
+
+// handleCustomersUpdateWebhook saves created or updated customer.
+func (s *customerService) handleCustomersUpdateWebhook(ctx context.Context, event WebhookEvent) error {
+	logger := s.logger.
+		Named("handleCustomersUpdateWebhook").
+		WithContext(ctx).
+		With("id", event.ID, "topic", event.Topic, "shop", event.Shop)
+
+	var payload customerWebhookPayload
+	err := json.Unmarshal(event.Payload, &payload)
+	if err != nil {
+		logger.Error("failed to decode webhook payload", "err", err)
+		return fmt.Errorf("failed to decode webhook payload: %w", err)
+	}
+	logger = logger.With("customer", payload.AdminGraphQLAPIID)
+
+	customer, err := customerToEntity(event.Shop.String(), Customer{
+		ID:             payload.AdminGraphQLAPIID,
+		FirstName:      payload.FirstName,
+		LastName:       payload.LastName,
+		Email:          payload.Email,
+		Phone:          payload.Phone,
+		State:          payload.State,
+		Tags:           SplitTags(payload.Tags),
+		DefaultAddress: payload.DefaultAddress,
+		CreatedAt:      payload.CreatedAt,
+		UpdatedAt:      payload.UpdatedAt,
+	})
+	if err != nil {
+		logger.Error("failed to convert customer", "err", err)
+		return fmt.Errorf("failed to convert customer: %w", err)
+	}
+
+	saved, err := s.storages.Customer.Save(ctx, customer)
+	if err != nil {
+		logger.Error("failed to save customer in storage", "err", err)
+		return fmt.Errorf("failed to save customer in storage: %w", err)
+	}
+	if !saved {
+		logger.Info("stored customer is more recent")
+		return nil
+	}
+
+	logger.Info("saved customer")
+	return nil
+}
+
+// handleCustomersDeleteWebhook deletes customer.
+func (s *customerService) handleCustomersDeleteWebhook(ctx context.Context, event WebhookEvent) error {
+	logger := s.logger.
+		Named("handleCustomersDeleteWebhook").
+		WithContext(ctx).
+		With("id", event.ID, "topic", event.Topic, "shop", event.Shop)
+
+	var payload struct {
+		ID int64 `json:"id"`
+	}
+	err := json.Unmarshal(event.Payload, &payload)
+	if err != nil {
+		logger.Error("failed to decode webhook payload", "err", err)
+		return fmt.Errorf("failed to decode webhook payload: %w", err)
+	}
+	id := customerGID(payload.ID)
+	logger = logger.With("customer", id)
+
+	err = s.storages.Customer.Delete(ctx, event.Shop.String(), id)
+	if err != nil {
+		logger.Error("failed to delete customer from storage", "err", err)
+		return fmt.Errorf("failed to delete customer from storage: %w", err)
+	}
+
+	logger.Info("deleted customer")
+	return nil
+}
+
+// customerGID returns global ID of the customer by its numeric ID, used by webhooks.
+func customerGID(id int64) string {
+	return fmt.Sprintf("gid://shopify/Customer/%d", id)
+}
+
+func customerToEntity(storeName string, customer Customer) (*entity.Customer, error) {
+	var address string
+	if customer.DefaultAddress != nil {
+		encoded, err := json.Marshal(customer.DefaultAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode address: %w", err)
+		}
+		address = string(encoded)
+	}
+
+	return &entity.Customer{
+		ID:                customer.ID,
+		StoreName:         storeName,
+		FirstName:         customer.FirstName,
+		LastName:          customer.LastName,
+		Email:             customer.Email,
+		Phone:             customer.Phone,
+		Address:           address,
+		State:             customer.State,
+		Tags:              customer.Tags,
+		PlatformCreatedAt: customer.CreatedAt,
+		PlatformUpdatedAt: customer.UpdatedAt,
+	}, nil
+}
+
+func customerFromEntity(customer *entity.Customer) (*Customer, error) {
+	var address *CustomerAddress
+	if customer.Address != "" {
+		err := json.Unmarshal([]byte(customer.Address), &address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode address: %w", err)
+		}
+	}
+
+	return &Customer{
+		ID:             customer.ID,
+		FirstName:      customer.FirstName,
+		LastName:       customer.LastName,
+		Email:          customer.Email,
+		Phone:          customer.Phone,
+		State:          customer.State,
+		Tags:           customer.Tags,
+		DefaultAddress: address,
+		CreatedAt:      customer.PlatformCreatedAt,
+		UpdatedAt:      customer.PlatformUpdatedAt,
+	}, nil
+}
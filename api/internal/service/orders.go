@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/pkg/errs"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+	"github.com/softcery/shopify-app-template-go/pkg/queue"
+)
+
+const (
+	// JobKindSyncOrders is a kind of jobs fetching store's orders updated since the previous sync.
+	JobKindSyncOrders = "orders.sync"
+)
+
+var (
+	// ErrOrderNotFound is returned when order is not found in store.
+	ErrOrderNotFound = errs.New("order is not found")
+)
+
+// Order is an order of platform store.
+type Order struct {
+	ID                string             `json:"id"`
+	Name              string             `json:"name"`
+	Email             string             `json:"email"`
+	Currency          string             `json:"currency"`
+	FinancialStatus   string             `json:"financialStatus"`
+	FulfillmentStatus string             `json:"fulfillmentStatus"`
+	TotalPrice        string             `json:"totalPrice"`
+	SubtotalPrice     string             `json:"subtotalPrice"`
+	TotalTax          string             `json:"totalTax"`
+	LineItems         []OrderLineItem    `json:"lineItems"`
+	Fulfillments      []OrderFulfillment `json:"fulfillments"`
+	CreatedAt         time.Time          `json:"createdAt"`
+	UpdatedAt         time.Time          `json:"updatedAt"`
+	ProcessedAt       *time.Time         `json:"processedAt"`
+	CancelledAt       *time.Time         `json:"cancelledAt"`
+}
+
+// OrderLineItem is a purchased product of the order.
+type OrderLineItem struct {
+	ID string `json:"id"`
+	// ProductID and VariantID are empty if product has been deleted.
+	ProductID    string `json:"productId"`
+	VariantID    string `json:"variantId"`
+	Title        string `json:"title"`
+	VariantTitle string `json:"variantTitle"`
+	SKU          string `json:"sku"`
+	Quantity     int    `json:"quantity"`
+	Price        string `json:"price"`
+}
+
+// OrderFulfillment is a shipment of the order's line items.
+type OrderFulfillment struct {
+	ID              string    `json:"id"`
+	Status          string    `json:"status"`
+	TrackingCompany string    `json:"trackingCompany"`
+	TrackingNumbers []string  `json:"trackingNumbers"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+type IterateOrdersOptions struct {
+	// UpdatedAtMin limits orders to the ones updated at or after the time.
+	UpdatedAtMin *time.Time
+}
+
+// OrderFilter filters store's orders. Empty fields are ignored.
+type OrderFilter struct {
+	StoreName         string
+	FinancialStatus   string
+	FulfillmentStatus string
+	CreatedAtMin      *time.Time
+	CreatedAtMax      *time.Time
+	// Query matches order's name. Email isn't matched, since it's encrypted at rest.
+	Query  string
+	Limit  int
+	Offset int
+}
+
+type ListOrdersOptions struct {
+	FinancialStatus   string
+	FulfillmentStatus string
+	CreatedAtMin      *time.Time
+	CreatedAtMax      *time.Time
+	Query             string
+	Limit             int
+	Offset            int
+}
+
+type OrdersPage struct {
+	Orders []Order `json:"orders"`
+	// Total is a number of orders matching the filter.
+	Total int64 `json:"total"`
+}
+
+// orderService service implements OrderService interface.
+type orderService struct {
+	apis     APIs
+	storages Storages
+	queue    JobQueue
+	config   *config.Config
+	logger   logging.Logger
+}
+
+var _ OrderService = (*orderService)(nil)
+
+func NewOrderService(opts *Options) *orderService {
+	return &orderService{
+		apis:     opts.Apis,
+		storages: opts.Storages,
+		queue:    opts.Queue,
+		config:   opts.Config,
+		logger:   opts.Logger.Named("Order"),
+	}
+}
+
+func (s *orderService) SyncOrders(ctx context.Context, storeName string) error {
+	logger := s.logger.
+		Named("SyncOrders").
+		WithContext(ctx).
+		With("storeName", storeName)
+
+	store, err := s.storages.Store.Get(ctx, storeName)
+	if err != nil {
+		logger.Error("failed to get store from storage", "err", err)
+		return fmt.Errorf("failed to get store from storage: %w", err)
+	}
+	if store == nil || !store.Installed {
+		logger.Info("store is not found or not installed")
+		return nil
+	}
+	updatedAtMin, err := s.storages.Order.GetLastUpdatedAt(ctx, storeName)
+	if err != nil {
+		logger.Error("failed to get last order update time from storage", "err", err)
+		return fmt.Errorf("failed to get last order update time from storage: %w", err)
+	}
+	logger = logger.With("updatedAtMin", updatedAtMin)
+
+	syncedAt := time.Now()
+	synced := 0
+	orders := s.apis.Platform.WithConfig(ctx, store).IterateOrders(ctx, IterateOrdersOptions{
+		UpdatedAtMin: updatedAtMin,
+	})
+	for orders.Next(ctx) {
+		order := orders.Item()
+		_, err = s.storages.Order.Save(ctx, orderToEntity(storeName, order))
+		if err != nil {
+			logger.Error("failed to save order in storage", "err", err, "order", order.ID)
+			return fmt.Errorf("failed to save order in storage: %w", err)
+		}
+		synced++
+	}
+	if err = orders.Err(); err != nil {
+		logger.Error("failed to iterate orders", "err", err)
+		return fmt.Errorf("failed to iterate orders: %w", err)
+	}
+
+	_, err = s.storages.Store.Update(ctx, &entity.Store{
+		Name:           storeName,
+		OrdersSyncedAt: &syncedAt,
+	})
+	if err != nil {
+		logger.Error("failed to update store in storage", "err", err)
+		return fmt.Errorf("failed to update store in storage: %w", err)
+	}
+
+	logger.Info("synced orders", "synced", synced)
+	return nil
+}
+
+func (s *orderService) ScheduleOrdersSync(ctx context.Context) error {
+	logger := s.logger.Named("ScheduleOrdersSync").WithContext(ctx)
+
+	stores, err := s.storages.Store.GetInstalled(ctx)
+	if err != nil {
+		logger.Error("failed to get installed stores from storage", "err", err)
+		return fmt.Errorf("failed to get installed stores from storage: %w", err)
+	}
+	logger = logger.With("storesCount", len(stores))
+
+	// Schedule every store, even if some of them fail
+	var failed []string
+	for _, store := range stores {
+		err = enqueueOrdersSync(ctx, s.queue, store.Name)
+		if err != nil {
+			logger.Error("failed to enqueue store's orders sync", "err", err, "storeName", store.Name)
+			failed = append(failed, store.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to enqueue orders sync of %d stores: %v", len(failed), failed)
+	}
+
+	logger.Debug("scheduled orders sync of all installed stores")
+	return nil
+}
+
+// enqueueOrdersSync enqueues orders sync of the store. Sync is skipped if store's sync is enqueued already,
+// so a store is synced by a single job at a time however many times it's scheduled.
+func enqueueOrdersSync(ctx context.Context, jobQueue JobQueue, storeName string) error {
+	return jobQueue.Enqueue(ctx, JobKindSyncOrders, storeName,
+		queue.Key(storeName),
+		queue.Unique(JobKindSyncOrders+":"+storeName),
+	)
+}
+
+func (s *orderService) ListOrders(ctx context.Context, opts ListOrdersOptions) (*OrdersPage, error) {
+	logger := s.logger.
+		Named("ListOrders").
+		WithContext(ctx).
+		With("opts", opts)
+
+	store, err := getSessionStore(ctx, s.apis, s.storages)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, err
+		}
+		logger.Error("failed to get session store", "err", err)
+		return nil, fmt.Errorf("failed to get session store: %w", err)
+	}
+
+	orders, total, err := s.storages.Order.List(ctx, OrderFilter{
+		StoreName:         store.Name,
+		FinancialStatus:   opts.FinancialStatus,
+		FulfillmentStatus: opts.FulfillmentStatus,
+		CreatedAtMin:      opts.CreatedAtMin,
+		CreatedAtMax:      opts.CreatedAtMax,
+		Query:             opts.Query,
+		Limit:             opts.Limit,
+		Offset:            opts.Offset,
+	})
+	if err != nil {
+		logger.Error("failed to list orders from storage", "err", err)
+		return nil, fmt.Errorf("failed to list orders from storage: %w", err)
+	}
+
+	page := &OrdersPage{
+		Orders: make([]Order, 0, len(orders)),
+		Total:  total,
+	}
+	for _, order := range orders {
+		page.Orders = append(page.Orders, orderFromEntity(order))
+	}
+
+	return page, nil
+}
+
+func (s *orderService) GetOrder(ctx context.Context, id string) (*Order, error) {
+	logger := s.logger.
+		Named("GetOrder").
+		WithContext(ctx).
+		With("id", id)
+
+	store, err := getSessionStore(ctx, s.apis, s.storages)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, err
+		}
+		logger.Error("failed to get session store", "err", err)
+		return nil, fmt.Errorf("failed to get session store: %w", err)
+	}
+
+	// Orders are stored by their global IDs, but UI may use numeric ones
+	if !strings.HasPrefix(id, "gid://") {
+		id = "gid://shopify/Order/" + id
+	}
+
+	order, err := s.storages.Order.Get(ctx, store.Name, id)
+	if err != nil {
+		logger.Error("failed to get order from storage", "err", err)
+		return nil, fmt.Errorf("failed to get order from storage: %w", err)
+	}
+	if order == nil {
+		logger.Info("order is not found")
+		return nil, ErrOrderNotFound
+	}
+
+	result := orderFromEntity(order)
+	return &result, nil
+}
+
+// orderGID returns global ID of the order by its numeric ID, used by webhooks.
+func orderGID(id int64) string {
+	return fmt.Sprintf("gid://shopify/Order/%d", id)
+}
+
+func orderToEntity(storeName string, order Order) *entity.Order {
+	lineItems := make([]entity.OrderLineItem, 0, len(order.LineItems))
+	for _, item := range order.LineItems {
+		lineItems = append(lineItems, entity.OrderLineItem(item))
+	}
+
+	fulfillments := make([]entity.OrderFulfillment, 0, len(order.Fulfillments))
+	for _, fulfillment := range order.Fulfillments {
+		fulfillments = append(fulfillments, entity.OrderFulfillment(fulfillment))
+	}
+
+	return &entity.Order{
+		ID:                order.ID,
+		StoreName:         storeName,
+		Name:              order.Name,
+		Email:             order.Email,
+		Currency:          order.Currency,
+		FinancialStatus:   order.FinancialStatus,
+		FulfillmentStatus: order.FulfillmentStatus,
+		TotalPrice:        order.TotalPrice,
+		SubtotalPrice:     order.SubtotalPrice,
+		TotalTax:          order.TotalTax,
+		LineItems:         lineItems,
+		Fulfillments:      fulfillments,
+		PlatformCreatedAt: order.CreatedAt,
+		PlatformUpdatedAt: order.UpdatedAt,
+		ProcessedAt:       order.ProcessedAt,
+		CancelledAt:       order.CancelledAt,
+	}
+}
+
+func orderFromEntity(order *entity.Order) Order {
+	lineItems := make([]OrderLineItem, 0, len(order.LineItems))
+	for _, item := range order.LineItems {
+		lineItems = append(lineItems, OrderLineItem(item))
+	}
+
+	fulfillments := make([]OrderFulfillment, 0, len(order.Fulfillments))
+	for _, fulfillment := range order.Fulfillments {
+		fulfillments = append(fulfillments, OrderFulfillment(fulfillment))
+	}
+
+	return Order{
+		ID:                order.ID,
+		Name:              order.Name,
+		Email:             order.Email,
+		Currency:          order.Currency,
+		FinancialStatus:   order.FinancialStatus,
+		FulfillmentStatus: order.FulfillmentStatus,
+		TotalPrice:        order.TotalPrice,
+		SubtotalPrice:     order.SubtotalPrice,
+		TotalTax:          order.TotalTax,
+		LineItems:         lineItems,
+		Fulfillments:      fulfillments,
+		CreatedAt:         order.PlatformCreatedAt,
+		UpdatedAt:         order.PlatformUpdatedAt,
+		ProcessedAt:       order.ProcessedAt,
+		CancelledAt:       order.CancelledAt,
+	}
+}
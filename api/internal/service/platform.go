@@ -2,37 +2,71 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/softcery/shopify-app-template-go/config"
 	"github.com/softcery/shopify-app-template-go/internal/entity"
 	"github.com/softcery/shopify-app-template-go/pkg/errs"
 	"github.com/softcery/shopify-app-template-go/pkg/logging"
+	"github.com/softcery/shopify-app-template-go/pkg/queue"
 )
 
 // platformService service implements PlatformService interface.
 type platformService struct {
-	apis     APIs
-	storages Storages
-	config   *config.Config
-	logger   logging.Logger
+	apis       APIs
+	storages   Storages
+	webhooks   *WebhookRegistry
+	queue      JobQueue
+	compliance ComplianceHandler
+	config     *config.Config
+	logger     logging.Logger
 }
 
 var _ PlatformService = (*platformService)(nil)
 
 func NewPlatformService(opts *Options) *platformService {
-	return &platformService{
-		apis:     opts.Apis,
-		storages: opts.Storages,
-		config:   opts.Config,
-		logger:   opts.Logger.Named("Platform"),
+	s := &platformService{
+		apis:       opts.Apis,
+		storages:   opts.Storages,
+		webhooks:   opts.Webhooks,
+		queue:      opts.Queue,
+		compliance: opts.Compliance,
+		config:     opts.Config,
+		logger:     opts.Logger.Named("Platform"),
 	}
+	if s.compliance == nil {
+		s.compliance = noopComplianceHandler{}
+	}
+
+	s.webhooks.Register(WebhookTopicAppUninstalled, s.handleAppUninstalledWebhook)
+	s.webhooks.Register(WebhookTopicCustomersDataRequest, s.handleCustomersDataRequestWebhook)
+	s.webhooks.Register(WebhookTopicCustomersRedact, s.handleCustomersRedactWebhook)
+	s.webhooks.Register(WebhookTopicShopRedact, s.handleShopRedactWebhook)
+	s.webhooks.Register(WebhookTopicShopUpdate, s.handleShopUpdateWebhook)
+
+	return s
 }
 
 func (s *platformService) Handle(ctx context.Context, storeName, installationURL string) (string, error) {
 	logger := s.logger.Named("Handle").WithContext(ctx)
 
+	shop, err := s.parseShopDomain(storeName)
+	if err != nil {
+		logger.Info(err.Error(), "storeName", storeName)
+		return "", err
+	}
+	storeName = shop.String()
+
+	// Verify the request is signed by platform
+	err = s.apis.Platform.VerifyRequestURL(ctx, installationURL)
+	if err != nil {
+		logger.Info(err.Error())
+		return "", err
+	}
+
 	// Check if store is not already installed
 	store, err := s.storages.Store.Get(ctx, storeName)
 	if err != nil {
@@ -47,7 +81,7 @@ func (s *platformService) Handle(ctx context.Context, storeName, installationURL
 		return fmt.Sprintf("https://%s/admin/apps/%s/exit-iframe", storeName, s.config.Shopify.ApiKey), nil
 	}
 
-	res, err := s.apis.Platform.HandleInstall(HandleInstallOptions{
+	res, err := s.apis.Platform.HandleInstall(ctx, HandleInstallOptions{
 		InstallationURL: installationURL,
 		RedirectURL:     s.config.App.BaseURL + "/auth/callback",
 		StoreName:       storeName,
@@ -59,11 +93,10 @@ func (s *platformService) Handle(ctx context.Context, storeName, installationURL
 	logger = logger.With("res", res)
 	logger.Debug("handled install on api side")
 
-	// Create new instance of a store in db or update existing one with nonce
+	// Create new instance of a store in db if it doesn't exist yet
 	if store == nil {
 		createdStore, err := s.storages.Store.Create(ctx, &entity.Store{
 			Name:      storeName,
-			Nonce:     res.Nonce,
 			Installed: false,
 		})
 		if err != nil {
@@ -71,87 +104,171 @@ func (s *platformService) Handle(ctx context.Context, storeName, installationURL
 			return "", fmt.Errorf("failed to create store in storage: %w", err)
 		}
 		logger = logger.With("createdStore", createdStore)
-	} else {
-		updatedStore, err := s.storages.Store.Update(ctx, &entity.Store{
-			Name:      storeName,
-			Nonce:     res.Nonce,
-			Installed: false,
-		})
-		if err != nil {
-			logger.Error("failed to updated store in storage", "err", err)
-			return "", fmt.Errorf("failed to create store in storage: %w", err)
-		}
-		logger = logger.With("updatedStore", updatedStore)
 	}
-	logger.Info("got redirect url and saved store's nonce into db")
+
+	// Save single-use oauth state, which will be verified on redirect
+	now := time.Now()
+	_, err = s.storages.OAuthState.Create(ctx, &entity.OAuthState{
+		State:     res.Nonce,
+		StoreName: storeName,
+		ExpiresAt: now.Add(s.config.Shopify.OAuthStateTTL),
+	})
+	if err != nil {
+		logger.Error("failed to create oauth state in storage", "err", err)
+		return "", fmt.Errorf("failed to create oauth state in storage: %w", err)
+	}
+
+	err = s.storages.OAuthState.DeleteExpired(ctx, now)
+	if err != nil {
+		logger.Error("failed to delete expired oauth states from storage", "err", err)
+		return "", fmt.Errorf("failed to delete expired oauth states from storage: %w", err)
+	}
+	logger.Info("got redirect url and saved store's oauth state into db")
 
 	return res.RedirectURL, nil
 }
 
-func (s *platformService) HandleRedirect(ctx context.Context, opts ServiceHandleRedirectOptions) error {
+func (s *platformService) HandleRedirect(ctx context.Context, opts ServiceHandleRedirectOptions) (string, error) {
 	logger := s.logger.
 		Named("HandleRedirect").
+		WithContext(ctx).
 		With("opts", opts)
 
+	shop, err := s.parseShopDomain(opts.StoreName)
+	if err != nil {
+		logger.Info(err.Error())
+		return "", err
+	}
+	opts.StoreName = shop.String()
+
+	// Verify the request is signed by platform
+	err = s.apis.Platform.VerifyRequestURL(ctx, opts.RedirectedURL)
+	if err != nil {
+		logger.Info(err.Error())
+		return "", err
+	}
+
 	// Check if store exists
 	store, err := s.storages.Store.Get(ctx, opts.StoreName)
 	if err != nil {
 		logger.Error("failed to get store from storage", "err", err)
-		return fmt.Errorf("failed to get store from storage: %w", err)
+		return "", fmt.Errorf("failed to get store from storage: %w", err)
 	}
 	if store == nil {
 		logger.Info("store is not found")
-		return ErrHandleRedirectStoreNotFound
+		return "", ErrHandleRedirectStoreNotFound
 	}
 	logger = logger.With("store", store)
 	logger.Debug("got store")
 
-	accessToken, err := s.apis.Platform.HandleRedirect(APIHandleRedirectOptions{
-		Nonce:         store.Nonce,
+	// Consume oauth state, so it can't be reused
+	oauthState, err := s.storages.OAuthState.Consume(ctx, opts.State)
+	if err != nil {
+		logger.Error("failed to consume oauth state", "err", err)
+		return "", fmt.Errorf("failed to consume oauth state: %w", err)
+	}
+	if oauthState == nil || oauthState.StoreName != opts.StoreName {
+		logger.Info("oauth state is not found")
+		return "", ErrHandleRedirectInvalidState
+	}
+	if time.Now().After(oauthState.ExpiresAt) {
+		logger.Info("oauth state has expired")
+		return "", ErrHandleRedirectStateExpired
+	}
+	logger.Debug("consumed oauth state")
+
+	accessToken, err := s.apis.Platform.HandleRedirect(ctx, APIHandleRedirectOptions{
+		Nonce:         oauthState.State,
 		RedirectedURL: opts.RedirectedURL,
 		StoreName:     opts.StoreName,
 	})
 	if err != nil {
 		if errs.IsExpected(err) {
 			logger.Info(err.Error())
-			return err
+			return "", err
 		}
 		logger.Error("failed to handle redirect at API ", "err", err)
-		return fmt.Errorf("failed to retrieve access token from api: %w", err)
+		return "", fmt.Errorf("failed to retrieve access token from api: %w", err)
 	}
 	logger.Debug("got access token")
 
-	err = s.apis.Platform.SubscribeToAppUninstallWebhook(SubscribeToAppUninstallWebhookOptions{
-		RedirectURL: fmt.Sprintf("%s/uninstall?shop=%s", s.config.App.BaseURL, opts.StoreName),
-		StoreName:   opts.StoreName,
-		AccessToken: accessToken,
+	reconciled, err := s.apis.Platform.ReconcileWebhooks(ctx, ReconcileWebhooksOptions{
+		Subscriptions: s.webhookSubscriptions(),
+		StoreName:     opts.StoreName,
+		AccessToken:   accessToken,
 	})
 	if err != nil {
-		logger.Error("failed to subscribe to app uninstalled webhook", "err", err)
-		return fmt.Errorf("failed to subscribe to app uninstalled webhook: %w", err)
+		logger.Error("failed to reconcile webhooks", "err", err)
+		return "", fmt.Errorf("failed to reconcile webhooks: %w", err)
 	}
-	logger.Debug("subscribed to webhook")
+	logger.Debug("reconciled webhooks", "reconciled", reconciled)
 
-	updatedStore, err := s.storages.Store.Update(ctx, &entity.Store{
+	shopMetadata, err := s.apis.Platform.WithConfig(ctx, &entity.Store{
 		Name:        opts.StoreName,
 		AccessToken: accessToken,
-		Installed:   true,
-	})
+	}).GetShop(ctx)
+	if err != nil {
+		logger.Error("failed to get shop from api", "err", err)
+		return "", fmt.Errorf("failed to get shop from api: %w", err)
+	}
+	logger.Debug("got shop", "shopMetadata", shopMetadata)
+
+	installedStore := shopToEntity(opts.StoreName, *shopMetadata)
+	installedStore.AccessToken = accessToken
+	installedStore.Installed = true
+	updatedStore, err := s.storages.Store.Update(ctx, installedStore)
 	if err != nil {
 		logger.Error("failed to update store in storage", "err", err)
-		return fmt.Errorf("failed to update store in storage: %w", err)
+		return "", fmt.Errorf("failed to update store in storage: %w", err)
 	}
 	logger = logger.With("updatedStore", updatedStore)
 	logger.Info("updated store")
 
-	return nil
+	// Store is installed already, so failing to enqueue its data sync mustn't fail installation
+	err = s.queue.Enqueue(ctx, JobKindImportProducts, opts.StoreName, queue.Key(opts.StoreName))
+	if err != nil {
+		logger.Error("failed to enqueue products import", "err", err)
+	}
+
+	err = enqueueOrdersSync(ctx, s.queue, opts.StoreName)
+	if err != nil {
+		logger.Error("failed to enqueue orders sync", "err", err)
+	}
+
+	err = s.queue.Enqueue(ctx, JobKindImportCustomers, opts.StoreName, queue.Key(opts.StoreName))
+	if err != nil {
+		logger.Error("failed to enqueue customers import", "err", err)
+	}
+
+	// Store owner approves subscription before using the app
+	if len(s.config.Billing.Plans) > 0 && updatedStore.SubscriptionStatus != AppSubscriptionStatusActive {
+		confirmationURL, err := createAppSubscription(ctx, s.apis, s.config, updatedStore, s.config.Billing.Plans[0])
+		if err != nil {
+			logger.Error("failed to create app subscription", "err", err)
+			return "", fmt.Errorf("failed to create app subscription: %w", err)
+		}
+
+		logger.Info("created app subscription")
+		return confirmationURL, nil
+	}
+
+	// After successful installation, user is redirected to app's UI at their platform store
+	return appAdminURL(s.config, opts.StoreName), nil
 }
 
 func (s *platformService) HandleUninstall(ctx context.Context, storeName string) error {
 	logger := s.logger.
 		Named("HandleUninstall").
+		WithContext(ctx).
 		With("storeName", storeName)
 
+	shop, err := s.parseShopDomain(storeName)
+	if err != nil {
+		logger.Info(err.Error())
+		return err
+	}
+	storeName = shop.String()
+
 	store, err := s.storages.Store.Get(ctx, storeName)
 	if err != nil {
 		logger.Error("failed to get store from storage", "err", err)
@@ -174,58 +291,268 @@ func (s *platformService) HandleUninstall(ctx context.Context, storeName string)
 	return nil
 }
 
-func (s *platformService) CreateProducts(ctx context.Context) error {
-	logger := s.logger.Named("CreateProducts").WithContext(ctx)
+func (s *platformService) ReconcileWebhooks(ctx context.Context) error {
+	logger := s.logger.Named("ReconcileWebhooks").WithContext(ctx)
+
+	stores, err := s.storages.Store.GetInstalled(ctx)
+	if err != nil {
+		logger.Error("failed to get installed stores from storage", "err", err)
+		return fmt.Errorf("failed to get installed stores from storage: %w", err)
+	}
+	logger = logger.With("storesCount", len(stores))
+	logger.Debug("got installed stores")
+
+	// Reconcile every store, even if some of them fail
+	var failed []string
+	subscriptions := s.webhookSubscriptions()
+	for _, store := range stores {
+		reconciled, err := s.apis.Platform.ReconcileWebhooks(ctx, ReconcileWebhooksOptions{
+			Subscriptions: subscriptions,
+			StoreName:     store.Name,
+			AccessToken:   store.AccessToken,
+		})
+		if err != nil {
+			logger.Error("failed to reconcile store's webhooks", "err", err, "storeName", store.Name)
+			failed = append(failed, store.Name)
+			continue
+		}
+		logger.Debug("reconciled store's webhooks", "storeName", store.Name, "reconciled", reconciled)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to reconcile webhooks of %d stores: %v", len(failed), failed)
+	}
+
+	logger.Info("reconciled webhooks of all installed stores")
+	return nil
+}
+
+func (s *platformService) SyncShops(ctx context.Context) error {
+	logger := s.logger.Named("SyncShops").WithContext(ctx)
 
-	output, err := s.apis.Platform.VerifySession(ctx)
+	stores, err := s.storages.Store.GetInstalled(ctx)
 	if err != nil {
-		logger.Error("failed to verify session", "err", err)
-		return fmt.Errorf("failed to verify session: %w", err)
+		logger.Error("failed to get installed stores from storage", "err", err)
+		return fmt.Errorf("failed to get installed stores from storage: %w", err)
+	}
+	logger = logger.With("storesCount", len(stores))
+	logger.Debug("got installed stores")
+
+	// Sync every store, even if some of them fail
+	var failed []string
+	for _, store := range stores {
+		shop, err := s.apis.Platform.WithConfig(ctx, store).GetShop(ctx)
+		if err != nil {
+			logger.Error("failed to get shop from api", "err", err, "storeName", store.Name)
+			failed = append(failed, store.Name)
+			continue
+		}
+
+		_, err = s.storages.Store.UpdateShop(ctx, shopToEntity(store.Name, *shop))
+		if err != nil {
+			logger.Error("failed to update store in storage", "err", err, "storeName", store.Name)
+			failed = append(failed, store.Name)
+			continue
+		}
+		logger.Debug("synced store's shop", "storeName", store.Name, "planName", shop.PlanName)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to sync shops of %d stores: %v", len(failed), failed)
 	}
-	if !output.IsVerified {
-		logger.Info("invalid session")
-		return errors.New("invalid session")
+
+	logger.Info("synced shops of all installed stores")
+	return nil
+}
+
+// webhookSubscriptions returns desired webhook subscriptions of every store.
+func (s *platformService) webhookSubscriptions() []WebhookSubscription {
+	topics := s.webhooks.Topics()
+	subscriptions := make([]WebhookSubscription, 0, len(topics))
+	for _, topic := range topics {
+		subscriptions = append(subscriptions, WebhookSubscription{
+			Topic:   topic,
+			Address: s.config.App.BaseURL + "/webhooks",
+			Format:  "json",
+		})
+	}
+	return subscriptions
+}
+
+// handleAppUninstalledWebhook deletes store, which app is uninstalled from.
+func (s *platformService) handleAppUninstalledWebhook(ctx context.Context, event WebhookEvent) error {
+	err := s.HandleUninstall(ctx, event.Shop.String())
+	// Store is already deleted, e.g. by the previous delivery of the webhook, so there is nothing to retry
+	if errors.Is(err, ErrHandleUninstallStoreNotFound) {
+		return nil
+	}
+	return err
+}
+
+// handleShopUpdateWebhook refreshes store's metadata, e.g. once store's plan is changed.
+func (s *platformService) handleShopUpdateWebhook(ctx context.Context, event WebhookEvent) error {
+	logger := s.logger.
+		Named("handleShopUpdateWebhook").
+		WithContext(ctx).
+		With("id", event.ID, "topic", event.Topic, "shop", event.Shop)
+
+	var payload shopWebhookPayload
+	err := json.Unmarshal(event.Payload, &payload)
+	if err != nil {
+		logger.Error("failed to decode webhook payload", "err", err)
+		return fmt.Errorf("failed to decode webhook payload: %w", err)
 	}
 
-	store, err := s.storages.Store.Get(ctx, output.StoreName)
+	store, err := s.storages.Store.Get(ctx, event.Shop.String())
 	if err != nil {
 		logger.Error("failed to get store from storage", "err", err)
 		return fmt.Errorf("failed to get store from storage: %w", err)
 	}
+	if store == nil {
+		logger.Info("store is not found")
+		return nil
+	}
 
-	err = s.apis.Platform.WithConfig(ctx, store).CreateProducts(ctx)
+	updated, err := s.storages.Store.UpdateShop(ctx, shopToEntity(store.Name, Shop{
+		ID:              payload.ID,
+		Name:            payload.Name,
+		Email:           payload.Email,
+		Currency:        payload.Currency,
+		IANATimezone:    payload.IANATimezone,
+		PlanName:        payload.PlanName,
+		PlanDisplayName: payload.PlanDisplayName,
+		PrimaryDomain:   payload.Domain,
+		CountryCode:     payload.CountryCode,
+		MyshopifyDomain: payload.MyshopifyDomain,
+		UpdatedAt:       payload.UpdatedAt,
+	}))
 	if err != nil {
-		logger.Error("failed to create products", "err", err)
-		return fmt.Errorf("failed to create products: %w", err)
+		logger.Error("failed to update store in storage", "err", err)
+		return fmt.Errorf("failed to update store in storage: %w", err)
+	}
+	if !updated {
+		logger.Info("shop's metadata is outdated", "updatedAt", payload.UpdatedAt)
+		return nil
 	}
 
+	if store.PlanName != payload.PlanName {
+		logger.Info("store's plan is changed", "oldPlan", store.PlanName, "newPlan", payload.PlanName)
+	}
+
+	logger.Info("updated store's metadata")
 	return nil
 }
 
+func (s *platformService) VerifyWebhook(ctx context.Context, opts ServiceVerifyWebhookOptions) (ShopDomain, error) {
+	logger := s.logger.
+		Named("VerifyWebhook").
+		WithContext(ctx).
+		With("storeName", opts.StoreName)
+
+	err := s.apis.Platform.VerifyWebhook(ctx, VerifyWebhookOptions{
+		Body: opts.Body,
+		HMAC: opts.HMAC,
+	})
+	if err != nil {
+		logger.Info(err.Error())
+		return "", err
+	}
+
+	shop, err := s.parseShopDomain(opts.StoreName)
+	if err != nil {
+		logger.Info(err.Error())
+		return "", err
+	}
+	logger.Debug("verified webhook")
+
+	return shop, nil
+}
+
+func (s *platformService) CreateProducts(ctx context.Context, opts CreateProductsOptions) ([]CreateProductResult, error) {
+	logger := s.logger.
+		Named("CreateProducts").
+		WithContext(ctx).
+		With("count", opts.Count, "templates", len(opts.Templates))
+
+	if opts.Count == 0 {
+		opts.Count = DEFAULT_PRODUCT_COUNT
+	}
+	if opts.Count < 0 || opts.Count > MaxCreateProductsCount {
+		logger.Info(ErrCreateProductsInvalidCount.Error())
+		return nil, ErrCreateProductsInvalidCount
+	}
+
+	store, err := getSessionStore(ctx, s.apis, s.storages)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, err
+		}
+		logger.Error("failed to get session store", "err", err)
+		return nil, fmt.Errorf("failed to get session store: %w", err)
+	}
+
+	results := s.apis.Platform.WithConfig(ctx, store).CreateProducts(ctx, opts)
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		logger.Info("failed to create some products", "failed", failed)
+	}
+
+	logger.Info("created products", "created", len(results)-failed)
+	return results, nil
+}
+
 func (s *platformService) GetProductsCount(ctx context.Context) (int, error) {
 	logger := s.logger.Named("GetProductsCount").WithContext(ctx)
 
-	output, err := s.apis.Platform.VerifySession(ctx)
+	store, err := getSessionStore(ctx, s.apis, s.storages)
 	if err != nil {
-		logger.Error("failed to verify session", "err", err)
-		return 0, fmt.Errorf("failed to verify session: %w", err)
-	}
-	if !output.IsVerified {
-		logger.Info("invalid session")
-		return 0, errors.New("invalid session")
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return 0, err
+		}
+		logger.Error("failed to get session store", "err", err)
+		return 0, fmt.Errorf("failed to get session store: %w", err)
 	}
 
-	store, err := s.storages.Store.Get(ctx, output.StoreName)
+	// Products are counted in local catalog, which is kept in sync with platform
+	count, err := s.storages.Product.Count(ctx, store.Name)
 	if err != nil {
-		logger.Error("failed to get store from storage", "err", err)
-		return 0, fmt.Errorf("failed to get store from storage: %w", err)
+		logger.Error("failed to count products in storage", "err", err)
+		return 0, fmt.Errorf("failed to count products in storage: %w", err)
 	}
 
-	count, err := s.apis.Platform.WithConfig(ctx, store).GetProductsCount(ctx)
+	return int(count), nil
+}
+
+// getSessionStore verifies session and returns the store it belongs to.
+func getSessionStore(ctx context.Context, apis APIs, storages Storages) (*entity.Store, error) {
+	output, err := apis.Platform.VerifySession(ctx)
+	if err != nil || !output.IsVerified {
+		return nil, ErrInvalidSession
+	}
+
+	store, err := storages.Store.Get(ctx, output.StoreName)
 	if err != nil {
-		logger.Error("failed to get product count", "err", err)
-		return 0, fmt.Errorf("failed to get product count: %w", err)
+		return nil, fmt.Errorf("failed to get store from storage: %w", err)
+	}
+	if store == nil || !store.Installed {
+		return nil, ErrSessionStoreNotFound
 	}
 
-	return count, nil
+	return store, nil
+}
+
+// appAdminURL returns URL of app's UI at platform store.
+func appAdminURL(cfg *config.Config, storeName string) string {
+	return fmt.Sprintf("https://%s/admin/apps/%s", storeName, cfg.Shopify.ApiKey)
+}
+
+// parseShopDomain validates and normalizes the shop domain using configured allowlist.
+func (s *platformService) parseShopDomain(storeName string) (ShopDomain, error) {
+	return ParseShopDomain(storeName, s.config.Shopify.AllowedShopDomains)
 }
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/pkg/errs"
+)
+
+const (
+	// ProductStatusActive is a status of the product available for sale.
+	ProductStatusActive = "ACTIVE"
+	// ProductStatusDraft is a status of the product not ready for sale.
+	ProductStatusDraft = "DRAFT"
+	// ProductStatusArchived is a status of the product no longer sold.
+	ProductStatusArchived = "ARCHIVED"
+)
+
+// MaxCreateProductsCount is a maximum number of products created at once.
+const MaxCreateProductsCount = 100
+
+var (
+	// ErrProductNotFound is returned when product is not found in store.
+	ErrProductNotFound = errs.New("product is not found")
+	// ErrCreateProductsInvalidCount is returned when number of products to create is out of range.
+	ErrCreateProductsInvalidCount = errs.New(fmt.Sprintf("count must be between 1 and %d", MaxCreateProductsCount))
+)
+
+// Product is a product of platform store.
+type Product struct {
+	ID              string           `json:"id"`
+	Title           string           `json:"title"`
+	DescriptionHTML string           `json:"descriptionHtml"`
+	Handle          string           `json:"handle"`
+	Status          string           `json:"status"`
+	Vendor          string           `json:"vendor"`
+	ProductType     string           `json:"productType"`
+	Tags            []string         `json:"tags"`
+	Options         []ProductOption  `json:"options"`
+	Variants        []ProductVariant `json:"variants"`
+	Images          []ProductImage   `json:"images"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	UpdatedAt       time.Time        `json:"updatedAt"`
+}
+
+// ProductOption is a product's property variants differ by, e.g. size or color.
+type ProductOption struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// ProductVariant is a purchasable variant of the product.
+type ProductVariant struct {
+	ID                string                  `json:"id"`
+	Title             string                  `json:"title"`
+	SKU               string                  `json:"sku"`
+	Barcode           string                  `json:"barcode"`
+	Price             string                  `json:"price"`
+	CompareAtPrice    *string                 `json:"compareAtPrice"`
+	InventoryQuantity int                     `json:"inventoryQuantity"`
+	SelectedOptions   []ProductSelectedOption `json:"selectedOptions"`
+}
+
+// ProductSelectedOption is an option value of the variant.
+type ProductSelectedOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ProductImage is an image of the product.
+type ProductImage struct {
+	ID      string `json:"id"`
+	URL     string `json:"url"`
+	AltText string `json:"altText"`
+}
+
+// ProductFilter filters store's products. Empty fields are ignored.
+type ProductFilter struct {
+	Title        string
+	Status       string
+	Vendor       string
+	ProductType  string
+	Tag          string
+	UpdatedAtMin *time.Time
+}
+
+type IterateProductsOptions struct {
+	Filter ProductFilter
+}
+
+type ListProductsOptions struct {
+	Filter ProductFilter
+	// Limit is a maximum number of products in the page.
+	Limit int
+	// Cursor is a cursor of the page returned with the previous page.
+	Cursor string
+}
+
+type ProductsPage struct {
+	Products []Product `json:"products"`
+	// NextCursor is a cursor of the next page, it is empty if the page is the last one.
+	NextCursor string `json:"nextCursor"`
+}
+
+// UpdateProductOptions contains product's fields to update. Nil fields are left unchanged.
+type UpdateProductOptions struct {
+	ID              string
+	Title           *string
+	DescriptionHTML *string
+	Vendor          *string
+	ProductType     *string
+	Tags            []string
+	Status          *string
+	Variants        []UpdateProductVariantOptions
+	// Images are added to the product's images.
+	Images []CreateProductImageOptions
+}
+
+type CreateProductsOptions struct {
+	// Count is a number of products to create, DEFAULT_PRODUCT_COUNT is used if it is zero.
+	Count int
+	// Templates are used for products in turn, products with random titles are created if there are none.
+	Templates []ProductTemplate
+}
+
+// ProductTemplate describes product to create, random title is generated if it is empty.
+type ProductTemplate struct {
+	Title           string   `json:"title"`
+	DescriptionHTML string   `json:"descriptionHtml"`
+	Vendor          string   `json:"vendor"`
+	ProductType     string   `json:"productType"`
+	Status          string   `json:"status"`
+	Tags            []string `json:"tags"`
+	// OptionName is a name of the option variants differ by, e.g. "Size", it is required if there are variants.
+	OptionName string                      `json:"optionName"`
+	Variants   []ProductVariantTemplate    `json:"variants"`
+	Images     []CreateProductImageOptions `json:"images"`
+}
+
+// ProductVariantTemplate describes variant of the product to create.
+type ProductVariantTemplate struct {
+	// Option is a value of the product's option, e.g. "Large".
+	Option         string `json:"option"`
+	SKU            string `json:"sku"`
+	Price          string `json:"price"`
+	CompareAtPrice string `json:"compareAtPrice"`
+}
+
+// CreateProductResult is a result of creating a single product.
+// ID is set once product is created, Err is set if product or its variants failed to be created.
+type CreateProductResult struct {
+	// Index is an index of the product among created ones.
+	Index int
+	ID    string
+	Err   error
+}
+
+type UpdateProductVariantOptions struct {
+	ID             string
+	Price          *string
+	CompareAtPrice *string
+	SKU            *string
+	Barcode        *string
+}
+
+type CreateProductImageOptions struct {
+	URL     string `json:"url"`
+	AltText string `json:"altText"`
+}
+
+func (s *platformService) ListProducts(ctx context.Context, opts ListProductsOptions) (*ProductsPage, error) {
+	logger := s.logger.
+		Named("ListProducts").
+		WithContext(ctx).
+		With("opts", opts)
+
+	store, err := getSessionStore(ctx, s.apis, s.storages)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, err
+		}
+		logger.Error("failed to get session store", "err", err)
+		return nil, fmt.Errorf("failed to get session store: %w", err)
+	}
+
+	page, err := s.apis.Platform.WithConfig(ctx, store).ListProducts(ctx, opts)
+	if err != nil {
+		logger.Error("failed to list products", "err", err)
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	return page, nil
+}
+
+func (s *platformService) GetProduct(ctx context.Context, id string) (*Product, error) {
+	logger := s.logger.
+		Named("GetProduct").
+		WithContext(ctx).
+		With("id", id)
+
+	store, err := getSessionStore(ctx, s.apis, s.storages)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, err
+		}
+		logger.Error("failed to get session store", "err", err)
+		return nil, fmt.Errorf("failed to get session store: %w", err)
+	}
+
+	product, err := s.apis.Platform.WithConfig(ctx, store).GetProduct(ctx, id)
+	if err != nil {
+		logger.Error("failed to get product", "err", err)
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		logger.Info("product is not found")
+		return nil, ErrProductNotFound
+	}
+
+	return product, nil
+}
+
+func (s *platformService) UpdateProduct(ctx context.Context, opts UpdateProductOptions) (*Product, error) {
+	logger := s.logger.
+		Named("UpdateProduct").
+		WithContext(ctx).
+		With("opts", opts)
+
+	store, err := getSessionStore(ctx, s.apis, s.storages)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return nil, err
+		}
+		logger.Error("failed to get session store", "err", err)
+		return nil, fmt.Errorf("failed to get session store: %w", err)
+	}
+
+	product, err := s.apis.Platform.WithConfig(ctx, store).UpdateProduct(ctx, opts)
+	if err != nil {
+		if _, ok := err.(PlatformUserErrors); ok {
+			logger.Info("product is invalid", "err", err)
+			return nil, err
+		}
+		logger.Error("failed to update product", "err", err)
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
+	if product == nil {
+		logger.Info("product is not found")
+		return nil, ErrProductNotFound
+	}
+
+	logger.Info("updated product")
+	return product, nil
+}
+
+func (s *platformService) DeleteProduct(ctx context.Context, id string) error {
+	logger := s.logger.
+		Named("DeleteProduct").
+		WithContext(ctx).
+		With("id", id)
+
+	store, err := getSessionStore(ctx, s.apis, s.storages)
+	if err != nil {
+		if errs.IsExpected(err) {
+			logger.Info(err.Error())
+			return err
+		}
+		logger.Error("failed to get session store", "err", err)
+		return fmt.Errorf("failed to get session store: %w", err)
+	}
+
+	deleted, err := s.apis.Platform.WithConfig(ctx, store).DeleteProduct(ctx, id)
+	if err != nil {
+		logger.Error("failed to delete product", "err", err)
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+	if !deleted {
+		logger.Info("product is not found")
+		return ErrProductNotFound
+	}
+
+	logger.Info("deleted product")
+	return nil
+}
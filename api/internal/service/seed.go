@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/pkg/errs"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+const (
+	// SeedTag is a tag of every seeded product, regardless of the run.
+	SeedTag = "seed-data"
+	// seedRunTagPrefix prefixes tag of the products seeded by a single run, e.g. "seed-run-1a2b3c4d".
+	// Handles of the run's collections are prefixed with the tag as well, e.g. "seed-run-1a2b3c4d-apparel".
+	seedRunTagPrefix = "seed-run-"
+	// seedRunIDLength is a number of random bytes in seed run ID.
+	seedRunIDLength = 4
+)
+
+var (
+	// ErrSeedStoreNotFound is returned when store is not found or not installed.
+	ErrSeedStoreNotFound = errs.New("store is not found")
+	// ErrSeedStoreNotDevelopment is returned when seeding store, which isn't a development one, without force.
+	ErrSeedStoreNotDevelopment = errs.New("store is not a development store, use force to seed it anyway")
+	// ErrSeedNoProducts is returned when there are no products to seed.
+	ErrSeedNoProducts = errs.New("no products to seed")
+	// ErrSeedRunNotFound is returned when seed run is not found.
+	ErrSeedRunNotFound = errs.New("seed run is not found")
+)
+
+// SeedService fills development stores with realistic data and removes it.
+type SeedService interface {
+	// Seed creates products and collections in store, all tagged with a new run ID.
+	// Products created before a failure are recorded, so they are deleted with the run.
+	Seed(ctx context.Context, opts SeedOptions) (*SeedResult, error)
+	// DeleteSeeded deletes products and collections created by the seed run, or by all runs if run ID is empty.
+	DeleteSeeded(ctx context.Context, opts DeleteSeededOptions) (*DeleteSeededResult, error)
+}
+
+// SeedProduct is a product fixture.
+type SeedProduct struct {
+	ProductTemplate
+	// Collections are titles of custom collections product is added to.
+	Collections []string `json:"collections"`
+}
+
+type SeedOptions struct {
+	StoreName string
+	// Products are seeded products, DefaultSeedProducts are used if there are none.
+	Products []SeedProduct
+	// Force allows seeding stores, which aren't development ones.
+	Force bool
+}
+
+type SeedResult struct {
+	// RunID is an ID, which seeded data is tagged with.
+	RunID    string
+	Products []CreateProductResult
+	// CollectionIDs are IDs of the created collections.
+	CollectionIDs []string
+}
+
+type DeleteSeededOptions struct {
+	StoreName string
+	// RunID is an ID of the seed run, data of all runs is deleted if it is empty.
+	RunID string
+}
+
+type DeleteSeededResult struct {
+	DeletedProducts    int
+	DeletedCollections int
+}
+
+// SeedRunTag returns tag of the products seeded by the run.
+func SeedRunTag(runID string) string {
+	return seedRunTagPrefix + runID
+}
+
+// seedCollectionHandle returns handle of the collection seeded by the run, so collections are found by the run at platform.
+func seedCollectionHandle(runID, title string) string {
+	return SeedRunTag(runID) + "-" + strings.Trim(nonHandleRegexp.ReplaceAllString(strings.ToLower(title), "-"), "-")
+}
+
+// nonHandleRegexp matches characters replaced with dashes in the handle generated from a title.
+var nonHandleRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// seedService service implements SeedService interface.
+type seedService struct {
+	apis     APIs
+	storages Storages
+	config   *config.Config
+	logger   logging.Logger
+}
+
+var _ SeedService = (*seedService)(nil)
+
+func NewSeedService(opts *Options) *seedService {
+	return &seedService{
+		apis:     opts.Apis,
+		storages: opts.Storages,
+		config:   opts.Config,
+		logger:   opts.Logger.Named("Seed"),
+	}
+}
+
+func (s *seedService) Seed(ctx context.Context, opts SeedOptions) (*SeedResult, error) {
+	logger := s.logger.
+		Named("Seed").
+		WithContext(ctx).
+		With("storeName", opts.StoreName, "force", opts.Force)
+
+	store, err := s.getInstalledStore(ctx, opts.StoreName)
+	if errs.IsExpected(err) {
+		logger.Info("store is not found or not installed")
+		return nil, err
+	}
+	if err != nil {
+		logger.Error("failed to get store", "err", err)
+		return nil, err
+	}
+	logger = logger.With("planName", store.PlanName)
+	if !opts.Force && !IsDevelopmentShopPlan(store.PlanName) {
+		logger.Info("store is not a development store")
+		return nil, ErrSeedStoreNotDevelopment
+	}
+
+	products := opts.Products
+	if len(products) == 0 {
+		products, err = DefaultSeedProducts()
+		if err != nil {
+			logger.Error("failed to load default seed products", "err", err)
+			return nil, fmt.Errorf("failed to load default seed products: %w", err)
+		}
+	}
+	if len(products) == 0 {
+		logger.Info("no products to seed")
+		return nil, ErrSeedNoProducts
+	}
+
+	runID, err := generateSeedRunID()
+	if err != nil {
+		logger.Error("failed to generate seed run id", "err", err)
+		return nil, fmt.Errorf("failed to generate seed run id: %w", err)
+	}
+	logger = logger.With("runID", runID)
+
+	templates := make([]ProductTemplate, 0, len(products))
+	for _, product := range products {
+		template := product.ProductTemplate
+		template.Tags = append(append([]string{}, template.Tags...), SeedTag, SeedRunTag(runID))
+		templates = append(templates, template)
+	}
+
+	api := s.apis.Platform.WithConfig(ctx, store)
+	result := &SeedResult{RunID: runID}
+	result.Products = api.CreateProducts(ctx, CreateProductsOptions{
+		Count:     len(templates),
+		Templates: templates,
+	})
+
+	// Collections are created from the created products only
+	collectionProducts := map[string][]string{}
+	created := 0
+	for i, product := range result.Products {
+		if product.ID == "" {
+			continue
+		}
+		created++
+		for _, title := range products[i].Collections {
+			collectionProducts[title] = append(collectionProducts[title], product.ID)
+		}
+	}
+	titles := make([]string, 0, len(collectionProducts))
+	for title := range collectionProducts {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+
+	var collectionErr error
+	for _, title := range titles {
+		id, err := api.CreateCollection(ctx, CreateCollectionOptions{
+			Title:      title,
+			Handle:     seedCollectionHandle(runID, title),
+			ProductIDs: collectionProducts[title],
+		})
+		if err != nil {
+			logger.Error("failed to create collection", "title", title, "err", err)
+			collectionErr = fmt.Errorf("failed to create collection %q: %w", title, err)
+			break
+		}
+		result.CollectionIDs = append(result.CollectionIDs, id)
+	}
+
+	// Run is recorded even if seeding failed midway, so its partial data can be deleted
+	_, err = s.storages.SeedRun.Create(ctx, &entity.SeedRun{
+		ID:            runID,
+		StoreName:     opts.StoreName,
+		ProductsCount: created,
+		CollectionIDs: result.CollectionIDs,
+	})
+	if err != nil {
+		logger.Error("failed to create seed run in storage", "err", err)
+		return nil, fmt.Errorf("failed to create seed run in storage: %w", err)
+	}
+	if collectionErr != nil {
+		return result, collectionErr
+	}
+	logger.Info("seeded store", "products", created, "failedProducts", len(result.Products)-created, "collections", len(result.CollectionIDs))
+
+	return result, nil
+}
+
+func (s *seedService) DeleteSeeded(ctx context.Context, opts DeleteSeededOptions) (*DeleteSeededResult, error) {
+	logger := s.logger.
+		Named("DeleteSeeded").
+		WithContext(ctx).
+		With("storeName", opts.StoreName, "runID", opts.RunID)
+
+	store, err := s.getInstalledStore(ctx, opts.StoreName)
+	if errs.IsExpected(err) {
+		logger.Info("store is not found or not installed")
+		return nil, err
+	}
+	if err != nil {
+		logger.Error("failed to get store", "err", err)
+		return nil, err
+	}
+
+	// Run is recorded once its data is created, so data of the run, which failed to be recorded,
+	// is still found at platform by its tags and handles
+	var runs []*entity.SeedRun
+	tag := SeedTag
+	handlePrefix := seedRunTagPrefix
+	if opts.RunID == "" {
+		runs, err = s.storages.SeedRun.List(ctx, opts.StoreName)
+		if err != nil {
+			logger.Error("failed to list seed runs from storage", "err", err)
+			return nil, fmt.Errorf("failed to list seed runs from storage: %w", err)
+		}
+	} else {
+		run, err := s.storages.SeedRun.Get(ctx, opts.StoreName, opts.RunID)
+		if err != nil {
+			logger.Error("failed to get seed run from storage", "err", err)
+			return nil, fmt.Errorf("failed to get seed run from storage: %w", err)
+		}
+		if run != nil {
+			runs = []*entity.SeedRun{run}
+		}
+		tag = SeedRunTag(opts.RunID)
+		handlePrefix = SeedRunTag(opts.RunID) + "-"
+	}
+
+	api := s.apis.Platform.WithConfig(ctx, store)
+
+	// Products are collected before deleting, so deletion doesn't shift the pages being iterated
+	var productIDs []string
+	products := api.IterateProducts(ctx, IterateProductsOptions{Filter: ProductFilter{Tag: tag}})
+	for products.Next(ctx) {
+		productIDs = append(productIDs, products.Item().ID)
+	}
+	if err := products.Err(); err != nil {
+		logger.Error("failed to iterate seeded products", "err", err)
+		return nil, fmt.Errorf("failed to iterate seeded products: %w", err)
+	}
+
+	var collectionIDs []string
+	collections := api.IterateCollections(ctx)
+	for collections.Next(ctx) {
+		if collection := collections.Item(); strings.HasPrefix(collection.Handle, handlePrefix) {
+			collectionIDs = append(collectionIDs, collection.ID)
+		}
+	}
+	if err := collections.Err(); err != nil {
+		logger.Error("failed to iterate seeded collections", "err", err)
+		return nil, fmt.Errorf("failed to iterate seeded collections: %w", err)
+	}
+
+	if opts.RunID != "" && len(runs) == 0 && len(productIDs) == 0 && len(collectionIDs) == 0 {
+		logger.Info("seed run is not found")
+		return nil, ErrSeedRunNotFound
+	}
+
+	result := &DeleteSeededResult{}
+	for _, id := range productIDs {
+		deleted, err := api.DeleteProduct(ctx, id)
+		if err != nil {
+			logger.Error("failed to delete product", "id", id, "err", err)
+			return result, fmt.Errorf("failed to delete product %s: %w", id, err)
+		}
+		if deleted {
+			result.DeletedProducts++
+		}
+	}
+
+	for _, id := range collectionIDs {
+		deleted, err := api.DeleteCollection(ctx, id)
+		if err != nil {
+			logger.Error("failed to delete collection", "id", id, "err", err)
+			return result, fmt.Errorf("failed to delete collection %s: %w", id, err)
+		}
+		if deleted {
+			result.DeletedCollections++
+		}
+	}
+
+	for _, run := range runs {
+		err = s.storages.SeedRun.Delete(ctx, opts.StoreName, run.ID)
+		if err != nil {
+			logger.Error("failed to delete seed run from storage", "id", run.ID, "err", err)
+			return result, fmt.Errorf("failed to delete seed run from storage: %w", err)
+		}
+	}
+	logger.Info("deleted seeded data", "products", result.DeletedProducts, "collections", result.DeletedCollections)
+
+	return result, nil
+}
+
+func (s *seedService) getInstalledStore(ctx context.Context, storeName string) (*entity.Store, error) {
+	store, err := s.storages.Store.Get(ctx, storeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store from storage: %w", err)
+	}
+	if store == nil || !store.Installed {
+		return nil, ErrSeedStoreNotFound
+	}
+	return store, nil
+}
+
+func generateSeedRunID() (string, error) {
+	id := make([]byte, seedRunIDLength)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// SeedFormatJSON is a format of JSON array of SeedProduct.
+	SeedFormatJSON = "json"
+	// SeedFormatCSV is a format of CSV with a header row and a row per variant or image, see seedCSVColumns.
+	SeedFormatCSV = "csv"
+)
+
+// defaultSeedProducts are fixtures seeded when no others are provided.
+//
+//go:embed seeddata/products.json
+var defaultSeedProducts []byte
+
+// seedCSVColumns are columns of seed CSV. Rows with empty or the same title continue the product of the previous row,
+// so a product with several variants or images takes several rows. Tags and collections are comma separated.
+var seedCSVColumns = []string{
+	"title",
+	"description_html",
+	"vendor",
+	"product_type",
+	"status",
+	"tags",
+	"collections",
+	"option_name",
+	"variant_option",
+	"variant_sku",
+	"variant_price",
+	"variant_compare_at_price",
+	"image_url",
+	"image_alt",
+}
+
+// DefaultSeedProducts returns fixtures embedded into the app.
+func DefaultSeedProducts() ([]SeedProduct, error) {
+	return ParseSeedProducts(bytes.NewReader(defaultSeedProducts), SeedFormatJSON)
+}
+
+// ParseSeedProducts parses fixtures in SeedFormatJSON or SeedFormatCSV format.
+func ParseSeedProducts(r io.Reader, format string) ([]SeedProduct, error) {
+	switch format {
+	case SeedFormatJSON:
+		var products []SeedProduct
+		err := json.NewDecoder(r).Decode(&products)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode seed products: %w", err)
+		}
+		for i, product := range products {
+			if product.Title == "" {
+				return nil, fmt.Errorf("seed product %d has no title", i)
+			}
+		}
+		return products, nil
+	case SeedFormatCSV:
+		return parseSeedProductsCSV(r)
+	default:
+		return nil, fmt.Errorf("unknown seed products format %q", format)
+	}
+}
+
+func parseSeedProductsCSV(r io.Reader) ([]SeedProduct, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed products header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !isSeedCSVColumn(name) {
+			return nil, fmt.Errorf("unknown seed products column %q, known columns are %s", name, strings.Join(seedCSVColumns, ", "))
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("seed products have no title column")
+	}
+
+	var products []SeedProduct
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read seed products: %w", err)
+		}
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		title := field("title")
+		if title != "" && (len(products) == 0 || products[len(products)-1].Title != title) {
+			products = append(products, SeedProduct{
+				ProductTemplate: ProductTemplate{
+					Title:           title,
+					DescriptionHTML: field("description_html"),
+					Vendor:          field("vendor"),
+					ProductType:     field("product_type"),
+					Status:          strings.ToUpper(field("status")),
+					Tags:            splitSeedList(field("tags")),
+					OptionName:      field("option_name"),
+				},
+				Collections: splitSeedList(field("collections")),
+			})
+		}
+		if len(products) == 0 {
+			return nil, fmt.Errorf("seed products line %d has no title", line)
+		}
+
+		product := &products[len(products)-1]
+		if option := field("variant_option"); option != "" {
+			product.Variants = append(product.Variants, ProductVariantTemplate{
+				Option:         option,
+				SKU:            field("variant_sku"),
+				Price:          field("variant_price"),
+				CompareAtPrice: field("variant_compare_at_price"),
+			})
+		}
+		if url := field("image_url"); url != "" {
+			product.Images = append(product.Images, CreateProductImageOptions{
+				URL:     url,
+				AltText: field("image_alt"),
+			})
+		}
+	}
+
+	return products, nil
+}
+
+func isSeedCSVColumn(name string) bool {
+	for _, column := range seedCSVColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSeedList splits comma separated CSV field, omitting empty values.
+func splitSeedList(field string) []string {
+	var values []string
+	for _, value := range strings.Split(field, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,115 @@
+package service_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+func TestParseSeedProductsCSV(t *testing.T) {
+	csv := `title,vendor,status,tags,collections,option_name,variant_option,variant_sku,variant_price,image_url,image_alt
+Crew Tee,Northwind,active,"cotton, basics",Apparel,Size,S,TEE-S,24.00,https://cdn.example.com/tee.jpg,Tee
+,,,,,,M,TEE-M,24.00,,
+Crew Tee,,,,,,,,,https://cdn.example.com/tee-back.jpg,Back
+Beanie,Northwind,draft,,"Apparel, Hats",,,,,,
+`
+
+	products, err := service.ParseSeedProducts(strings.NewReader(csv), service.SeedFormatCSV)
+	if err != nil {
+		t.Fatalf("ParseSeedProducts() error = %v", err)
+	}
+
+	want := []service.SeedProduct{
+		{
+			ProductTemplate: service.ProductTemplate{
+				Title:      "Crew Tee",
+				Vendor:     "Northwind",
+				Status:     "ACTIVE",
+				Tags:       []string{"cotton", "basics"},
+				OptionName: "Size",
+				// Rows with empty or the same title continue the product
+				Variants: []service.ProductVariantTemplate{
+					{Option: "S", SKU: "TEE-S", Price: "24.00"},
+					{Option: "M", SKU: "TEE-M", Price: "24.00"},
+				},
+				Images: []service.CreateProductImageOptions{
+					{URL: "https://cdn.example.com/tee.jpg", AltText: "Tee"},
+					{URL: "https://cdn.example.com/tee-back.jpg", AltText: "Back"},
+				},
+			},
+			Collections: []string{"Apparel"},
+		},
+		{
+			ProductTemplate: service.ProductTemplate{
+				Title:  "Beanie",
+				Vendor: "Northwind",
+				Status: "DRAFT",
+			},
+			Collections: []string{"Apparel", "Hats"},
+		},
+	}
+	if !reflect.DeepEqual(products, want) {
+		t.Errorf("ParseSeedProducts() = %+v, want %+v", products, want)
+	}
+}
+
+func TestParseSeedProductsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		wantErr string
+	}{
+		{
+			name:    "unknown CSV column",
+			format:  service.SeedFormatCSV,
+			data:    "title,price\nCrew Tee,24.00\n",
+			wantErr: `unknown seed products column "price"`,
+		},
+		{
+			name:    "no CSV title column",
+			format:  service.SeedFormatCSV,
+			data:    "vendor\nNorthwind\n",
+			wantErr: "no title column",
+		},
+		{
+			name:    "first CSV row without title",
+			format:  service.SeedFormatCSV,
+			data:    "title,variant_option\n,S\n",
+			wantErr: "line 2 has no title",
+		},
+		{
+			name:    "JSON product without title",
+			format:  service.SeedFormatJSON,
+			data:    `[{"title":"Crew Tee"},{"vendor":"Northwind"}]`,
+			wantErr: "seed product 1 has no title",
+		},
+		{
+			name:    "unknown format",
+			format:  "xml",
+			data:    "<products/>",
+			wantErr: `unknown seed products format "xml"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ParseSeedProducts(strings.NewReader(tt.data), tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseSeedProducts() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultSeedProducts(t *testing.T) {
+	products, err := service.DefaultSeedProducts()
+	if err != nil {
+		t.Fatalf("DefaultSeedProducts() error = %v", err)
+	}
+	if len(products) == 0 {
+		t.Error("DefaultSeedProducts() returned no products")
+	}
+}
@@ -0,0 +1,296 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/api/shopify"
+	"github.com/softcery/shopify-app-template-go/internal/api/shopify/shopifytest"
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/internal/storage/storagetest"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+const seedTestStoreName = "seed-test.myshopify.com"
+
+// seedTest is seed service of the store installed at fake Shopify server.
+type seedTest struct {
+	service  service.SeedService
+	fake     *shopifytest.Server
+	storages service.Storages
+}
+
+func newSeedTest(t *testing.T, planName string) *seedTest {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Shopify.ApiKey = "api-key"
+	cfg.Shopify.ApiSecret = "hush"
+	cfg.Shopify.Scopes = "read_products,write_products"
+	cfg.Shopify.APIVersion = "2024-04"
+	cfg.Shopify.RateLimitBucketSize = 40
+	cfg.Shopify.RateLimitLeakRate = 2
+	cfg.Shopify.RateLimitThreshold = 0.8
+	cfg.Shopify.RequestTimeout = 10 * time.Second
+	cfg.Shopify.CreateProductsConcurrency = 2
+
+	fake := shopifytest.NewServer(shopifytest.Options{
+		APIKey:    cfg.Shopify.ApiKey,
+		APISecret: cfg.Shopify.ApiSecret,
+		Scopes:    cfg.Shopify.Scopes,
+	})
+	t.Cleanup(fake.Close)
+	accessToken, err := fake.Install(seedTestStoreName)
+	if err != nil {
+		t.Fatalf("failed to install app: %v", err)
+	}
+
+	storages := storagetest.New()
+	_, err = storages.Store.Create(context.Background(), &entity.Store{
+		Name:        seedTestStoreName,
+		AccessToken: accessToken,
+		Installed:   true,
+		PlanName:    planName,
+	})
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	logger := logging.NewZap("error")
+	return &seedTest{
+		service: service.NewSeedService(&service.Options{
+			Apis: service.APIs{
+				Platform: shopify.NewAPI(shopify.Options{Config: cfg, Logger: logger, BaseURL: fake.BaseURL}),
+			},
+			Storages: storages,
+			Config:   cfg,
+			Logger:   logger,
+		}),
+		fake:     fake,
+		storages: storages,
+	}
+}
+
+// productTitles returns sorted titles of store's products.
+func (st *seedTest) productTitles() []string {
+	var titles []string
+	for _, product := range st.fake.Products(seedTestStoreName) {
+		titles = append(titles, product.Title)
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+// collectionTitles returns sorted titles of store's collections.
+func (st *seedTest) collectionTitles() []string {
+	var titles []string
+	for _, collection := range st.fake.Collections(seedTestStoreName) {
+		titles = append(titles, collection.Title)
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+func seedTestProducts() []service.SeedProduct {
+	return []service.SeedProduct{
+		{
+			ProductTemplate: service.ProductTemplate{
+				Title:      "Crew Tee",
+				Tags:       []string{"cotton"},
+				OptionName: "Size",
+				Variants: []service.ProductVariantTemplate{
+					{Option: "S", SKU: "TEE-S", Price: "24.00"},
+					{Option: "M", SKU: "TEE-M", Price: "24.00"},
+				},
+			},
+			Collections: []string{"Apparel", "Essentials"},
+		},
+		{
+			ProductTemplate: service.ProductTemplate{Title: "Beanie"},
+			Collections:     []string{"Apparel"},
+		},
+	}
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	st := newSeedTest(t, service.ShopPlanPartnerTest)
+
+	result, err := st.service.Seed(ctx, service.SeedOptions{StoreName: seedTestStoreName, Products: seedTestProducts()})
+	if err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+	if result.RunID == "" || len(result.Products) != 2 || len(result.CollectionIDs) != 2 {
+		t.Fatalf("Seed() = %+v, want run with 2 products and 2 collections", result)
+	}
+
+	products := st.fake.Products(seedTestStoreName)
+	if len(products) != 2 {
+		t.Fatalf("store has %d products, want 2", len(products))
+	}
+	for _, product := range products {
+		if !hasTag(product.Tags, service.SeedTag) || !hasTag(product.Tags, service.SeedRunTag(result.RunID)) {
+			t.Errorf("product %q tags = %v, want seed tags of run %s", product.Title, product.Tags, result.RunID)
+		}
+		if product.Title == "Crew Tee" && (len(product.Variants) != 2 || product.Variants[1].SKU != "TEE-M") {
+			t.Errorf("product %q variants = %+v", product.Title, product.Variants)
+		}
+	}
+
+	for _, collection := range st.fake.Collections(seedTestStoreName) {
+		wantProducts := 1
+		if collection.Title == "Apparel" {
+			wantProducts = 2
+		}
+		if len(collection.ProductIDs) != wantProducts {
+			t.Errorf("collection %q has %d products, want %d", collection.Title, len(collection.ProductIDs), wantProducts)
+		}
+	}
+	for _, collection := range st.fake.Collections(seedTestStoreName) {
+		if !strings.HasPrefix(collection.Handle, service.SeedRunTag(result.RunID)+"-") {
+			t.Errorf("collection %q handle = %q, want it prefixed with run's tag", collection.Title, collection.Handle)
+		}
+	}
+	if titles := st.collectionTitles(); len(titles) != 2 || titles[0] != "Apparel" || titles[1] != "Essentials" {
+		t.Errorf("store's collections = %v, want Apparel and Essentials", titles)
+	}
+
+	run, err := st.storages.SeedRun.Get(ctx, seedTestStoreName, result.RunID)
+	if err != nil {
+		t.Fatalf("failed to get seed run: %v", err)
+	}
+	if run == nil || run.ProductsCount != 2 || len(run.CollectionIDs) != 2 {
+		t.Errorf("seed run = %+v, want run with 2 products and 2 collections", run)
+	}
+}
+
+func TestSeedNotDevelopmentStore(t *testing.T) {
+	ctx := context.Background()
+	st := newSeedTest(t, "basic")
+
+	_, err := st.service.Seed(ctx, service.SeedOptions{StoreName: seedTestStoreName, Products: seedTestProducts()})
+	if !errors.Is(err, service.ErrSeedStoreNotDevelopment) {
+		t.Fatalf("Seed() error = %v, want %v", err, service.ErrSeedStoreNotDevelopment)
+	}
+	if len(st.fake.Products(seedTestStoreName)) != 0 {
+		t.Error("products are seeded at store, which isn't a development one")
+	}
+
+	_, err = st.service.Seed(ctx, service.SeedOptions{StoreName: seedTestStoreName, Products: seedTestProducts(), Force: true})
+	if err != nil {
+		t.Fatalf("Seed() with force error = %v", err)
+	}
+	if len(st.fake.Products(seedTestStoreName)) != 2 {
+		t.Error("products are not seeded with force")
+	}
+}
+
+func TestSeedStoreNotFound(t *testing.T) {
+	st := newSeedTest(t, service.ShopPlanPartnerTest)
+
+	_, err := st.service.Seed(context.Background(), service.SeedOptions{StoreName: "unknown.myshopify.com"})
+	if !errors.Is(err, service.ErrSeedStoreNotFound) {
+		t.Errorf("Seed() error = %v, want %v", err, service.ErrSeedStoreNotFound)
+	}
+}
+
+func TestDeleteSeeded(t *testing.T) {
+	ctx := context.Background()
+	st := newSeedTest(t, service.ShopPlanPartnerTest)
+	// Products, which are not seeded, are never deleted
+	st.fake.AddProduct(seedTestStoreName, shopifytest.Product{Title: "Own Product", Tags: []string{"cotton"}})
+
+	first, err := st.service.Seed(ctx, service.SeedOptions{StoreName: seedTestStoreName, Products: seedTestProducts()})
+	if err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+	_, err = st.service.Seed(ctx, service.SeedOptions{StoreName: seedTestStoreName, Products: []service.SeedProduct{
+		{ProductTemplate: service.ProductTemplate{Title: "Scarf"}, Collections: []string{"Winter"}},
+	}})
+	if err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+
+	result, err := st.service.DeleteSeeded(ctx, service.DeleteSeededOptions{StoreName: seedTestStoreName, RunID: first.RunID})
+	if err != nil {
+		t.Fatalf("DeleteSeeded() error = %v", err)
+	}
+	if result.DeletedProducts != 2 || result.DeletedCollections != 2 {
+		t.Errorf("DeleteSeeded() = %+v, want 2 products and 2 collections of the run", result)
+	}
+	if titles := st.productTitles(); len(titles) != 2 || titles[0] != "Own Product" || titles[1] != "Scarf" {
+		t.Errorf("store's products after deleting the run = %v, want Own Product and Scarf", titles)
+	}
+	if titles := st.collectionTitles(); len(titles) != 1 || titles[0] != "Winter" {
+		t.Errorf("store's collections after deleting the run = %v, want Winter", titles)
+	}
+
+	_, err = st.service.DeleteSeeded(ctx, service.DeleteSeededOptions{StoreName: seedTestStoreName, RunID: first.RunID})
+	if !errors.Is(err, service.ErrSeedRunNotFound) {
+		t.Errorf("DeleteSeeded() of deleted run error = %v, want %v", err, service.ErrSeedRunNotFound)
+	}
+
+	result, err = st.service.DeleteSeeded(ctx, service.DeleteSeededOptions{StoreName: seedTestStoreName})
+	if err != nil {
+		t.Fatalf("DeleteSeeded() of all runs error = %v", err)
+	}
+	if result.DeletedProducts != 1 || result.DeletedCollections != 1 {
+		t.Errorf("DeleteSeeded() of all runs = %+v, want 1 product and 1 collection", result)
+	}
+	if titles := st.productTitles(); len(titles) != 1 || titles[0] != "Own Product" {
+		t.Errorf("store's products after deleting all runs = %v, want Own Product", titles)
+	}
+	if len(st.fake.Collections(seedTestStoreName)) != 0 {
+		t.Errorf("store's collections after deleting all runs = %v, want none", st.collectionTitles())
+	}
+
+	runs, err := st.storages.SeedRun.List(ctx, seedTestStoreName)
+	if err != nil {
+		t.Fatalf("failed to list seed runs: %v", err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("%d seed runs are left in storage, want none", len(runs))
+	}
+}
+
+func TestDeleteSeededUnrecordedRun(t *testing.T) {
+	ctx := context.Background()
+	st := newSeedTest(t, service.ShopPlanPartnerTest)
+
+	seeded, err := st.service.Seed(ctx, service.SeedOptions{StoreName: seedTestStoreName, Products: seedTestProducts()})
+	if err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+	// Run isn't recorded if seeding is interrupted after its data is created
+	err = st.storages.SeedRun.Delete(ctx, seedTestStoreName, seeded.RunID)
+	if err != nil {
+		t.Fatalf("failed to delete seed run: %v", err)
+	}
+
+	result, err := st.service.DeleteSeeded(ctx, service.DeleteSeededOptions{StoreName: seedTestStoreName, RunID: seeded.RunID})
+	if err != nil {
+		t.Fatalf("DeleteSeeded() error = %v", err)
+	}
+	if result.DeletedProducts != 2 || result.DeletedCollections != 2 {
+		t.Errorf("DeleteSeeded() = %+v, want 2 products and 2 collections of the run", result)
+	}
+	if len(st.fake.Products(seedTestStoreName)) != 0 || len(st.fake.Collections(seedTestStoreName)) != 0 {
+		t.Errorf("store has %d products and %d collections left, want none",
+			len(st.fake.Products(seedTestStoreName)), len(st.fake.Collections(seedTestStoreName)))
+	}
+}
@@ -2,49 +2,169 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/entity"
 	"github.com/softcery/shopify-app-template-go/pkg/errs"
 	"github.com/softcery/shopify-app-template-go/pkg/logging"
+	"github.com/softcery/shopify-app-template-go/pkg/queue"
 )
 
 // Services contains all available services.
 type Services struct {
-	Platform PlatformService
+	Platform      PlatformService
+	Webhook       WebhookService
+	BulkOperation BulkOperationService
+	Catalog       CatalogService
+	Order         OrderService
+	Customer      CustomerService
+	Billing       BillingService
+	Seed          SeedService
 }
 
 // Options provides options for creating a new service instance.
 type Options struct {
 	Apis     APIs
 	Storages Storages
-	Config   *config.Config
-	Logger   logging.Logger
+	Webhooks *WebhookRegistry
+	Queue    JobQueue
+	// BulkOperations is used by services running bulk operations.
+	BulkOperations BulkOperationService
+	// Compliance handles app specific privacy requests. Optional.
+	Compliance ComplianceHandler
+	Config     *config.Config
+	Logger     logging.Logger
 }
 
 // PlatformService provides business logic related to shop platformService.
 type PlatformService interface {
 	Handle(ctx context.Context, storeName, installationURL string) (string, error)
 	// HandleRedirect handles an oauth2 redirect call for a platform integration.
-	HandleRedirect(ctx context.Context, opts ServiceHandleRedirectOptions) error
+	// It returns URL to redirect user to after successful installation.
+	HandleRedirect(ctx context.Context, opts ServiceHandleRedirectOptions) (string, error)
 	// HandleUninstall is called when user wants to uninstall the app from a platform.
 	// In this case we need to delete all records about their store from database.
 	HandleUninstall(ctx context.Context, storeName string) error
-	// GetProductsCount returns number of products in store.
+	// ReconcileWebhooks updates webhook subscriptions of all installed stores,
+	// e.g. after app's base URL or registered webhook topics have changed.
+	ReconcileWebhooks(ctx context.Context) error
+	// SyncShops updates metadata of all installed stores from platform,
+	// e.g. to backfill stores installed before their metadata was stored.
+	SyncShops(ctx context.Context) error
+	// VerifyWebhook verifies that webhook is sent by platform and returns the store it is sent for.
+	VerifyWebhook(ctx context.Context, opts ServiceVerifyWebhookOptions) (ShopDomain, error)
+	// GetProductsCount returns number of products in session store's local catalog.
 	GetProductsCount(ctx context.Context) (int, error)
-	// CreateProducts creates random products in store.
-	CreateProducts(ctx context.Context) error
+	// CreateProducts creates products in session store concurrently and returns result of every product,
+	// so products created before a failure are reported as well.
+	CreateProducts(ctx context.Context, opts CreateProductsOptions) ([]CreateProductResult, error)
+	// ListProducts returns a page of session store's products.
+	ListProducts(ctx context.Context, opts ListProductsOptions) (*ProductsPage, error)
+	// GetProduct returns session store's product by its ID.
+	GetProduct(ctx context.Context, id string) (*Product, error)
+	// UpdateProduct updates session store's product and returns the updated one.
+	UpdateProduct(ctx context.Context, opts UpdateProductOptions) (*Product, error)
+	// DeleteProduct deletes session store's product.
+	DeleteProduct(ctx context.Context, id string) error
+}
+
+// WebhookService dispatches platform webhooks to the handlers registered for their topics.
+type WebhookService interface {
+	// EnqueueWebhook adds webhook to the job queue to be handled asynchronously.
+	EnqueueWebhook(ctx context.Context, event WebhookEvent) error
+	// HandleWebhook runs handlers registered for the webhook's topic.
+	HandleWebhook(ctx context.Context, event WebhookEvent) error
+}
+
+// BulkOperationService runs platform's bulk operations and processes their results.
+type BulkOperationService interface {
+	// RegisterHandler registers handler of the results of bulk operations of the given kind.
+	RegisterHandler(kind string, handler BulkRecordHandler)
+	// RegisterCompletionHandler registers handler called once the whole result of bulk operation of the given kind is handled.
+	RegisterCompletionHandler(kind string, handler BulkCompletionHandler)
+	// Run starts bulk operation of the given kind.
+	// Unprocessed operation of the same kind is returned instead of starting a new one.
+	Run(ctx context.Context, opts RunBulkOperationOptions) (*entity.BulkOperation, error)
+	// PollBulkOperation updates status of the bulk operation and processes its result once it finishes.
+	PollBulkOperation(ctx context.Context, id string) error
+}
+
+// CatalogService keeps local copy of stores' products in sync with platform.
+type CatalogService interface {
+	// ImportProducts replaces store's local products with all products from platform.
+	// Import runs asynchronously as bulk operation.
+	ImportProducts(ctx context.Context, storeName string) error
+}
+
+// OrderService keeps local copy of stores' orders and provides access to it.
+type OrderService interface {
+	// SyncOrders saves store's orders updated since the previous sync.
+	SyncOrders(ctx context.Context, storeName string) error
+	// ScheduleOrdersSync enqueues orders sync of every installed store, unless store's sync is enqueued already.
+	ScheduleOrdersSync(ctx context.Context) error
+	// ListOrders returns a page of session store's orders.
+	ListOrders(ctx context.Context, opts ListOrdersOptions) (*OrdersPage, error)
+	// GetOrder returns session store's order by its ID.
+	GetOrder(ctx context.Context, id string) (*Order, error)
+}
+
+// CustomerService keeps local copy of stores' customers in sync with platform.
+type CustomerService interface {
+	// ImportCustomers replaces store's local customers with all customers from platform.
+	ImportCustomers(ctx context.Context, storeName string) error
+}
+
+// BillingService charges stores for using the app.
+type BillingService interface {
+	// GetBilling returns available plans and session store's subscription.
+	GetBilling(ctx context.Context) (*Billing, error)
+	// Subscribe creates session store's subscription to the plan.
+	// It returns URL, where store owner approves subscription.
+	Subscribe(ctx context.Context, plan string) (string, error)
+	// HandleCallback records subscription approved by store owner.
+	// It returns URL to redirect store owner to.
+	HandleCallback(ctx context.Context, opts HandleBillingCallbackOptions) (string, error)
+	// CheckSubscription returns ErrSubscriptionRequired if session store has no active subscription.
+	CheckSubscription(ctx context.Context) error
+	// CreateUsageCharge charges store for app usage within its plan's usage cap.
+	CreateUsageCharge(ctx context.Context, opts CreateUsageChargeOptions) error
+}
+
+// JobQueue is used to process jobs asynchronously.
+type JobQueue interface {
+	// Enqueue adds a job of the given kind to the queue.
+	Enqueue(ctx context.Context, kind string, payload interface{}, opts ...queue.EnqueueOption) error
+	// EnqueueAt adds a job of the given kind to the queue, which is run not earlier than runAt.
+	EnqueueAt(ctx context.Context, kind string, payload interface{}, runAt time.Time, opts ...queue.EnqueueOption) error
+	// DeleteByKey deletes all jobs enqueued with the key.
+	DeleteByKey(ctx context.Context, key string) error
 }
 
 const (
 	DEFAULT_PRODUCT_COUNT = 5
 )
 
+const (
+	// JobKindWebhook is a kind of jobs handling platform webhooks.
+	JobKindWebhook = "webhook"
+)
+
 var (
 	// ErrHandleRedirectStoreNotFound is returned when store is not found.
 	ErrHandleRedirectStoreNotFound = errs.New("store is not found")
+	// ErrHandleRedirectInvalidState is returned when oauth state is missing, unknown or already used.
+	ErrHandleRedirectInvalidState = errs.New("invalid oauth state")
+	// ErrHandleRedirectStateExpired is returned when oauth state has expired.
+	ErrHandleRedirectStateExpired = errs.New("oauth state has expired")
 
 	// ErrHandleUninstallStoreNotFound is returned when store is not found.
 	ErrHandleUninstallStoreNotFound = errs.New("store is not found")
+
+	// ErrInvalidSession is returned when session token is invalid.
+	ErrInvalidSession = errs.New("invalid session")
+	// ErrSessionStoreNotFound is returned when store of the session is not found or not installed.
+	ErrSessionStoreNotFound = errs.New("store is not found")
 )
 
 type ServiceHandlerOptions struct {
@@ -59,5 +179,12 @@ type ServiceHandleInstallOptions struct {
 
 type ServiceHandleRedirectOptions struct {
 	StoreName     string
+	State         string
 	RedirectedURL string
 }
+
+type ServiceVerifyWebhookOptions struct {
+	Body      []byte
+	HMAC      string
+	StoreName string
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+
+	"github.com/softcery/shopify-app-template-go/pkg/errs"
+)
+
+// ShopDomain is a validated and normalized myshopify domain of a store, e.g. "example.myshopify.com".
+type ShopDomain string
+
+// DefaultShopDomain is a domain all production stores belong to.
+const DefaultShopDomain = "myshopify.com"
+
+// shopNameRegexp matches the store's subdomain part of the shop domain.
+var shopNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// WebhookTopicShopUpdate is sent when store's metadata, e.g. its plan, is updated.
+const WebhookTopicShopUpdate = "shop/update"
+
+// Plan names of the shops, which are not regular paying shops.
+const (
+	// ShopPlanFrozen is a plan of the shop frozen due to unpaid bills.
+	ShopPlanFrozen = "frozen"
+	// ShopPlanDormant is a plan of the shop paused by its owner.
+	ShopPlanDormant = "dormant"
+	// ShopPlanCancelled is a plan of the closed shop.
+	ShopPlanCancelled = "cancelled"
+	// ShopPlanPartnerTest is a plan of the development shop created by partner.
+	ShopPlanPartnerTest = "partner_test"
+	// ShopPlanAffiliate is a plan of the development shop created by affiliate.
+	ShopPlanAffiliate = "affiliate"
+)
+
+// Shop is platform store's metadata.
+type Shop struct {
+	ID              int64
+	Name            string
+	Email           string
+	Currency        string
+	IANATimezone    string
+	PlanName        string
+	PlanDisplayName string
+	// PrimaryDomain is shop's storefront domain, which may be a custom one.
+	PrimaryDomain   string
+	CountryCode     string
+	MyshopifyDomain string
+	UpdatedAt       time.Time
+}
+
+// shopWebhookPayload is a payload of shop/update webhook.
+type shopWebhookPayload struct {
+	ID              int64     `json:"id"`
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	Currency        string    `json:"currency"`
+	IANATimezone    string    `json:"iana_timezone"`
+	PlanName        string    `json:"plan_name"`
+	PlanDisplayName string    `json:"plan_display_name"`
+	Domain          string    `json:"domain"`
+	CountryCode     string    `json:"country_code"`
+	MyshopifyDomain string    `json:"myshopify_domain"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// shopToEntity returns store with shop's metadata.
+func shopToEntity(storeName string, shop Shop) *entity.Store {
+	store := &entity.Store{
+		Name:            storeName,
+		ShopID:          shop.ID,
+		Email:           shop.Email,
+		Currency:        shop.Currency,
+		Timezone:        shop.IANATimezone,
+		PlanName:        shop.PlanName,
+		PlanDisplayName: shop.PlanDisplayName,
+		PrimaryDomain:   shop.PrimaryDomain,
+		CountryCode:     shop.CountryCode,
+		MyshopifyDomain: shop.MyshopifyDomain,
+	}
+	if !shop.UpdatedAt.IsZero() {
+		updatedAt := shop.UpdatedAt
+		store.ShopUpdatedAt = &updatedAt
+	}
+	return store
+}
+
+// IsDevelopmentShopPlan reports whether plan is a plan of development shop.
+func IsDevelopmentShopPlan(plan string) bool {
+	return plan == ShopPlanPartnerTest || plan == ShopPlanAffiliate
+}
+
+// IsInactiveShopPlan reports whether plan is a plan of frozen, paused or closed shop.
+func IsInactiveShopPlan(plan string) bool {
+	return plan == ShopPlanFrozen || plan == ShopPlanDormant || plan == ShopPlanCancelled
+}
+
+var (
+	// ErrInvalidShopDomain is returned when provided shop is not a valid store domain.
+	ErrInvalidShopDomain = errs.New("invalid shop domain")
+)
+
+// ParseShopDomain validates and normalizes raw shop domain.
+// Besides myshopify.com, subdomains of allowedDomains (e.g. dev or test domains) are accepted.
+func ParseShopDomain(raw string, allowedDomains []string) (ShopDomain, error) {
+	domain := strings.ToLower(strings.TrimSpace(raw))
+	domain = strings.TrimPrefix(domain, "https://")
+	domain = strings.TrimPrefix(domain, "http://")
+	domain = strings.TrimSuffix(domain, "/")
+
+	for _, allowedDomain := range append([]string{DefaultShopDomain}, allowedDomains...) {
+		allowedDomain = strings.ToLower(strings.TrimSpace(allowedDomain))
+		if allowedDomain == "" {
+			continue
+		}
+
+		shopName := strings.TrimSuffix(domain, "."+allowedDomain)
+		if shopName != domain && shopNameRegexp.MatchString(shopName) {
+			return ShopDomain(domain), nil
+		}
+	}
+
+	return "", ErrInvalidShopDomain
+}
+
+// String returns shop domain as a string.
+func (d ShopDomain) String() string {
+	return string(d)
+}
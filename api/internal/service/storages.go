@@ -2,24 +2,139 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/softcery/shopify-app-template-go/internal/entity"
 )
 
 // Storages contains all available storages.
 type Storages struct {
-	Store StoreStorage
+	Store             StoreStorage
+	OAuthState        OAuthStateStorage
+	ComplianceRequest ComplianceRequestStorage
+	ProcessedWebhook  ProcessedWebhookStorage
+	BulkOperation     BulkOperationStorage
+	Product           ProductStorage
+	Order             OrderStorage
+	Customer          CustomerStorage
+	SeedRun           SeedRunStorage
 }
 
 type StoreStorage interface {
 	// Get is used to retrieve store from storage by its name.
 	Get(ctx context.Context, storeName string) (*entity.Store, error)
+	// GetInstalled is used to retrieve all stores app is installed at.
+	GetInstalled(ctx context.Context) ([]*entity.Store, error)
 	// Create is used to create new store.
 	Create(ctx context.Context, store *entity.Store) (*entity.Store, error)
-	// Update is used to update store.
+	// Update is used to update store. Fields with zero values are not updated.
 	Update(ctx context.Context, store *entity.Store) (*entity.Store, error)
+	// UpdateShop is used to update store's shop metadata, unless the stored one has been updated at platform later.
+	// It returns false if the update is skipped as outdated.
+	UpdateShop(ctx context.Context, store *entity.Store) (bool, error)
+	// UpdateSubscription is used to replace store's subscription fields, including clearing them with zero values.
+	UpdateSubscription(ctx context.Context, store *entity.Store) error
 	// Delete is used to delete store.
 	Delete(ctx context.Context, storeName string) error
+	// Purge is used to permanently delete store, including soft deleted one.
+	Purge(ctx context.Context, storeName string) error
+}
+
+type OAuthStateStorage interface {
+	// Create is used to create new oauth state.
+	Create(ctx context.Context, state *entity.OAuthState) (*entity.OAuthState, error)
+	// Consume is used to mark oauth state as consumed and return it.
+	// It returns nil if state is not found or has already been consumed.
+	Consume(ctx context.Context, state string) (*entity.OAuthState, error)
+	// DeleteExpired is used to delete oauth states expired at the given time.
+	DeleteExpired(ctx context.Context, now time.Time) error
+	// DeleteByStore is used to delete all oauth states of the store.
+	DeleteByStore(ctx context.Context, storeName string) error
+}
+
+type ComplianceRequestStorage interface {
+	// GetByWebhookID is used to retrieve compliance request by ID of the webhook it was received with.
+	GetByWebhookID(ctx context.Context, webhookID string) (*entity.ComplianceRequest, error)
+	// Create is used to create new compliance request.
+	Create(ctx context.Context, request *entity.ComplianceRequest) (*entity.ComplianceRequest, error)
+	// Complete is used to mark compliance request as completed, clearPayload also deletes its payload.
+	Complete(ctx context.Context, id string, completedAt time.Time, clearPayload bool) error
+	// DeleteByStore is used to permanently delete all compliance requests of the store, except shop erasure requests.
+	DeleteByStore(ctx context.Context, storeName string) error
+}
+
+type ProcessedWebhookStorage interface {
+	// Get is used to retrieve webhook's handling progress by webhook ID.
+	Get(ctx context.Context, webhookID string) (*entity.ProcessedWebhook, error)
+	// Save is used to create or update webhook's handling progress.
+	Save(ctx context.Context, webhook *entity.ProcessedWebhook) error
+	// DeleteProcessedBefore is used to delete webhooks processed, or last handled partially, before the given time.
+	DeleteProcessedBefore(ctx context.Context, before time.Time) error
+	// DeleteByStore is used to delete all processed webhooks of the store.
+	DeleteByStore(ctx context.Context, storeName string) error
+}
+
+type BulkOperationStorage interface {
+	// Get is used to retrieve bulk operation by its ID.
+	Get(ctx context.Context, id string) (*entity.BulkOperation, error)
+	// GetUnprocessed is used to retrieve the latest bulk operation of the kind, which result is not handled yet.
+	GetUnprocessed(ctx context.Context, storeName, kind string) (*entity.BulkOperation, error)
+	// Create is used to create new bulk operation.
+	Create(ctx context.Context, operation *entity.BulkOperation) (*entity.BulkOperation, error)
+	// Update is used to update bulk operation.
+	Update(ctx context.Context, operation *entity.BulkOperation) (*entity.BulkOperation, error)
+	// Lock is used to lease unprocessed bulk operation to a single worker until the given time.
+	// It returns false if bulk operation is processed or is leased by another worker.
+	Lock(ctx context.Context, id string, until time.Time) (bool, error)
+	// Unlock is used to end the lease of bulk operation, so its result can be processed again.
+	Unlock(ctx context.Context, id string) error
+	// MarkProcessed is used to mark bulk operation as processed and end its lease.
+	MarkProcessed(ctx context.Context, id string, processedAt time.Time) error
+	// DeleteByStore is used to permanently delete all bulk operations of the store.
+	DeleteByStore(ctx context.Context, storeName string) error
+}
+
+type ProductStorage interface {
+	// Count is used to retrieve number of store's products.
+	Count(ctx context.Context, storeName string) (int64, error)
+	// Save is used to create or replace product with its variants.
+	// It returns false if stored product is more recent than the given one.
+	Save(ctx context.Context, product *entity.Product, variants []entity.ProductVariant) (bool, error)
+	// Delete is used to permanently delete product with its variants.
+	Delete(ctx context.Context, storeName, id string) error
+	// DeleteNotUpdatedSince is used to permanently delete store's products with their variants, which weren't saved since the time.
+	DeleteNotUpdatedSince(ctx context.Context, storeName string, since time.Time) error
+	// DeleteByStore is used to permanently delete all products of the store.
+	DeleteByStore(ctx context.Context, storeName string) error
+}
+
+type OrderStorage interface {
+	// Get is used to retrieve store's order by its ID.
+	Get(ctx context.Context, storeName, id string) (*entity.Order, error)
+	// List is used to retrieve a page of orders matching the filter and total number of matching orders.
+	List(ctx context.Context, filter OrderFilter) ([]*entity.Order, int64, error)
+	// GetLastUpdatedAt is used to retrieve platform's update time of the most recently updated store's order.
+	// It returns nil if store has no orders.
+	GetLastUpdatedAt(ctx context.Context, storeName string) (*time.Time, error)
+	// Save is used to create or replace order.
+	// It returns false if stored order is more recent than the given one.
+	Save(ctx context.Context, order *entity.Order) (bool, error)
+	// Delete is used to permanently delete store's orders by their IDs.
+	Delete(ctx context.Context, storeName string, ids []string) error
+	// DeleteByStore is used to permanently delete all orders of the store.
+	DeleteByStore(ctx context.Context, storeName string) error
+}
+
+type CustomerStorage interface {
+	// Get is used to retrieve store's customer by its ID.
+	Get(ctx context.Context, storeName, id string) (*entity.Customer, error)
+	// Save is used to create or replace customer.
+	// It returns false if stored customer is more recent than the given one.
+	Save(ctx context.Context, customer *entity.Customer) (bool, error)
+	// Delete is used to permanently delete customer.
+	Delete(ctx context.Context, storeName, id string) error
+	// DeleteByStore is used to permanently delete all customers of the store.
+	DeleteByStore(ctx context.Context, storeName string) error
 }
 
 type SessionStorage interface {
@@ -30,3 +145,16 @@ type SessionStorage interface {
 	// Delete is used to delete session.
 	Delete(ctx context.Context, sessionID string) error
 }
+
+type SeedRunStorage interface {
+	// Get is used to retrieve store's seed run by its ID.
+	Get(ctx context.Context, storeName, id string) (*entity.SeedRun, error)
+	// List is used to retrieve all seed runs of the store.
+	List(ctx context.Context, storeName string) ([]*entity.SeedRun, error)
+	// Create is used to create new seed run.
+	Create(ctx context.Context, run *entity.SeedRun) (*entity.SeedRun, error)
+	// Delete is used to permanently delete seed run.
+	Delete(ctx context.Context, storeName, id string) error
+	// DeleteByStore is used to permanently delete all seed runs of the store.
+	DeleteByStore(ctx context.Context, storeName string) error
+}
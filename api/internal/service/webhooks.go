@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+	"github.com/softcery/shopify-app-template-go/pkg/queue"
+)
+
+const (
+	// WebhookTopicAppUninstalled is sent when app is uninstalled from the store.
+	WebhookTopicAppUninstalled = "app/uninstalled"
+)
+
+// WebhookEvent represents a single webhook delivered by platform.
+type WebhookEvent struct {
+	ID         string
+	Topic      string
+	Shop       ShopDomain
+	APIVersion string
+	Payload    []byte
+}
+
+// WebhookHandler handles webhook events of the topic it is registered for.
+type WebhookHandler func(ctx context.Context, event WebhookEvent) error
+
+// WebhookRegistry stores webhook handlers by their topics.
+// Every registered topic, except compliance ones, is subscribed to when the app is installed.
+type WebhookRegistry struct {
+	mu       sync.RWMutex
+	handlers map[string][]WebhookHandler
+}
+
+func NewWebhookRegistry() *WebhookRegistry {
+	return &WebhookRegistry{
+		handlers: make(map[string][]WebhookHandler),
+	}
+}
+
+// Register adds handler for the given topic.
+// Handlers registered for the same topic are run in the order of registration.
+// Webhooks are delivered at least once, so handlers must be idempotent.
+func (r *WebhookRegistry) Register(topic string, handler WebhookHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[topic] = append(r.handlers[topic], handler)
+}
+
+// Handlers returns handlers registered for the given topic.
+func (r *WebhookRegistry) Handlers(topic string) []WebhookHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.handlers[topic]
+}
+
+// Topics returns sorted list of registered topics, that can be subscribed to with platform API.
+func (r *WebhookRegistry) Topics() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	topics := make([]string, 0, len(r.handlers))
+	for topic := range r.handlers {
+		if complianceWebhookTopics[topic] {
+			continue
+		}
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	return topics
+}
+
+// webhookService service implements WebhookService interface.
+type webhookService struct {
+	storages Storages
+	webhooks *WebhookRegistry
+	queue    JobQueue
+	config   *config.Config
+	logger   logging.Logger
+}
+
+var _ WebhookService = (*webhookService)(nil)
+
+func NewWebhookService(opts *Options) *webhookService {
+	return &webhookService{
+		storages: opts.Storages,
+		webhooks: opts.Webhooks,
+		queue:    opts.Queue,
+		config:   opts.Config,
+		logger:   opts.Logger.Named("Webhook"),
+	}
+}
+
+func (s *webhookService) EnqueueWebhook(ctx context.Context, event WebhookEvent) error {
+	logger := s.logger.
+		Named("EnqueueWebhook").
+		WithContext(ctx).
+		With("id", event.ID, "topic", event.Topic, "shop", event.Shop)
+
+	opts := []queue.EnqueueOption{queue.Key(event.Shop.String())}
+	// Duplicate deliveries aren't handled concurrently, the one arriving while another is queued is dropped
+	if event.ID != "" {
+		opts = append(opts, queue.Unique(JobKindWebhook+":"+event.ID))
+	}
+	err := s.queue.Enqueue(ctx, JobKindWebhook, event, opts...)
+	if err != nil {
+		logger.Error("failed to enqueue webhook", "err", err)
+		return fmt.Errorf("failed to enqueue webhook: %w", err)
+	}
+
+	logger.Info("enqueued webhook")
+	return nil
+}
+
+func (s *webhookService) HandleWebhook(ctx context.Context, event WebhookEvent) error {
+	logger := s.logger.
+		Named("HandleWebhook").
+		WithContext(ctx).
+		With("id", event.ID, "topic", event.Topic, "shop", event.Shop)
+
+	handlers := s.webhooks.Handlers(event.Topic)
+	if len(handlers) == 0 {
+		// Acknowledge webhook anyway, otherwise platform will keep retrying it
+		logger.Warn("no handlers registered for webhook topic")
+		return nil
+	}
+
+	// Platform delivers webhooks at least once, so duplicate deliveries are acknowledged and skipped
+	processed := &entity.ProcessedWebhook{
+		WebhookID: event.ID,
+		Topic:     event.Topic,
+		StoreName: event.Shop.String(),
+	}
+	if event.ID != "" {
+		saved, err := s.storages.ProcessedWebhook.Get(ctx, event.ID)
+		if err != nil {
+			logger.Error("failed to get processed webhook from storage", "err", err)
+			return fmt.Errorf("failed to get processed webhook from storage: %w", err)
+		}
+		if saved != nil && saved.ProcessedAt != nil {
+			logger.Info("webhook has already been processed")
+			return nil
+		}
+		if saved != nil {
+			processed = saved
+			logger.Info("resuming partially handled webhook", "handledCount", processed.HandledCount)
+		}
+	}
+
+	// Progress is saved after every handler, so a retry after a failure or a crash doesn't re-run handlers,
+	// which have succeeded. Handlers are idempotent, since the one running on crash is run again.
+	for i := processed.HandledCount; i < len(handlers); i++ {
+		err := handlers[i](ctx, event)
+		if err != nil {
+			logger.Error("failed to handle webhook", "err", err, "handler", i)
+			return fmt.Errorf("failed to handle %s webhook: %w", event.Topic, err)
+		}
+		if event.ID == "" {
+			continue
+		}
+
+		processed.HandledCount = i + 1
+		if processed.HandledCount == len(handlers) {
+			processedAt := time.Now()
+			processed.ProcessedAt = &processedAt
+		}
+		err = s.storages.ProcessedWebhook.Save(ctx, processed)
+		if err != nil {
+			logger.Error("failed to save processed webhook in storage", "err", err)
+			return fmt.Errorf("failed to save processed webhook in storage: %w", err)
+		}
+	}
+
+	// Webhook has been handled already, so failed cleanup must not trigger redelivery
+	err := s.storages.ProcessedWebhook.DeleteProcessedBefore(ctx, time.Now().Add(-s.config.Shopify.WebhookRetention))
+	if err != nil {
+		logger.Error("failed to delete outdated processed webhooks", "err", err)
+	}
+
+	logger.Info("handled webhook")
+	return nil
+}
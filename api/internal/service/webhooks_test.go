@@ -0,0 +1,66 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/config"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/internal/storage/storagetest"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+)
+
+func TestHandleWebhookResumesFailedHandlers(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	cfg.Shopify.WebhookRetention = time.Hour
+
+	calls := map[string]int{}
+	failSecond := true
+	registry := service.NewWebhookRegistry()
+	registry.Register("products/update", func(ctx context.Context, event service.WebhookEvent) error {
+		calls["first"]++
+		return nil
+	})
+	registry.Register("products/update", func(ctx context.Context, event service.WebhookEvent) error {
+		calls["second"]++
+		if failSecond {
+			return errors.New("storage is unavailable")
+		}
+		return nil
+	})
+
+	webhooks := service.NewWebhookService(&service.Options{
+		Storages: storagetest.New(),
+		Webhooks: registry,
+		Config:   cfg,
+		Logger:   logging.NewZap("error"),
+	})
+	event := service.WebhookEvent{ID: "webhook-1", Topic: "products/update", Shop: "s.myshopify.com", Payload: []byte(`{}`)}
+
+	err := webhooks.HandleWebhook(ctx, event)
+	if err == nil {
+		t.Fatal("HandleWebhook() error = nil, want error of the failed handler")
+	}
+
+	// Retry runs the failed handler only
+	failSecond = false
+	err = webhooks.HandleWebhook(ctx, event)
+	if err != nil {
+		t.Fatalf("HandleWebhook() retry error = %v", err)
+	}
+	if calls["first"] != 1 || calls["second"] != 2 {
+		t.Errorf("handlers are called %v times, want first once and second twice", calls)
+	}
+
+	// Duplicate delivery of the processed webhook is skipped
+	err = webhooks.HandleWebhook(ctx, event)
+	if err != nil {
+		t.Fatalf("HandleWebhook() duplicate error = %v", err)
+	}
+	if calls["first"] != 1 || calls["second"] != 2 {
+		t.Errorf("handlers of duplicate delivery are called, calls = %v", calls)
+	}
+}
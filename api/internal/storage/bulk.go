@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"gorm.io/gorm"
+)
+
+type bulkOperationStorage struct {
+	database.Database
+}
+
+var _ service.BulkOperationStorage = (*bulkOperationStorage)(nil)
+
+func NewBulkOperationStorage(db database.Database) *bulkOperationStorage {
+	return &bulkOperationStorage{db}
+}
+
+func (s *bulkOperationStorage) Get(ctx context.Context, id string) (*entity.BulkOperation, error) {
+	var operation entity.BulkOperation
+	err := s.Instance().
+		Where(&entity.BulkOperation{ID: id}).
+		First(&operation).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bulk operation: %w", err)
+	}
+
+	return &operation, nil
+}
+
+func (s *bulkOperationStorage) GetUnprocessed(ctx context.Context, storeName, kind string) (*entity.BulkOperation, error) {
+	var operation entity.BulkOperation
+	err := s.Instance().
+		Where(&entity.BulkOperation{StoreName: storeName, Kind: kind}).
+		Where("processed_at IS NULL").
+		Order("created_at DESC").
+		First(&operation).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unprocessed bulk operation: %w", err)
+	}
+
+	return &operation, nil
+}
+
+func (s *bulkOperationStorage) Create(ctx context.Context, operation *entity.BulkOperation) (*entity.BulkOperation, error) {
+	err := s.Instance().Create(operation).Error
+	if err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+func (s *bulkOperationStorage) Update(ctx context.Context, operation *entity.BulkOperation) (*entity.BulkOperation, error) {
+	err := s.Instance().
+		Where(&entity.BulkOperation{ID: operation.ID}).
+		Updates(operation).
+		Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update bulk operation: %w", err)
+	}
+
+	return s.Get(ctx, operation.ID)
+}
+
+func (s *bulkOperationStorage) Lock(ctx context.Context, id string, until time.Time) (bool, error) {
+	// Only one caller can take an expired lease, so the result is handled by one worker at a time
+	res := s.Instance().
+		Model(&entity.BulkOperation{}).
+		Where("id = ? AND processed_at IS NULL AND (locked_until IS NULL OR locked_until < ?)", id, time.Now()).
+		Update("locked_until", until)
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to lock bulk operation: %w", res.Error)
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (s *bulkOperationStorage) Unlock(ctx context.Context, id string) error {
+	err := s.Instance().
+		Model(&entity.BulkOperation{}).
+		Where("id = ?", id).
+		Update("locked_until", nil).
+		Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *bulkOperationStorage) MarkProcessed(ctx context.Context, id string, processedAt time.Time) error {
+	err := s.Instance().
+		Model(&entity.BulkOperation{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"processed_at": processedAt, "locked_until": nil}).
+		Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *bulkOperationStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	err := s.Instance().Unscoped().Delete(&entity.BulkOperation{}, "store_name = ?", storeName).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
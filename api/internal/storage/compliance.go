@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"github.com/softcery/shopify-app-template-go/pkg/encryption"
+	"gorm.io/gorm"
+)
+
+type complianceRequestStorage struct {
+	database.Database
+	cipher encryption.Cipher
+}
+
+var _ service.ComplianceRequestStorage = (*complianceRequestStorage)(nil)
+
+func NewComplianceRequestStorage(db database.Database, cipher encryption.Cipher) *complianceRequestStorage {
+	return &complianceRequestStorage{db, cipher}
+}
+
+func (s *complianceRequestStorage) GetByWebhookID(ctx context.Context, webhookID string) (*entity.ComplianceRequest, error) {
+	stmt := s.Instance().
+		Where(&entity.ComplianceRequest{WebhookID: webhookID})
+
+	var request entity.ComplianceRequest
+	err := stmt.First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get compliance request: %w", err)
+	}
+
+	request.Payload, err = s.cipher.Decrypt(request.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt compliance request: %w", err)
+	}
+	return &request, nil
+}
+
+func (s *complianceRequestStorage) Create(ctx context.Context, request *entity.ComplianceRequest) (*entity.ComplianceRequest, error) {
+	encrypted := *request
+	var err error
+	encrypted.Payload, err = s.cipher.Encrypt(request.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt compliance request: %w", err)
+	}
+
+	err = s.Instance().Create(&encrypted).Error
+	if err != nil {
+		return nil, err
+	}
+
+	created := *request
+	created.ID = encrypted.ID
+	created.CreatedAt = encrypted.CreatedAt
+	created.UpdatedAt = encrypted.UpdatedAt
+	return &created, nil
+}
+
+func (s *complianceRequestStorage) Complete(ctx context.Context, id string, completedAt time.Time, clearPayload bool) error {
+	fields := map[string]interface{}{"completed_at": completedAt}
+	if clearPayload {
+		fields["payload"] = ""
+	}
+
+	err := s.Instance().
+		Model(&entity.ComplianceRequest{}).
+		Where("id = ?", id).
+		Updates(fields).
+		Error
+	if err != nil {
+		return fmt.Errorf("failed to complete compliance request: %w", err)
+	}
+	return nil
+}
+
+func (s *complianceRequestStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	// Shop erasure requests are kept as a record of the erasure, they don't carry personal data
+	err := s.Instance().
+		Unscoped().
+		Delete(&entity.ComplianceRequest{}, "store_name = ? AND topic <> ?", storeName, service.WebhookTopicShopRedact).
+		Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
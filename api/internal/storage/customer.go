@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"github.com/softcery/shopify-app-template-go/pkg/encryption"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type customerStorage struct {
+	database.Database
+	cipher encryption.Cipher
+}
+
+var _ service.CustomerStorage = (*customerStorage)(nil)
+
+func NewCustomerStorage(db database.Database, cipher encryption.Cipher) *customerStorage {
+	return &customerStorage{db, cipher}
+}
+
+func (s *customerStorage) Get(ctx context.Context, storeName, id string) (*entity.Customer, error) {
+	var customer entity.Customer
+	err := s.Instance().
+		Where(&entity.Customer{StoreName: storeName, ID: id}).
+		First(&customer).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	err = s.transform(&customer, s.cipher.Decrypt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt customer: %w", err)
+	}
+
+	return &customer, nil
+}
+
+func (s *customerStorage) Save(ctx context.Context, customer *entity.Customer) (bool, error) {
+	encrypted := *customer
+	err := s.transform(&encrypted, s.cipher.Encrypt)
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt customer: %w", err)
+	}
+
+	// Customer is not updated by a stale copy
+	res := s.Instance().
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			UpdateAll: true,
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "customers.platform_updated_at <= excluded.platform_updated_at"},
+			}},
+		}).
+		Create(&encrypted)
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to upsert customer: %w", res.Error)
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (s *customerStorage) Delete(ctx context.Context, storeName, id string) error {
+	err := s.Instance().Unscoped().Delete(&entity.Customer{}, "store_name = ? AND id = ?", storeName, id).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete customer: %w", err)
+	}
+	return nil
+}
+
+func (s *customerStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	err := s.Instance().Unscoped().Delete(&entity.Customer{}, "store_name = ?", storeName).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// transform applies fn to every personal data field of the customer.
+func (s *customerStorage) transform(customer *entity.Customer, fn func(string) (string, error)) error {
+	for _, field := range []*string{&customer.FirstName, &customer.LastName, &customer.Email, &customer.Phone, &customer.Address} {
+		value, err := fn(*field)
+		if err != nil {
+			return err
+		}
+		*field = value
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"gorm.io/gorm/clause"
+)
+
+type oauthStateStorage struct {
+	database.Database
+}
+
+var _ service.OAuthStateStorage = (*oauthStateStorage)(nil)
+
+func NewOAuthStateStorage(db database.Database) *oauthStateStorage {
+	return &oauthStateStorage{db}
+}
+
+func (s *oauthStateStorage) Create(ctx context.Context, state *entity.OAuthState) (*entity.OAuthState, error) {
+	err := s.Instance().Create(state).Error
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *oauthStateStorage) Consume(ctx context.Context, state string) (*entity.OAuthState, error) {
+	// Mark state as consumed and return it in a single statement,
+	// so concurrent callbacks can't consume the same state twice.
+	var consumedState entity.OAuthState
+	res := s.Instance().
+		Model(&consumedState).
+		Clauses(clause.Returning{}).
+		Where("state = ? AND consumed = ?", state, false).
+		Update("consumed", true)
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to consume oauth state: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	return &consumedState, nil
+}
+
+func (s *oauthStateStorage) DeleteExpired(ctx context.Context, now time.Time) error {
+	err := s.Instance().Delete(&entity.OAuthState{}, "expires_at <= ?", now).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *oauthStateStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	err := s.Instance().Delete(&entity.OAuthState{}, "store_name = ?", storeName).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"github.com/softcery/shopify-app-template-go/pkg/encryption"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type orderStorage struct {
+	database.Database
+	cipher encryption.Cipher
+}
+
+var _ service.OrderStorage = (*orderStorage)(nil)
+
+func NewOrderStorage(db database.Database, cipher encryption.Cipher) *orderStorage {
+	return &orderStorage{db, cipher}
+}
+
+func (s *orderStorage) Get(ctx context.Context, storeName, id string) (*entity.Order, error) {
+	var order entity.Order
+	err := s.Instance().
+		Where(&entity.Order{StoreName: storeName, ID: id}).
+		First(&order).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	order.Email, err = s.cipher.Decrypt(order.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt order: %w", err)
+	}
+
+	return &order, nil
+}
+
+func (s *orderStorage) List(ctx context.Context, filter service.OrderFilter) ([]*entity.Order, int64, error) {
+	stmt := s.Instance().
+		Model(&entity.Order{}).
+		Where(&entity.Order{
+			StoreName:         filter.StoreName,
+			FinancialStatus:   filter.FinancialStatus,
+			FulfillmentStatus: filter.FulfillmentStatus,
+		})
+	if filter.CreatedAtMin != nil {
+		stmt = stmt.Where("platform_created_at >= ?", filter.CreatedAtMin)
+	}
+	if filter.CreatedAtMax != nil {
+		stmt = stmt.Where("platform_created_at <= ?", filter.CreatedAtMax)
+	}
+	if filter.Query != "" {
+		stmt = stmt.Where("name ILIKE ?", "%"+filter.Query+"%")
+	}
+	// Statement is shared by count and list queries
+	stmt = stmt.Session(&gorm.Session{})
+
+	var total int64
+	err := stmt.Count(&total).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	var orders []*entity.Order
+	err = stmt.
+		Order("platform_created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&orders).
+		Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list orders: %w", err)
+	}
+	for _, order := range orders {
+		order.Email, err = s.cipher.Decrypt(order.Email)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decrypt order: %w", err)
+		}
+	}
+
+	return orders, total, nil
+}
+
+func (s *orderStorage) GetLastUpdatedAt(ctx context.Context, storeName string) (*time.Time, error) {
+	var updatedAt *time.Time
+	err := s.Instance().
+		Model(&entity.Order{}).
+		Where(&entity.Order{StoreName: storeName}).
+		Select("MAX(platform_updated_at)").
+		Scan(&updatedAt).
+		Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last order update time: %w", err)
+	}
+
+	return updatedAt, nil
+}
+
+func (s *orderStorage) Save(ctx context.Context, order *entity.Order) (bool, error) {
+	encrypted := *order
+	var err error
+	encrypted.Email, err = s.cipher.Encrypt(order.Email)
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt order: %w", err)
+	}
+
+	// Order is not updated by a stale copy
+	res := s.Instance().
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			UpdateAll: true,
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "orders.platform_updated_at <= excluded.platform_updated_at"},
+			}},
+		}).
+		Create(&encrypted)
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to upsert order: %w", res.Error)
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (s *orderStorage) Delete(ctx context.Context, storeName string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := s.Instance().Unscoped().Delete(&entity.Order{}, "store_name = ? AND id IN ?", storeName, ids).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete orders: %w", err)
+	}
+	return nil
+}
+
+func (s *orderStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	err := s.Instance().Unscoped().Delete(&entity.Order{}, "store_name = ?", storeName).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type productStorage struct {
+	database.Database
+}
+
+var _ service.ProductStorage = (*productStorage)(nil)
+
+func NewProductStorage(db database.Database) *productStorage {
+	return &productStorage{db}
+}
+
+func (s *productStorage) Count(ctx context.Context, storeName string) (int64, error) {
+	var count int64
+	err := s.Instance().
+		Model(&entity.Product{}).
+		Where("store_name = ?", storeName).
+		Count(&count).
+		Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+	return count, nil
+}
+
+func (s *productStorage) Save(ctx context.Context, product *entity.Product, variants []entity.ProductVariant) (bool, error) {
+	saved := false
+	err := s.Instance().Transaction(func(tx *gorm.DB) error {
+		// Product is not updated by a stale copy, e.g. by webhook delivered out of order
+		res := tx.
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				UpdateAll: true,
+				Where: clause.Where{Exprs: []clause.Expression{
+					clause.Expr{SQL: "products.platform_updated_at <= excluded.platform_updated_at"},
+				}},
+			}).
+			Create(product)
+		if res.Error != nil {
+			return fmt.Errorf("failed to upsert product: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		ids := make([]string, 0, len(variants))
+		for i := range variants {
+			variants[i].ProductID = product.ID
+			variants[i].StoreName = product.StoreName
+			ids = append(ids, variants[i].ID)
+		}
+
+		deleteVariants := tx.Unscoped().Where("product_id = ?", product.ID)
+		if len(ids) > 0 {
+			deleteVariants = deleteVariants.Where("id NOT IN ?", ids)
+		}
+		err := deleteVariants.Delete(&entity.ProductVariant{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete removed variants: %w", err)
+		}
+
+		if len(variants) > 0 {
+			err = tx.
+				Clauses(clause.OnConflict{
+					Columns:   []clause.Column{{Name: "id"}},
+					UpdateAll: true,
+				}).
+				Create(&variants).
+				Error
+			if err != nil {
+				return fmt.Errorf("failed to upsert variants: %w", err)
+			}
+		}
+
+		saved = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return saved, nil
+}
+
+func (s *productStorage) Delete(ctx context.Context, storeName, id string) error {
+	err := s.Instance().Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Delete(&entity.ProductVariant{}, "store_name = ? AND product_id = ?", storeName, id).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&entity.Product{}, "store_name = ? AND id = ?", storeName, id).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+	return nil
+}
+
+func (s *productStorage) DeleteNotUpdatedSince(ctx context.Context, storeName string, since time.Time) error {
+	err := s.Instance().Transaction(func(tx *gorm.DB) error {
+		stale := tx.
+			Model(&entity.Product{}).
+			Select("id").
+			Where("store_name = ? AND updated_at < ?", storeName, since)
+		err := tx.Unscoped().Delete(&entity.ProductVariant{}, "store_name = ? AND product_id IN (?)", storeName, stale).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&entity.Product{}, "store_name = ? AND updated_at < ?", storeName, since).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete stale products: %w", err)
+	}
+	return nil
+}
+
+func (s *productStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	err := s.Instance().Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Delete(&entity.ProductVariant{}, "store_name = ?", storeName).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&entity.Product{}, "store_name = ?", storeName).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete store's products: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"gorm.io/gorm"
+)
+
+type seedRunStorage struct {
+	database.Database
+}
+
+var _ service.SeedRunStorage = (*seedRunStorage)(nil)
+
+func NewSeedRunStorage(db database.Database) *seedRunStorage {
+	return &seedRunStorage{db}
+}
+
+func (s *seedRunStorage) Get(ctx context.Context, storeName, id string) (*entity.SeedRun, error) {
+	var run entity.SeedRun
+	err := s.Instance().
+		Where(&entity.SeedRun{ID: id, StoreName: storeName}).
+		First(&run).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seed run: %w", err)
+	}
+
+	return &run, nil
+}
+
+func (s *seedRunStorage) List(ctx context.Context, storeName string) ([]*entity.SeedRun, error) {
+	var runs []*entity.SeedRun
+	err := s.Instance().
+		Where(&entity.SeedRun{StoreName: storeName}).
+		Order("created_at").
+		Find(&runs).
+		Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list seed runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (s *seedRunStorage) Create(ctx context.Context, run *entity.SeedRun) (*entity.SeedRun, error) {
+	err := s.Instance().Create(run).Error
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *seedRunStorage) Delete(ctx context.Context, storeName, id string) error {
+	err := s.Instance().Unscoped().Delete(&entity.SeedRun{}, "store_name = ? AND id = ?", storeName, id).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *seedRunStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	err := s.Instance().Unscoped().Delete(&entity.SeedRun{}, "store_name = ?", storeName).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"gorm.io/gorm"
+)
+
+type bulkOperationStorage struct {
+	mu         sync.Mutex
+	operations []*entity.BulkOperation
+}
+
+var _ service.BulkOperationStorage = (*bulkOperationStorage)(nil)
+
+func NewBulkOperationStorage() *bulkOperationStorage {
+	return &bulkOperationStorage{}
+}
+
+func (s *bulkOperationStorage) Get(ctx context.Context, id string) (*entity.BulkOperation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	operation := s.get(id)
+	if operation == nil {
+		return nil, nil
+	}
+	result := *operation
+	return &result, nil
+}
+
+func (s *bulkOperationStorage) GetUnprocessed(ctx context.Context, storeName, kind string) (*entity.BulkOperation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var latest *entity.BulkOperation
+	for _, operation := range s.operations {
+		if operation.StoreName == storeName && operation.Kind == kind && operation.ProcessedAt == nil &&
+			(latest == nil || !operation.CreatedAt.Before(latest.CreatedAt)) {
+			latest = operation
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	result := *latest
+	return &result, nil
+}
+
+func (s *bulkOperationStorage) Create(ctx context.Context, operation *entity.BulkOperation) (*entity.BulkOperation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if operation.CreatedAt.IsZero() {
+		operation.CreatedAt = now()
+	}
+	operation.UpdatedAt = now()
+	created := *operation
+	s.operations = append(s.operations, &created)
+	return operation, nil
+}
+
+func (s *bulkOperationStorage) Update(ctx context.Context, operation *entity.BulkOperation) (*entity.BulkOperation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.get(operation.ID)
+	if stored == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	// Like database's update, fields with zero values are skipped
+	if operation.Status != "" {
+		stored.Status = operation.Status
+	}
+	if operation.ErrorCode != "" {
+		stored.ErrorCode = operation.ErrorCode
+	}
+	if operation.ObjectCount != 0 {
+		stored.ObjectCount = operation.ObjectCount
+	}
+	if operation.Attempt != 0 {
+		stored.Attempt = operation.Attempt
+	}
+	if operation.CompletedAt != nil {
+		stored.CompletedAt = operation.CompletedAt
+	}
+	if operation.ProcessedAt != nil {
+		stored.ProcessedAt = operation.ProcessedAt
+	}
+	stored.UpdatedAt = now()
+
+	result := *stored
+	return &result, nil
+}
+
+func (s *bulkOperationStorage) Lock(ctx context.Context, id string, until time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	operation := s.get(id)
+	if operation == nil || operation.ProcessedAt != nil {
+		return false, nil
+	}
+	if operation.LockedUntil != nil && !operation.LockedUntil.Before(time.Now()) {
+		return false, nil
+	}
+	operation.LockedUntil = &until
+	return true, nil
+}
+
+func (s *bulkOperationStorage) Unlock(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if operation := s.get(id); operation != nil {
+		operation.LockedUntil = nil
+	}
+	return nil
+}
+
+func (s *bulkOperationStorage) MarkProcessed(ctx context.Context, id string, processedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if operation := s.get(id); operation != nil {
+		operation.ProcessedAt = &processedAt
+		operation.LockedUntil = nil
+	}
+	return nil
+}
+
+func (s *bulkOperationStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	operations := s.operations[:0]
+	for _, operation := range s.operations {
+		if operation.StoreName != storeName {
+			operations = append(operations, operation)
+		}
+	}
+	s.operations = operations
+	return nil
+}
+
+// get must be called with mu locked.
+func (s *bulkOperationStorage) get(id string) *entity.BulkOperation {
+	for _, operation := range s.operations {
+		if operation.ID == id {
+			return operation
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+type complianceRequestStorage struct {
+	mu       sync.Mutex
+	requests []*entity.ComplianceRequest
+}
+
+var _ service.ComplianceRequestStorage = (*complianceRequestStorage)(nil)
+
+func NewComplianceRequestStorage() *complianceRequestStorage {
+	return &complianceRequestStorage{}
+}
+
+func (s *complianceRequestStorage) GetByWebhookID(ctx context.Context, webhookID string) (*entity.ComplianceRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, request := range s.requests {
+		if request.WebhookID == webhookID {
+			found := *request
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *complianceRequestStorage) Create(ctx context.Context, request *entity.ComplianceRequest) (*entity.ComplianceRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := *request
+	created.ID = newID()
+	created.CreatedAt = now()
+	created.UpdatedAt = created.CreatedAt
+	stored := created
+	s.requests = append(s.requests, &stored)
+	return &created, nil
+}
+
+func (s *complianceRequestStorage) Complete(ctx context.Context, id string, completedAt time.Time, clearPayload bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, request := range s.requests {
+		if request.ID == id {
+			request.CompletedAt = &completedAt
+			if clearPayload {
+				request.Payload = ""
+			}
+		}
+	}
+	return nil
+}
+
+func (s *complianceRequestStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := s.requests[:0]
+	for _, request := range s.requests {
+		if request.StoreName != storeName || request.Topic == service.WebhookTopicShopRedact {
+			requests = append(requests, request)
+		}
+	}
+	s.requests = requests
+	return nil
+}
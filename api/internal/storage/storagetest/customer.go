@@ -0,0 +1,67 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+type customerStorage struct {
+	mu        sync.Mutex
+	customers map[string]*entity.Customer
+}
+
+var _ service.CustomerStorage = (*customerStorage)(nil)
+
+func NewCustomerStorage() *customerStorage {
+	return &customerStorage{customers: map[string]*entity.Customer{}}
+}
+
+func (s *customerStorage) Get(ctx context.Context, storeName, id string) (*entity.Customer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	customer := s.customers[id]
+	if customer == nil || customer.StoreName != storeName {
+		return nil, nil
+	}
+	result := *customer
+	return &result, nil
+}
+
+func (s *customerStorage) Save(ctx context.Context, customer *entity.Customer) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Customer is not updated by a stale copy
+	if stored := s.customers[customer.ID]; stored != nil && stored.PlatformUpdatedAt.After(customer.PlatformUpdatedAt) {
+		return false, nil
+	}
+	saved := *customer
+	s.customers[customer.ID] = &saved
+	return true, nil
+}
+
+func (s *customerStorage) Delete(ctx context.Context, storeName, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if customer := s.customers[id]; customer != nil && customer.StoreName == storeName {
+		delete(s.customers, id)
+	}
+	return nil
+}
+
+func (s *customerStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, customer := range s.customers {
+		if customer.StoreName == storeName {
+			delete(s.customers, id)
+		}
+	}
+	return nil
+}
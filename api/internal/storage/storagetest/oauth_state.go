@@ -0,0 +1,69 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+type oauthStateStorage struct {
+	mu     sync.Mutex
+	states map[string]*entity.OAuthState
+}
+
+var _ service.OAuthStateStorage = (*oauthStateStorage)(nil)
+
+func NewOAuthStateStorage() *oauthStateStorage {
+	return &oauthStateStorage{states: map[string]*entity.OAuthState{}}
+}
+
+func (s *oauthStateStorage) Create(ctx context.Context, state *entity.OAuthState) (*entity.OAuthState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state.CreatedAt = now()
+	created := *state
+	s.states[state.State] = &created
+	return state, nil
+}
+
+func (s *oauthStateStorage) Consume(ctx context.Context, state string) (*entity.OAuthState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.states[state]
+	if stored == nil || stored.Consumed {
+		return nil, nil
+	}
+	stored.Consumed = true
+
+	result := *stored
+	return &result, nil
+}
+
+func (s *oauthStateStorage) DeleteExpired(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, state := range s.states {
+		if !state.ExpiresAt.After(now) {
+			delete(s.states, key)
+		}
+	}
+	return nil
+}
+
+func (s *oauthStateStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, state := range s.states {
+		if state.StoreName == storeName {
+			delete(s.states, key)
+		}
+	}
+	return nil
+}
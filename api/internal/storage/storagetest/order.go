@@ -0,0 +1,125 @@
+package storagetest
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+type orderStorage struct {
+	mu     sync.Mutex
+	orders map[string]*entity.Order
+}
+
+var _ service.OrderStorage = (*orderStorage)(nil)
+
+func NewOrderStorage() *orderStorage {
+	return &orderStorage{orders: map[string]*entity.Order{}}
+}
+
+func (s *orderStorage) Get(ctx context.Context, storeName, id string) (*entity.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order := s.orders[id]
+	if order == nil || order.StoreName != storeName {
+		return nil, nil
+	}
+	result := *order
+	return &result, nil
+}
+
+func (s *orderStorage) List(ctx context.Context, filter service.OrderFilter) ([]*entity.Order, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var orders []*entity.Order
+	for _, order := range s.orders {
+		if matchesOrderFilter(order, filter) {
+			result := *order
+			orders = append(orders, &result)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].PlatformCreatedAt.After(orders[j].PlatformCreatedAt)
+	})
+	total := int64(len(orders))
+
+	if filter.Offset > len(orders) {
+		filter.Offset = len(orders)
+	}
+	orders = orders[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(orders) {
+		orders = orders[:filter.Limit]
+	}
+	return orders, total, nil
+}
+
+func (s *orderStorage) GetLastUpdatedAt(ctx context.Context, storeName string) (*time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var updatedAt *time.Time
+	for _, order := range s.orders {
+		if order.StoreName == storeName && (updatedAt == nil || order.PlatformUpdatedAt.After(*updatedAt)) {
+			orderUpdatedAt := order.PlatformUpdatedAt
+			updatedAt = &orderUpdatedAt
+		}
+	}
+	return updatedAt, nil
+}
+
+func (s *orderStorage) Save(ctx context.Context, order *entity.Order) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Order is not updated by a stale copy
+	if stored := s.orders[order.ID]; stored != nil && stored.PlatformUpdatedAt.After(order.PlatformUpdatedAt) {
+		return false, nil
+	}
+	saved := *order
+	s.orders[order.ID] = &saved
+	return true, nil
+}
+
+func (s *orderStorage) Delete(ctx context.Context, storeName string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if order := s.orders[id]; order != nil && order.StoreName == storeName {
+			delete(s.orders, id)
+		}
+	}
+	return nil
+}
+
+func (s *orderStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, order := range s.orders {
+		if order.StoreName == storeName {
+			delete(s.orders, id)
+		}
+	}
+	return nil
+}
+
+func matchesOrderFilter(order *entity.Order, filter service.OrderFilter) bool {
+	switch {
+	case filter.StoreName != "" && order.StoreName != filter.StoreName,
+		filter.FinancialStatus != "" && order.FinancialStatus != filter.FinancialStatus,
+		filter.FulfillmentStatus != "" && order.FulfillmentStatus != filter.FulfillmentStatus,
+		filter.CreatedAtMin != nil && order.PlatformCreatedAt.Before(*filter.CreatedAtMin),
+		filter.CreatedAtMax != nil && order.PlatformCreatedAt.After(*filter.CreatedAtMax),
+		filter.Query != "" && !strings.Contains(strings.ToLower(order.Name), strings.ToLower(filter.Query)):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+type storedProduct struct {
+	product  entity.Product
+	variants []entity.ProductVariant
+}
+
+type productStorage struct {
+	mu       sync.Mutex
+	products map[string]*storedProduct
+}
+
+var _ service.ProductStorage = (*productStorage)(nil)
+
+func NewProductStorage() *productStorage {
+	return &productStorage{products: map[string]*storedProduct{}}
+}
+
+func (s *productStorage) Count(ctx context.Context, storeName string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, stored := range s.products {
+		if stored.product.StoreName == storeName {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *productStorage) Save(ctx context.Context, product *entity.Product, variants []entity.ProductVariant) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Product is not updated by a stale copy
+	stored := s.products[product.ID]
+	if stored != nil && stored.product.PlatformUpdatedAt.After(product.PlatformUpdatedAt) {
+		return false, nil
+	}
+
+	product.UpdatedAt = now()
+	if stored == nil {
+		product.CreatedAt = product.UpdatedAt
+	} else {
+		product.CreatedAt = stored.product.CreatedAt
+	}
+	for i := range variants {
+		variants[i].ProductID = product.ID
+		variants[i].StoreName = product.StoreName
+	}
+
+	s.products[product.ID] = &storedProduct{
+		product:  *product,
+		variants: append([]entity.ProductVariant(nil), variants...),
+	}
+	return true, nil
+}
+
+func (s *productStorage) Delete(ctx context.Context, storeName, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored := s.products[id]; stored != nil && stored.product.StoreName == storeName {
+		delete(s.products, id)
+	}
+	return nil
+}
+
+func (s *productStorage) DeleteNotUpdatedSince(ctx context.Context, storeName string, since time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, stored := range s.products {
+		if stored.product.StoreName == storeName && stored.product.UpdatedAt.Before(since) {
+			delete(s.products, id)
+		}
+	}
+	return nil
+}
+
+func (s *productStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, stored := range s.products {
+		if stored.product.StoreName == storeName {
+			delete(s.products, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+type seedRunStorage struct {
+	mu   sync.Mutex
+	runs []*entity.SeedRun
+}
+
+var _ service.SeedRunStorage = (*seedRunStorage)(nil)
+
+func NewSeedRunStorage() *seedRunStorage {
+	return &seedRunStorage{}
+}
+
+func (s *seedRunStorage) Get(ctx context.Context, storeName, id string) (*entity.SeedRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, run := range s.runs {
+		if run.StoreName == storeName && run.ID == id {
+			result := *run
+			return &result, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *seedRunStorage) List(ctx context.Context, storeName string) ([]*entity.SeedRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Runs are kept in order of creation
+	var runs []*entity.SeedRun
+	for _, run := range s.runs {
+		if run.StoreName == storeName {
+			result := *run
+			runs = append(runs, &result)
+		}
+	}
+	return runs, nil
+}
+
+func (s *seedRunStorage) Create(ctx context.Context, run *entity.SeedRun) (*entity.SeedRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run.CreatedAt = now()
+	run.UpdatedAt = run.CreatedAt
+	created := *run
+	s.runs = append(s.runs, &created)
+	return run, nil
+}
+
+func (s *seedRunStorage) Delete(ctx context.Context, storeName, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteWhere(func(run *entity.SeedRun) bool {
+		return run.StoreName == storeName && run.ID == id
+	})
+	return nil
+}
+
+func (s *seedRunStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteWhere(func(run *entity.SeedRun) bool {
+		return run.StoreName == storeName
+	})
+	return nil
+}
+
+// deleteWhere must be called with mu locked.
+func (s *seedRunStorage) deleteWhere(match func(run *entity.SeedRun) bool) {
+	runs := s.runs[:0]
+	for _, run := range s.runs {
+		if !match(run) {
+			runs = append(runs, run)
+		}
+	}
+	s.runs = runs
+}
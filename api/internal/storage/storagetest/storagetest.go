@@ -0,0 +1,40 @@
+// Package storagetest provides in-memory storages, so services can be tested without database.
+//
+// Storages follow semantics of the database ones, including soft deletion of stores and
+// skipping of stale copies on save, and return copies of the stored entities.
+package storagetest
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+// New returns in-memory implementations of all storages.
+func New() service.Storages {
+	return service.Storages{
+		Store:             NewStoreStorage(),
+		OAuthState:        NewOAuthStateStorage(),
+		ComplianceRequest: NewComplianceRequestStorage(),
+		ProcessedWebhook:  NewProcessedWebhookStorage(),
+		BulkOperation:     NewBulkOperationStorage(),
+		Product:           NewProductStorage(),
+		Order:             NewOrderStorage(),
+		Customer:          NewCustomerStorage(),
+		SeedRun:           NewSeedRunStorage(),
+	}
+}
+
+// lastID is the last ID generated for the entities, which IDs are generated by database.
+var lastID int64
+
+func newID() string {
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", atomic.AddInt64(&lastID, 1))
+}
+
+// now returns current time, truncated like database's timestamps.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
@@ -0,0 +1,154 @@
+package storagetest
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"gorm.io/gorm"
+)
+
+type storeStorage struct {
+	mu     sync.Mutex
+	stores []*entity.Store
+}
+
+var _ service.StoreStorage = (*storeStorage)(nil)
+
+func NewStoreStorage() *storeStorage {
+	return &storeStorage{}
+}
+
+func (s *storeStorage) Get(ctx context.Context, storeName string) (*entity.Store, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store := s.get(storeName)
+	if store == nil {
+		return nil, nil
+	}
+	result := *store
+	return &result, nil
+}
+
+func (s *storeStorage) GetInstalled(ctx context.Context) ([]*entity.Store, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stores []*entity.Store
+	for _, store := range s.stores {
+		if store.Installed && !store.DeletedAt.Valid {
+			result := *store
+			stores = append(stores, &result)
+		}
+	}
+	return stores, nil
+}
+
+func (s *storeStorage) Create(ctx context.Context, store *entity.Store) (*entity.Store, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if store.ID == "" {
+		store.ID = newID()
+	}
+	store.CreatedAt = now()
+	store.UpdatedAt = store.CreatedAt
+
+	created := *store
+	s.stores = append(s.stores, &created)
+	return store, nil
+}
+
+func (s *storeStorage) Update(ctx context.Context, store *entity.Store) (*entity.Store, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.get(store.Name)
+	if stored == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	// Like database's update, fields with zero values are skipped
+	source := reflect.ValueOf(store).Elem()
+	target := reflect.ValueOf(stored).Elem()
+	for i := 0; i < source.NumField(); i++ {
+		if !source.Field(i).IsZero() && source.Type().Field(i).Name != "Model" {
+			target.Field(i).Set(source.Field(i))
+		}
+	}
+	stored.UpdatedAt = now()
+
+	result := *stored
+	return &result, nil
+}
+
+func (s *storeStorage) UpdateShop(ctx context.Context, store *entity.Store) (bool, error) {
+	s.mu.Lock()
+	stored := s.get(store.Name)
+	outdated := stored != nil && store.ShopUpdatedAt != nil && stored.ShopUpdatedAt != nil && stored.ShopUpdatedAt.After(*store.ShopUpdatedAt)
+	s.mu.Unlock()
+	if stored == nil || outdated {
+		return false, nil
+	}
+
+	_, err := s.Update(ctx, store)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *storeStorage) UpdateSubscription(ctx context.Context, store *entity.Store) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.get(store.Name)
+	if stored == nil {
+		return nil
+	}
+	stored.SubscriptionID = store.SubscriptionID
+	stored.SubscriptionPlan = store.SubscriptionPlan
+	stored.SubscriptionStatus = store.SubscriptionStatus
+	stored.UsageLineItemID = store.UsageLineItemID
+	stored.UpdatedAt = now()
+	return nil
+}
+
+func (s *storeStorage) Delete(ctx context.Context, storeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, store := range s.stores {
+		if store.Name == storeName && !store.DeletedAt.Valid {
+			store.DeletedAt = gorm.DeletedAt{Time: now(), Valid: true}
+		}
+	}
+	return nil
+}
+
+func (s *storeStorage) Purge(ctx context.Context, storeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stores := s.stores[:0]
+	for _, store := range s.stores {
+		if store.Name != storeName {
+			stores = append(stores, store)
+		}
+	}
+	s.stores = stores
+	return nil
+}
+
+// get returns the store, which isn't soft deleted, it must be called with mu locked.
+func (s *storeStorage) get(storeName string) *entity.Store {
+	for _, store := range s.stores {
+		if store.Name == storeName && !store.DeletedAt.Valid {
+			return store
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package storagetest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+)
+
+type processedWebhookStorage struct {
+	mu       sync.Mutex
+	webhooks map[string]*entity.ProcessedWebhook
+}
+
+var _ service.ProcessedWebhookStorage = (*processedWebhookStorage)(nil)
+
+func NewProcessedWebhookStorage() *processedWebhookStorage {
+	return &processedWebhookStorage{webhooks: map[string]*entity.ProcessedWebhook{}}
+}
+
+func (s *processedWebhookStorage) Get(ctx context.Context, webhookID string) (*entity.ProcessedWebhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook := s.webhooks[webhookID]
+	if webhook == nil {
+		return nil, nil
+	}
+	found := *webhook
+	return &found, nil
+}
+
+func (s *processedWebhookStorage) Save(ctx context.Context, webhook *entity.ProcessedWebhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *webhook
+	saved.UpdatedAt = now()
+	s.webhooks[webhook.WebhookID] = &saved
+	return nil
+}
+
+func (s *processedWebhookStorage) DeleteProcessedBefore(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, webhook := range s.webhooks {
+		updatedAt := webhook.UpdatedAt
+		if webhook.ProcessedAt != nil {
+			updatedAt = *webhook.ProcessedAt
+		}
+		if updatedAt.Before(before) {
+			delete(s.webhooks, id)
+		}
+	}
+	return nil
+}
+
+func (s *processedWebhookStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, webhook := range s.webhooks {
+		if webhook.StoreName == storeName {
+			delete(s.webhooks, id)
+		}
+	}
+	return nil
+}
@@ -37,6 +37,19 @@ func (s *storeStorage) Get(ctx context.Context, storeName string) (*entity.Store
 	return &store, nil
 }
 
+func (s *storeStorage) GetInstalled(ctx context.Context) ([]*entity.Store, error) {
+	var stores []*entity.Store
+	err := s.Instance().
+		Where(&entity.Store{Installed: true}).
+		Find(&stores).
+		Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get installed stores: %w", err)
+	}
+
+	return stores, nil
+}
+
 func (s *storeStorage) Update(ctx context.Context, store *entity.Store) (*entity.Store, error) {
 	err := s.Instance().
 		Where(&entity.Store{Name: store.Name}).
@@ -58,6 +71,36 @@ func (s *storeStorage) Update(ctx context.Context, store *entity.Store) (*entity
 	return &updatedStore, nil
 }
 
+func (s *storeStorage) UpdateShop(ctx context.Context, store *entity.Store) (bool, error) {
+	stmt := s.Instance().
+		Model(&entity.Store{}).
+		Where(&entity.Store{Name: store.Name})
+	// Webhooks may arrive out of order, so metadata older than the stored one is skipped
+	if store.ShopUpdatedAt != nil {
+		stmt = stmt.Where("shop_updated_at IS NULL OR shop_updated_at <= ?", store.ShopUpdatedAt)
+	}
+	res := stmt.Updates(store)
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to update store's shop: %w", res.Error)
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+func (s *storeStorage) UpdateSubscription(ctx context.Context, store *entity.Store) error {
+	// Select updates zero values as well, e.g. usage line item of the plan without usage charges
+	err := s.Instance().
+		Model(&entity.Store{}).
+		Where(&entity.Store{Name: store.Name}).
+		Select("subscription_id", "subscription_plan", "subscription_status", "usage_line_item_id").
+		Updates(store).
+		Error
+	if err != nil {
+		return fmt.Errorf("failed to update store's subscription: %w", err)
+	}
+	return nil
+}
+
 func (s *storeStorage) Create(ctx context.Context, store *entity.Store) (*entity.Store, error) {
 	err := s.Instance().Create(store).Error
 	if err != nil {
@@ -73,3 +116,12 @@ func (s *storeStorage) Delete(ctx context.Context, storeName string) error {
 	}
 	return nil
 }
+
+func (s *storeStorage) Purge(ctx context.Context, storeName string) error {
+	// Unscoped is used to delete store permanently instead of soft deleting it
+	err := s.Instance().Unscoped().Delete(&entity.Store{}, "name = ?", storeName).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
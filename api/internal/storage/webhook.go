@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/internal/entity"
+	"github.com/softcery/shopify-app-template-go/internal/service"
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type processedWebhookStorage struct {
+	database.Database
+}
+
+var _ service.ProcessedWebhookStorage = (*processedWebhookStorage)(nil)
+
+func NewProcessedWebhookStorage(db database.Database) *processedWebhookStorage {
+	return &processedWebhookStorage{db}
+}
+
+func (s *processedWebhookStorage) Get(ctx context.Context, webhookID string) (*entity.ProcessedWebhook, error) {
+	stmt := s.Instance().
+		Where(&entity.ProcessedWebhook{WebhookID: webhookID})
+
+	var webhook entity.ProcessedWebhook
+	err := stmt.First(&webhook).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processed webhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+func (s *processedWebhookStorage) Save(ctx context.Context, webhook *entity.ProcessedWebhook) error {
+	err := s.Instance().
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "webhook_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"handled_count", "processed_at", "updated_at"}),
+		}).
+		Create(webhook).
+		Error
+	if err != nil {
+		return fmt.Errorf("failed to save processed webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *processedWebhookStorage) DeleteProcessedBefore(ctx context.Context, before time.Time) error {
+	err := s.Instance().Delete(&entity.ProcessedWebhook{}, "COALESCE(processed_at, updated_at) < ?", before).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *processedWebhookStorage) DeleteByStore(ctx context.Context, storeName string) error {
+	err := s.Instance().Delete(&entity.ProcessedWebhook{}, "store_name = ?", storeName).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Cipher encrypts and decrypts values stored at rest.
+type Cipher interface {
+	// Encrypt encrypts plaintext and returns base64 encoded ciphertext.
+	Encrypt(plaintext string) (string, error)
+	// Decrypt decrypts base64 encoded ciphertext returned by Encrypt.
+	Decrypt(ciphertext string) (string, error)
+}
+
+// AESGCM is a Cipher using AES-GCM with random nonce prepended to every ciphertext.
+type AESGCM struct {
+	aead cipher.AEAD
+}
+
+// Check if implements the interface.
+var _ Cipher = (*AESGCM)(nil)
+
+// NewAESGCM is used to create new instance of AESGCM.
+// Key must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+func NewAESGCM(key []byte) (*AESGCM, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aes cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+
+	return &AESGCM{aead: aead}, nil
+}
+
+// NewAESGCMFromBase64 is used to create new instance of AESGCM from base64 encoded key.
+func NewAESGCMFromBase64(key string) (*AESGCM, error) {
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key: %w", err)
+	}
+
+	return NewAESGCM(decoded)
+}
+
+func (c *AESGCM) Encrypt(plaintext string) (string, error) {
+	// Empty values are kept empty, so missing data can be told apart without decryption
+	if plaintext == "" {
+		return "", nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	_, err := io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *AESGCM) Decrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("ciphertext is too short")
+	}
+
+	nonce, sealed := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt ciphertext: %w", err)
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,99 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func newTestCipher(t *testing.T, key byte) *AESGCM {
+	t.Helper()
+
+	cipher, err := NewAESGCM(bytes.Repeat([]byte{key}, 32))
+	if err != nil {
+		t.Fatalf("NewAESGCM() error = %v", err)
+	}
+	return cipher
+}
+
+func TestRoundTrip(t *testing.T) {
+	cipher := newTestCipher(t, 1)
+
+	for _, plaintext := range []string{"", "shpat_token", `{"customer":{"email":"customer@example.com"}}`} {
+		ciphertext, err := cipher.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt(%q) error = %v", plaintext, err)
+		}
+		if plaintext != "" && ciphertext == plaintext {
+			t.Errorf("Encrypt(%q) returned plaintext", plaintext)
+		}
+
+		decrypted, err := cipher.Decrypt(ciphertext)
+		if err != nil {
+			t.Fatalf("Decrypt() error = %v", err)
+		}
+		if decrypted != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q", plaintext, decrypted)
+		}
+	}
+}
+
+func TestEncryptUsesRandomNonce(t *testing.T) {
+	cipher := newTestCipher(t, 1)
+
+	first, _ := cipher.Encrypt("shpat_token")
+	second, _ := cipher.Encrypt("shpat_token")
+	if first == second {
+		t.Errorf("the same plaintext is encrypted into the same ciphertext %q", first)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	cipher := newTestCipher(t, 1)
+	ciphertext, err := cipher.Encrypt("shpat_token")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	sealed, _ := base64.StdEncoding.DecodeString(ciphertext)
+	sealed[len(sealed)-1] ^= 1
+	tampered := base64.StdEncoding.EncodeToString(sealed)
+
+	tests := map[string]string{
+		"tampered":   tampered,
+		"truncated":  base64.StdEncoding.EncodeToString(sealed[:4]),
+		"not base64": "not base64!",
+	}
+	for name, ciphertext := range tests {
+		_, err := cipher.Decrypt(ciphertext)
+		if err == nil {
+			t.Errorf("Decrypt() of %s ciphertext succeeded, want error", name)
+		}
+	}
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	ciphertext, err := newTestCipher(t, 1).Encrypt("shpat_token")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	_, err = newTestCipher(t, 2).Decrypt(ciphertext)
+	if err == nil {
+		t.Error("Decrypt() with wrong key succeeded, want error")
+	}
+}
+
+func TestNewAESGCMFromBase64(t *testing.T) {
+	_, err := NewAESGCMFromBase64(base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	if err != nil {
+		t.Errorf("NewAESGCMFromBase64() of 32 bytes key error = %v", err)
+	}
+
+	for _, key := range []string{"not base64!", base64.StdEncoding.EncodeToString(make([]byte, 20))} {
+		_, err := NewAESGCMFromBase64(key)
+		if err == nil {
+			t.Errorf("NewAESGCMFromBase64(%q) succeeded, want error", key)
+		}
+	}
+}
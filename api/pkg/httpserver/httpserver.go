@@ -22,6 +22,8 @@ type Server struct {
 	server          *http.Server
 	notify          chan error
 	shutdownTimeout time.Duration
+	// cancel cancels contexts of the requests being handled.
+	cancel context.CancelFunc
 }
 
 // Option - represents http server option.
@@ -65,10 +67,14 @@ func New(handler http.Handler, opts ...Option) *Server {
 		MaxHeaderBytes: _defaultMaxHeaderBytes,
 	}
 
+	baseCtx, cancel := context.WithCancel(context.Background())
+	httpServer.BaseContext = func(net.Listener) context.Context { return baseCtx }
+
 	s := &Server{
 		server:          httpServer,
 		notify:          make(chan error, 1),
 		shutdownTimeout: _defaultShutdownTimeout,
+		cancel:          cancel,
 	}
 
 	// add custom options
@@ -96,9 +102,11 @@ func (s *Server) Notify() <-chan error {
 }
 
 // Shutdown - shuts down http server gracefully.
+// Requests, which aren't handled within shutdown timeout, are cancelled.
 func (s *Server) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
+	defer s.cancel()
 
 	return s.server.Shutdown(ctx)
 }
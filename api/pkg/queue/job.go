@@ -0,0 +1,39 @@
+package queue
+
+import (
+	"time"
+)
+
+// Status represents state of the job.
+type Status string
+
+const (
+	// StatusPending is a status of the job waiting to be processed.
+	StatusPending Status = "pending"
+	// StatusRunning is a status of the job being processed by a worker.
+	StatusRunning Status = "running"
+	// StatusDead is a status of the job that has run out of attempts.
+	StatusDead Status = "dead"
+)
+
+// Job model represents a single job in the queue.
+// Successfully processed jobs are deleted from the queue.
+type Job struct {
+	ID   string `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Kind string `gorm:"index"`
+	// Key groups related jobs, e.g. jobs of a single store, so they can be deleted together.
+	Key string `gorm:"index"`
+	// UniqueKey prevents enqueueing a job while another pending or running job has the same key.
+	// Dead jobs release their unique keys.
+	UniqueKey *string `gorm:"uniqueIndex"`
+	Payload   string  `gorm:"type:jsonb"`
+	// Encrypted is set if payload is a JSON string of the encrypted payload, see Cipher.
+	Encrypted bool
+	Status    Status `gorm:"index"`
+	Attempts  int
+	RunAt     time.Time `gorm:"index"`
+	LockedAt  *time.Time
+	LastError string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,466 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"github.com/softcery/shopify-app-template-go/pkg/encryption"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	_defaultWorkers         = 1
+	_defaultPollInterval    = time.Second
+	_defaultMaxAttempts     = 10
+	_defaultBackoffBase     = 5 * time.Second
+	_defaultBackoffMax      = time.Hour
+	_defaultLockTimeout     = 10 * time.Minute
+	_defaultShutdownTimeout = 30 * time.Second
+)
+
+// Handler processes payload of the job.
+type Handler func(ctx context.Context, payload []byte) error
+
+// JSONHandler wraps handler of typed payload, which is decoded from JSON.
+func JSONHandler[T any](handle func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, payload []byte) error {
+		var decoded T
+		err := json.Unmarshal(payload, &decoded)
+		if err != nil {
+			return fmt.Errorf("failed to decode job payload: %w", err)
+		}
+		return handle(ctx, decoded)
+	}
+}
+
+// Queue - represents durable job queue stored in PostgreSQL.
+type Queue struct {
+	db       database.Database
+	logger   logging.Logger
+	handlers map[string]Handler
+	cipher   encryption.Cipher
+
+	workers         int
+	pollInterval    time.Duration
+	maxAttempts     int
+	backoffBase     time.Duration
+	backoffMax      time.Duration
+	lockTimeout     time.Duration
+	shutdownTimeout time.Duration
+
+	// stop stops workers from fetching new jobs, cancel cancels jobs being processed.
+	stop   context.CancelFunc
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Option - represents queue option.
+type Option func(*Queue)
+
+// Workers - configures number of concurrent workers.
+func Workers(n int) Option {
+	return func(q *Queue) {
+		q.workers = n
+	}
+}
+
+// PollInterval - configures how often idle workers check for new jobs.
+func PollInterval(interval time.Duration) Option {
+	return func(q *Queue) {
+		q.pollInterval = interval
+	}
+}
+
+// MaxAttempts - configures number of attempts before job is moved to the dead state.
+func MaxAttempts(n int) Option {
+	return func(q *Queue) {
+		q.maxAttempts = n
+	}
+}
+
+// Backoff - configures exponential backoff between attempts.
+func Backoff(base, max time.Duration) Option {
+	return func(q *Queue) {
+		q.backoffBase = base
+		q.backoffMax = max
+	}
+}
+
+// LockTimeout - configures time after which running job is considered abandoned and is retried.
+// Lock of the running job is refreshed every third of the timeout, so only jobs of dead workers are abandoned.
+func LockTimeout(timeout time.Duration) Option {
+	return func(q *Queue) {
+		q.lockTimeout = timeout
+	}
+}
+
+// ShutdownTimeout - configures time given to running jobs to finish on shutdown.
+func ShutdownTimeout(timeout time.Duration) Option {
+	return func(q *Queue) {
+		q.shutdownTimeout = timeout
+	}
+}
+
+// Cipher - configures encryption of payloads of enqueued jobs, which may carry customers' data.
+// Jobs enqueued without encryption are still processed.
+func Cipher(cipher encryption.Cipher) Option {
+	return func(q *Queue) {
+		q.cipher = cipher
+	}
+}
+
+// New - creates instance of new queue.
+func New(db database.Database, logger logging.Logger, opts ...Option) *Queue {
+	q := &Queue{
+		db:              db,
+		logger:          logger.Named("queue"),
+		handlers:        make(map[string]Handler),
+		workers:         _defaultWorkers,
+		pollInterval:    _defaultPollInterval,
+		maxAttempts:     _defaultMaxAttempts,
+		backoffBase:     _defaultBackoffBase,
+		backoffMax:      _defaultBackoffMax,
+		lockTimeout:     _defaultLockTimeout,
+		shutdownTimeout: _defaultShutdownTimeout,
+	}
+
+	// add custom options
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q
+}
+
+// Register - registers handler for jobs of the given kind.
+// Handlers must be registered before the queue is started.
+func (q *Queue) Register(kind string, handler Handler) {
+	q.handlers[kind] = handler
+}
+
+// EnqueueOption - represents option of the enqueued job.
+type EnqueueOption func(*Job)
+
+// Key - sets key grouping the job with related ones, e.g. store's name, see DeleteByKey.
+func Key(key string) EnqueueOption {
+	return func(job *Job) {
+		job.Key = key
+	}
+}
+
+// Unique - skips enqueueing the job if another pending or running job has the same unique key,
+// e.g. to run a single periodic job of the store at a time.
+func Unique(key string) EnqueueOption {
+	return func(job *Job) {
+		job.UniqueKey = &key
+	}
+}
+
+// Enqueue - adds a job of the given kind to the queue.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}, opts ...EnqueueOption) error {
+	return q.EnqueueAt(ctx, kind, payload, time.Now(), opts...)
+}
+
+// EnqueueAt - adds a job of the given kind to the queue, which is run not earlier than runAt.
+func (q *Queue) EnqueueAt(ctx context.Context, kind string, payload interface{}, runAt time.Time, opts ...EnqueueOption) error {
+	encodedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	job := &Job{
+		Kind:    kind,
+		Payload: string(encodedPayload),
+		Status:  StatusPending,
+		RunAt:   runAt,
+	}
+	if q.cipher != nil {
+		err = q.encrypt(job)
+		if err != nil {
+			return err
+		}
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	stmt := q.db.Instance()
+	if job.UniqueKey != nil {
+		stmt = stmt.Clauses(clause.OnConflict{DoNothing: true})
+	}
+	err = stmt.Create(job).Error
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByKey - deletes all jobs with the key regardless of their status.
+// Running jobs are not cancelled, but they aren't retried if they fail.
+func (q *Queue) DeleteByKey(ctx context.Context, key string) error {
+	err := q.db.Instance().Delete(&Job{}, "key = ?", key).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete jobs: %w", err)
+	}
+
+	return nil
+}
+
+// Start - starts workers.
+func (q *Queue) Start() {
+	stopCtx, stop := context.WithCancel(context.Background())
+	jobCtx, cancel := context.WithCancel(context.Background())
+	q.stop = stop
+	q.cancel = cancel
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			q.work(stopCtx, jobCtx)
+		}()
+	}
+}
+
+// Shutdown - stops fetching new jobs and waits for the running ones to finish.
+// Running jobs are cancelled if they don't finish within shutdown timeout.
+func (q *Queue) Shutdown() error {
+	q.stop()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-time.After(q.shutdownTimeout):
+		q.cancel()
+		<-done
+		return fmt.Errorf("running jobs didn't finish within %s", q.shutdownTimeout)
+	}
+}
+
+// work - processes jobs until stopCtx is done.
+func (q *Queue) work(stopCtx, jobCtx context.Context) {
+	for {
+		select {
+		case <-stopCtx.Done():
+			return
+		default:
+		}
+
+		job, err := q.fetch()
+		if err != nil {
+			q.logger.Error("failed to fetch job", "err", err)
+		}
+		if job == nil {
+			select {
+			case <-stopCtx.Done():
+				return
+			case <-time.After(q.pollInterval):
+			}
+			continue
+		}
+
+		q.process(jobCtx, job)
+	}
+}
+
+// fetch - locks the next job ready to run and marks it as running.
+// Returns nil if there are no jobs ready to run.
+func (q *Queue) fetch() (*Job, error) {
+	var job *Job
+	now := time.Now()
+
+	err := q.db.Instance().Transaction(func(tx *gorm.DB) error {
+		var candidate Job
+		// SKIP LOCKED lets concurrent workers fetch different jobs without waiting for each other
+		res := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at <= ?)",
+				StatusPending, now, StatusRunning, now.Add(-q.lockTimeout)).
+			Order("run_at").
+			Limit(1).
+			Find(&candidate)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		candidate.Status = StatusRunning
+		candidate.LockedAt = &now
+		candidate.Attempts++
+		err := tx.Model(&candidate).Select("status", "locked_at", "attempts").Updates(&candidate).Error
+		if err != nil {
+			return err
+		}
+
+		job = &candidate
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// process - runs job's handler and saves the result.
+func (q *Queue) process(ctx context.Context, job *Job) {
+	logger := q.logger.With("jobID", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+
+	var err error
+	handler, ok := q.handlers[job.Kind]
+	if ok {
+		stopHeartbeat := q.heartbeat(job)
+		err = q.run(ctx, handler, job)
+		stopHeartbeat()
+	} else {
+		err = fmt.Errorf("no handler registered for job kind %q", job.Kind)
+	}
+
+	if err == nil {
+		err = q.db.Instance().Delete(job).Error
+		if err != nil {
+			logger.Error("failed to delete processed job", "err", err)
+			return
+		}
+		logger.Debug("processed job")
+		return
+	}
+
+	job.LastError = err.Error()
+	job.LockedAt = nil
+	if !ok || job.Attempts >= q.maxAttempts {
+		job.Status = StatusDead
+		// Dead job doesn't prevent enqueueing the same job again
+		job.UniqueKey = nil
+		logger.Error("job is dead", "err", err)
+	} else {
+		job.Status = StatusPending
+		job.RunAt = time.Now().Add(q.backoff(job.Attempts))
+		logger.Info("job failed, will be retried", "err", err, "runAt", job.RunAt)
+	}
+
+	err = q.db.Instance().Model(job).Select("status", "run_at", "locked_at", "last_error", "unique_key").Updates(job).Error
+	if err != nil {
+		logger.Error("failed to update failed job", "err", err)
+	}
+}
+
+// heartbeat - refreshes lock of the running job until the returned function is called,
+// so the job isn't fetched by another worker however long it runs.
+func (q *Queue) heartbeat(job *Job) func() {
+	interval := q.lockTimeout / 3
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := q.db.Instance().
+					Model(&Job{}).
+					Where("id = ? AND status = ?", job.ID, StatusRunning).
+					Update("locked_at", time.Now()).
+					Error
+				if err != nil {
+					q.logger.Error("failed to refresh job lock", "jobID", job.ID, "err", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// run - runs handler recovering from panics.
+func (q *Queue) run(ctx context.Context, handler Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	payload := job.Payload
+	if job.Encrypted {
+		payload, err = q.decrypt(job)
+		if err != nil {
+			return err
+		}
+	}
+
+	return handler(ctx, []byte(payload))
+}
+
+// encrypt - replaces job's payload with JSON string of its ciphertext, so it is still valid JSON.
+func (q *Queue) encrypt(job *Job) error {
+	ciphertext, err := q.cipher.Encrypt(job.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt job payload: %w", err)
+	}
+	encodedCiphertext, err := json.Marshal(ciphertext)
+	if err != nil {
+		return fmt.Errorf("failed to encode encrypted job payload: %w", err)
+	}
+
+	job.Payload = string(encodedCiphertext)
+	job.Encrypted = true
+	return nil
+}
+
+// decrypt - returns decrypted payload of the encrypted job.
+func (q *Queue) decrypt(job *Job) (string, error) {
+	if q.cipher == nil {
+		return "", fmt.Errorf("job payload is encrypted, but queue has no cipher")
+	}
+
+	var ciphertext string
+	err := json.Unmarshal([]byte(job.Payload), &ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted job payload: %w", err)
+	}
+	payload, err := q.cipher.Decrypt(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt job payload: %w", err)
+	}
+
+	return payload, nil
+}
+
+// backoff - returns delay before the next attempt.
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.backoffBase
+	for i := 1; i < attempts && delay < q.backoffMax; i++ {
+		delay *= 2
+	}
+	if delay > q.backoffMax {
+		delay = q.backoffMax
+	}
+	return delay
+}
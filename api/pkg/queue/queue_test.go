@@ -0,0 +1,352 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/softcery/shopify-app-template-go/pkg/database"
+	"github.com/softcery/shopify-app-template-go/pkg/encryption"
+	"github.com/softcery/shopify-app-template-go/pkg/logging"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// testPostgresHostEnv is an environment variable with PostgreSQL host, tests of the queue stored in it are skipped without it.
+// User, password and database are set by TEST_POSTGRES_USER, TEST_POSTGRES_PASSWORD and TEST_POSTGRES_DATABASE.
+const testPostgresHostEnv = "TEST_POSTGRES_HOST"
+
+// newTestQueue creates queue stored in its own table, which is dropped once the test finishes.
+func newTestQueue(t *testing.T, opts ...Option) *Queue {
+	t.Helper()
+
+	host := os.Getenv(testPostgresHostEnv)
+	if host == "" {
+		t.Skipf("%s is not set", testPostgresHostEnv)
+	}
+	dsn := fmt.Sprintf("user=%s password=%s dbname=%s host=%s",
+		envOrDefault("TEST_POSTGRES_USER", "postgres"),
+		envOrDefault("TEST_POSTGRES_PASSWORD", "postgres"),
+		envOrDefault("TEST_POSTGRES_DATABASE", "api"),
+		host,
+	)
+
+	prefix := fmt.Sprintf("queue_test_%d_", time.Now().UnixNano())
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{NamingStrategy: schema.NamingStrategy{TablePrefix: prefix}})
+	if err != nil {
+		t.Fatalf("failed to connect to postgresql: %v", err)
+	}
+	err = db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error
+	if err != nil {
+		t.Fatalf("failed to create uuid-ossp extension: %v", err)
+	}
+	err = db.AutoMigrate(&Job{})
+	if err != nil {
+		t.Fatalf("failed to migrate jobs: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Migrator().DropTable(&Job{})
+		sqlDB, err := db.DB()
+		if err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	return New(&database.PostgreSQL{DB: db}, logging.NewZap("error"), opts...)
+}
+
+func envOrDefault(name, value string) string {
+	if env := os.Getenv(name); env != "" {
+		return env
+	}
+	return value
+}
+
+// getJob returns the only job of the queue.
+func getJob(t *testing.T, q *Queue) *Job {
+	t.Helper()
+
+	var jobs []Job
+	err := q.db.Instance().Find(&jobs).Error
+	if err != nil {
+		t.Fatalf("failed to get jobs: %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("queue has %d jobs, want 1", len(jobs))
+	}
+	return &jobs[0]
+}
+
+// fetchAndProcess fetches the next job and processes it, it fails the test if there is no job ready to run.
+func fetchAndProcess(t *testing.T, q *Queue) {
+	t.Helper()
+
+	job, err := q.fetch()
+	if err != nil {
+		t.Fatalf("fetch() error = %v", err)
+	}
+	if job == nil {
+		t.Fatal("fetch() = nil, want job ready to run")
+	}
+	q.process(context.Background(), job)
+}
+
+func TestBackoff(t *testing.T) {
+	q := &Queue{backoffBase: 5 * time.Second, backoffMax: time.Minute}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 5 * time.Second},
+		{attempts: 2, want: 10 * time.Second},
+		{attempts: 3, want: 20 * time.Second},
+		{attempts: 4, want: 40 * time.Second},
+		{attempts: 5, want: time.Minute},
+		{attempts: 50, want: time.Minute},
+	}
+	for _, tt := range tests {
+		if got := q.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestProcessSucceeded(t *testing.T) {
+	type greeting struct {
+		Name string `json:"name"`
+	}
+	q := newTestQueue(t)
+	var payload greeting
+	q.Register("greet", JSONHandler(func(ctx context.Context, p greeting) error {
+		payload = p
+		return nil
+	}))
+
+	err := q.Enqueue(context.Background(), "greet", map[string]string{"name": "store"})
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	fetchAndProcess(t, q)
+
+	if payload.Name != "store" {
+		t.Errorf("handler got payload %+v, want name store", payload)
+	}
+	var count int64
+	q.db.Instance().Model(&Job{}).Count(&count)
+	if count != 0 {
+		t.Errorf("queue has %d jobs after processing, want processed job deleted", count)
+	}
+}
+
+func TestRunEncryptedPayload(t *testing.T) {
+	cipher, err := encryption.NewAESGCM(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("failed to create cipher: %v", err)
+	}
+	q := &Queue{cipher: cipher}
+	var payload []byte
+	handler := func(ctx context.Context, p []byte) error {
+		payload = p
+		return nil
+	}
+
+	job := &Job{Payload: `{"email":"customer@example.com"}`}
+	err = q.encrypt(job)
+	if err != nil {
+		t.Fatalf("encrypt() error = %v", err)
+	}
+	if !job.Encrypted || strings.Contains(job.Payload, "customer@example.com") || !json.Valid([]byte(job.Payload)) {
+		t.Errorf("encrypted job = %+v, want payload encrypted into JSON string", job)
+	}
+
+	err = q.run(context.Background(), handler, job)
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if string(payload) != `{"email":"customer@example.com"}` {
+		t.Errorf("handler got payload %s, want decrypted one", payload)
+	}
+
+	// Jobs enqueued before payloads were encrypted are run as they are
+	err = q.run(context.Background(), handler, &Job{Payload: `"gid://shopify/BulkOperation/1"`})
+	if err != nil {
+		t.Fatalf("run() of unencrypted job error = %v", err)
+	}
+	if string(payload) != `"gid://shopify/BulkOperation/1"` {
+		t.Errorf("handler got payload %s, want unencrypted one", payload)
+	}
+}
+
+func TestProcessRetriesAndMovesToDead(t *testing.T) {
+	q := newTestQueue(t, MaxAttempts(2), Backoff(time.Minute, time.Hour))
+	q.Register("fail", func(ctx context.Context, payload []byte) error {
+		return errors.New("platform is unavailable")
+	})
+
+	err := q.Enqueue(context.Background(), "fail", nil, Unique("fail"))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	fetchAndProcess(t, q)
+	job := getJob(t, q)
+	if job.Status != StatusPending || job.Attempts != 1 || job.LastError != "platform is unavailable" {
+		t.Errorf("job after the first failure = %+v, want pending with 1 attempt", job)
+	}
+	if delay := time.Until(job.RunAt); delay < 50*time.Second || delay > time.Minute {
+		t.Errorf("job is retried in %s, want backoff of a minute", delay)
+	}
+
+	// Job isn't fetched until its backoff is over
+	fetched, err := q.fetch()
+	if err != nil || fetched != nil {
+		t.Fatalf("fetch() = %+v, %v during backoff, want no job", fetched, err)
+	}
+	q.db.Instance().Model(job).Update("run_at", time.Now())
+
+	fetchAndProcess(t, q)
+	job = getJob(t, q)
+	if job.Status != StatusDead || job.Attempts != 2 || job.UniqueKey != nil {
+		t.Errorf("job after the last attempt = %+v, want dead without unique key", job)
+	}
+	fetched, err = q.fetch()
+	if err != nil || fetched != nil {
+		t.Errorf("fetch() = %+v, %v, want dead job not fetched", fetched, err)
+	}
+}
+
+func TestProcessRecoversPanic(t *testing.T) {
+	q := newTestQueue(t)
+	q.Register("panic", func(ctx context.Context, payload []byte) error {
+		panic("unexpected payload")
+	})
+
+	err := q.Enqueue(context.Background(), "panic", nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	fetchAndProcess(t, q)
+
+	job := getJob(t, q)
+	if job.Status != StatusPending || job.LastError != "job panicked: unexpected payload" {
+		t.Errorf("job after panic = %+v, want pending with panic error", job)
+	}
+}
+
+func TestEnqueueUnique(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t, MaxAttempts(1))
+	q.Register("sync", func(ctx context.Context, payload []byte) error {
+		return errors.New("failed")
+	})
+
+	for i := 0; i < 2; i++ {
+		err := q.Enqueue(ctx, "sync", i, Unique("sync:store"))
+		if err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	job := getJob(t, q)
+	if job.Payload != "0" {
+		t.Errorf("job payload = %s, want the first enqueued job", job.Payload)
+	}
+
+	// Dead job releases its unique key
+	fetchAndProcess(t, q)
+	err := q.Enqueue(ctx, "sync", 2, Unique("sync:store"))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	var count int64
+	q.db.Instance().Model(&Job{}).Where("status = ?", StatusPending).Count(&count)
+	if count != 1 {
+		t.Errorf("queue has %d pending jobs, want job enqueued after the dead one", count)
+	}
+}
+
+func TestFetchSkipsLockedJobs(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t)
+	for i := 0; i < 2; i++ {
+		err := q.EnqueueAt(ctx, "job", i, time.Now().Add(time.Duration(i-2)*time.Second))
+		if err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	// Another worker keeps the first job locked within its transaction
+	tx := q.db.Instance().Begin()
+	defer tx.Rollback()
+	var locked Job
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("run_at").First(&locked).Error
+	if err != nil {
+		t.Fatalf("failed to lock job: %v", err)
+	}
+
+	job, err := q.fetch()
+	if err != nil {
+		t.Fatalf("fetch() error = %v", err)
+	}
+	if job == nil || job.ID == locked.ID || job.Payload != "1" {
+		t.Fatalf("fetch() = %+v, want the second job, which isn't locked", job)
+	}
+	if job.Status != StatusRunning || job.LockedAt == nil || job.Attempts != 1 {
+		t.Errorf("fetched job = %+v, want running with 1 attempt", job)
+	}
+}
+
+func TestFetchAbandonedJob(t *testing.T) {
+	q := newTestQueue(t, LockTimeout(time.Minute))
+	err := q.Enqueue(context.Background(), "job", nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	job, err := q.fetch()
+	if err != nil || job == nil {
+		t.Fatalf("fetch() = %+v, %v, want job", job, err)
+	}
+
+	// Running job isn't fetched again until its lock times out
+	fetched, err := q.fetch()
+	if err != nil || fetched != nil {
+		t.Fatalf("fetch() = %+v, %v, want running job not fetched", fetched, err)
+	}
+
+	q.db.Instance().Model(job).Update("locked_at", time.Now().Add(-2*time.Minute))
+	fetched, err = q.fetch()
+	if err != nil || fetched == nil || fetched.ID != job.ID || fetched.Attempts != 2 {
+		t.Errorf("fetch() = %+v, %v, want abandoned job fetched for the second attempt", fetched, err)
+	}
+}
+
+func TestHeartbeatKeepsJobLocked(t *testing.T) {
+	lockTimeout := 300 * time.Millisecond
+	q := newTestQueue(t, LockTimeout(lockTimeout))
+
+	fetchedTwice := make(chan bool, 1)
+	q.Register("slow", func(ctx context.Context, payload []byte) error {
+		// Job outlives its lock timeout, while another worker tries to fetch it
+		time.Sleep(3 * lockTimeout)
+		fetched, err := q.fetch()
+		fetchedTwice <- err == nil && fetched != nil
+		return nil
+	})
+
+	err := q.Enqueue(context.Background(), "slow", nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	fetchAndProcess(t, q)
+
+	if <-fetchedTwice {
+		t.Error("running job is fetched by another worker after its lock timeout")
+	}
+}
@@ -35,11 +35,23 @@ export function ProductsCard() {
 
   const handlePopulate = async () => {
     setIsLoading(true);
-    const response = await fetch("/api/products/create");
+    const response = await fetch("/api/products/create", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ count: 5 }),
+    });
 
     if (response.ok) {
+      const { created, failed } = await response.json();
       await refetchProductCount();
-      setToastProps({ content: "5 products created!" });
+      setToastProps(
+        failed > 0
+          ? {
+              content: `${created} products created, ${failed} failed`,
+              error: true,
+            }
+          : { content: `${created} products created!` }
+      );
     } else {
       setIsLoading(false);
       setToastProps({